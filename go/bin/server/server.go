@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	redis "github.com/hbina/radish"
+	"github.com/hbina/radish/internal/commands"
 )
 
 const (
@@ -25,6 +26,7 @@ func main() {
 
 	port := PORT
 	logging := true
+	configs := commands.GenerateConfigs()
 
 	for idx := 0; idx < len(args); idx++ {
 		arg := strings.ToLower(string(args[idx]))
@@ -52,12 +54,27 @@ func main() {
 			}
 		default:
 			{
-				fmt.Printf("Unknown parameter '%s'\n", string(arg))
-				os.Exit(1)
+				// Any configuration can be passed as `--<name> <value>`
+				name := strings.TrimPrefix(arg, "--")
+				_, exists := configs[name]
+
+				if !strings.HasPrefix(arg, "--") || !exists {
+					fmt.Printf("Unknown parameter '%s'\n", string(arg))
+					os.Exit(1)
+				}
+
+				idx++
+				if idx >= len(args) {
+					log.Fatalf("Need to provide the value of '%s'", name)
+				}
+
+				configs[name] = args[idx]
 			}
 		}
 	}
 
+	configs["port"] = strconv.Itoa(port)
+
 	fmt.Printf("Starting server at port %d\n", port)
-	redis.Run(port, logging)
+	redis.RunWithConfigs(configs, logging)
 }
@@ -6,6 +6,7 @@ require (
 	github.com/go-redis/redis v6.15.9+incompatible
 	github.com/pkg/errors v0.8.0
 	github.com/stretchr/testify v1.5.1
+	github.com/yuin/gopher-lua v1.1.1
 )
 
 require (
@@ -33,7 +33,7 @@ func BzmpopCommand(c *pkg.Client, args [][]byte) *pkg.BlockedCommand {
 	ttl := time.Time{}
 
 	if timeout64 > 0 {
-		ttl = time.Now().Add(time.Duration(timeout64 * float64(time.Second)))
+		ttl = c.Redis().Now().Add(time.Duration(timeout64 * float64(time.Second)))
 	}
 
 	numKeyStr := string(args[2])
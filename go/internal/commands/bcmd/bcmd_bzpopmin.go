@@ -30,7 +30,7 @@ func BzpopminCommand(c *pkg.Client, args [][]byte) *pkg.BlockedCommand {
 	ttl := time.Time{}
 
 	if timeout64 > 0 {
-		ttl = time.Now().Add(time.Duration(timeout64 * float64(time.Second)))
+		ttl = c.Redis().Now().Add(time.Duration(timeout64 * float64(time.Second)))
 	}
 
 	keys := make([]string, 0, len(args)-2)
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/acl/
+// ACL SETUSER username [rule [rule ...]]
+// ACL GETUSER username
+// ACL DELUSER username [username ...]
+// ACL LIST
+// ACL USERS
+// ACL WHOAMI
+// ACL CAT [category]
+// ACL LOG [count | RESET]
+// ACL SAVE
+// ACL LOAD
+func AclCommand(c *pkg.Client, args [][]byte) {
+	if len(args) < 2 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	subcommand := strings.ToLower(string(args[1]))
+
+	switch subcommand {
+	case "setuser":
+		if len(args) < 3 {
+			c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, "acl|setuser"))
+			return
+		}
+
+		rules := make([]string, 0, len(args)-3)
+
+		for _, arg := range args[3:] {
+			rules = append(rules, string(arg))
+		}
+
+		err := c.Redis().AclSetUser(string(args[2]), rules)
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+
+		c.Conn().WriteString("OK")
+	case "getuser":
+		if len(args) != 3 {
+			c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, "acl|getuser"))
+			return
+		}
+
+		info, exists := c.Redis().AclGetUser(string(args[2]))
+
+		if !exists {
+			if c.R3 {
+				c.Conn().WriteNull()
+			} else {
+				c.Conn().WriteNullArray()
+			}
+			return
+		}
+
+		if c.R3 {
+			c.Conn().WriteMap(6 * 2)
+		} else {
+			c.Conn().WriteArray(6 * 2)
+		}
+
+		c.Conn().WriteBulkString("flags")
+		c.Conn().WriteArray(len(info.Flags))
+		for _, f := range info.Flags {
+			c.Conn().WriteBulkString(f)
+		}
+		c.Conn().WriteBulkString("passwords")
+		c.Conn().WriteArray(len(info.Passwords))
+		for _, p := range info.Passwords {
+			c.Conn().WriteBulkString(p)
+		}
+		c.Conn().WriteBulkString("commands")
+		c.Conn().WriteBulkString(info.Commands)
+		c.Conn().WriteBulkString("keys")
+		c.Conn().WriteBulkString(info.Keys)
+		c.Conn().WriteBulkString("channels")
+		c.Conn().WriteBulkString(info.Channels)
+		c.Conn().WriteBulkString("selectors")
+		c.Conn().WriteArray(0)
+	case "deluser":
+		if len(args) < 3 {
+			c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, "acl|deluser"))
+			return
+		}
+
+		names := make([]string, 0, len(args)-2)
+
+		for _, arg := range args[2:] {
+			names = append(names, string(arg))
+		}
+
+		count, err := c.Redis().AclDelUser(names...)
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+
+		c.Conn().WriteInt(count)
+	case "list":
+		if len(args) != 2 {
+			c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, "acl|list"))
+			return
+		}
+
+		writeBulkStrings(c, c.Redis().AclList())
+	case "users":
+		if len(args) != 2 {
+			c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, "acl|users"))
+			return
+		}
+
+		writeBulkStrings(c, c.Redis().AclUsernames())
+	case "whoami":
+		if len(args) != 2 {
+			c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, "acl|whoami"))
+			return
+		}
+
+		c.Conn().WriteBulkString(c.User().Name())
+	case "cat":
+		if len(args) == 2 {
+			writeBulkStrings(c, pkg.AclCategoryNames())
+		} else if len(args) == 3 {
+			names, ok := c.Redis().AclCategoryCommands(string(args[2]))
+
+			if !ok {
+				c.Conn().WriteError(fmt.Sprintf("ERR Unknown category '%s'", string(args[2])))
+				return
+			}
+
+			writeBulkStrings(c, names)
+		} else {
+			c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, "acl|cat"))
+		}
+	case "log":
+		aclLog(c, args)
+	case "save":
+		err := c.Redis().AclSave()
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+
+		c.Conn().WriteString("OK")
+	case "load":
+		err := c.Redis().AclLoad()
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+
+		c.Conn().WriteString("OK")
+	default:
+		c.Conn().WriteError(fmt.Sprintf("ERR unknown subcommand '%s'. Try ACL HELP.", string(args[1])))
+	}
+}
+
+func aclLog(c *pkg.Client, args [][]byte) {
+	count := 10
+
+	if len(args) == 3 {
+		if strings.ToLower(string(args[2])) == "reset" {
+			c.Redis().AclLogReset()
+			c.Conn().WriteString("OK")
+			return
+		}
+
+		count64, err := strconv.ParseInt(string(args[2]), 10, 64)
+
+		if err != nil || count64 < 0 {
+			c.Conn().WriteError(util.InvalidIntErr)
+			return
+		}
+
+		count = int(count64)
+	} else if len(args) > 3 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, "acl|log"))
+		return
+	}
+
+	entries := c.Redis().AclLog(count)
+	now := c.Redis().Now()
+
+	c.Conn().WriteArray(len(entries))
+
+	for _, e := range entries {
+		if c.R3 {
+			c.Conn().WriteMap(10 * 2)
+		} else {
+			c.Conn().WriteArray(10 * 2)
+		}
+
+		c.Conn().WriteBulkString("count")
+		c.Conn().WriteInt(e.Count)
+		c.Conn().WriteBulkString("reason")
+		c.Conn().WriteBulkString(e.Reason)
+		c.Conn().WriteBulkString("context")
+		c.Conn().WriteBulkString(e.Context)
+		c.Conn().WriteBulkString("object")
+		c.Conn().WriteBulkString(e.Object)
+		c.Conn().WriteBulkString("username")
+		c.Conn().WriteBulkString(e.Username)
+		c.Conn().WriteBulkString("age-seconds")
+		age := float64(now.Sub(e.Created).Milliseconds()) / 1000
+		if c.R3 {
+			c.Conn().WriteFloat64(age)
+		} else {
+			c.Conn().WriteBulkString(strconv.FormatFloat(age, 'f', 3, 64))
+		}
+		c.Conn().WriteBulkString("client-info")
+		c.Conn().WriteBulkString(e.ClientInfo)
+		c.Conn().WriteBulkString("entry-id")
+		c.Conn().WriteInt64(e.EntryId)
+		c.Conn().WriteBulkString("timestamp-created")
+		c.Conn().WriteInt64(e.Created.UnixMilli())
+		c.Conn().WriteBulkString("timestamp-last-updated")
+		c.Conn().WriteInt64(e.Updated.UnixMilli())
+	}
+}
+
+func writeBulkStrings(c *pkg.Client, values []string) {
+	c.Conn().WriteArray(len(values))
+
+	for _, v := range values {
+		c.Conn().WriteBulkString(v)
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/types"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/append/
+// APPEND key value
+func AppendCommand(c *pkg.Client, args [][]byte) {
+	if len(args) != 3 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	key := string(args[1])
+	value := args[2]
+	db := c.Db()
+
+	maybeItem, ttl := db.Get(key)
+
+	if maybeItem == nil {
+		db.Set(key, types.NewString(string(value)), time.Time{})
+		c.Conn().WriteInt(len(value))
+		return
+	} else if maybeItem.Type() != types.ValueTypeString {
+		c.Conn().WriteError(util.WrongTypeErr)
+		return
+	}
+
+	item := maybeItem.(*types.String)
+
+	if !checkStringLength(c, int64(item.Len()), int64(len(value))) {
+		return
+	}
+
+	// The string is appended in place, Set only accounts for it
+	item.Append(value)
+	db.Set(key, item, ttl)
+	c.Conn().WriteInt(item.Len())
+}
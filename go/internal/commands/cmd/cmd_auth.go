@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/auth/
+// AUTH [username] password
+func AuthCommand(c *pkg.Client, args [][]byte) {
+	if len(args) < 2 || len(args) > 3 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	username := "default"
+	password := string(args[1])
+
+	if len(args) == 3 {
+		username = string(args[1])
+		password = string(args[2])
+	} else if !c.Redis().RequiresPass() {
+		// Mimic the old behavior of Redis when there is no password set
+		c.Conn().WriteError(util.NoPasswordErr)
+		return
+	}
+
+	if !c.Redis().Authenticate(c, username, password) {
+		c.Conn().WriteError(util.WrongPassErr)
+		return
+	}
+
+	c.Conn().WriteString("OK")
+}
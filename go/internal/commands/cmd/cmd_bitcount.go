@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/types"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/bitcount/
+// BITCOUNT key [start end [BYTE | BIT]]
+func BitcountCommand(c *pkg.Client, args [][]byte) {
+	if len(args) < 2 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	if len(args) == 3 || len(args) > 5 {
+		c.Conn().WriteError(util.SyntaxErr)
+		return
+	}
+
+	key := string(args[1])
+	start, end := int64(0), int64(-1)
+	isBit := false
+
+	if len(args) >= 4 {
+		var ok bool
+		start, end, isBit, ok = parseBitRange(c, args[2], args[3], args[4:])
+
+		if !ok {
+			return
+		}
+	}
+
+	maybeItem, _ := c.Db().Get(key)
+
+	if maybeItem == nil {
+		c.Conn().WriteInt(0)
+		return
+	}
+
+	if maybeItem.Type() != types.ValueTypeString {
+		c.Conn().WriteError(util.WrongTypeErr)
+		return
+	}
+
+	str := maybeItem.(*types.String).AsBytes()
+
+	// Both ends are negative but the range is reversed
+	if start < 0 && end < 0 && start > end {
+		c.Conn().WriteInt(0)
+		return
+	}
+
+	start, end = bitRange(start, end, len(str), isBit)
+
+	if start > end {
+		c.Conn().WriteInt(0)
+		return
+	}
+
+	c.Conn().WriteInt(util.BitCount(str, int(start), int(end)))
+}
+
+// parseBitRange parses the range of BITCOUNT and BITPOS and its unit.
+func parseBitRange(c *pkg.Client, startArg []byte, endArg []byte, unit [][]byte) (int64, int64, bool, bool) {
+	start, err := strconv.ParseInt(string(startArg), 10, 64)
+
+	if err != nil {
+		c.Conn().WriteError(util.InvalidIntErr)
+		return 0, 0, false, false
+	}
+
+	end, err := strconv.ParseInt(string(endArg), 10, 64)
+
+	if err != nil {
+		c.Conn().WriteError(util.InvalidIntErr)
+		return 0, 0, false, false
+	}
+
+	isBit := false
+
+	if len(unit) > 0 {
+		switch strings.ToLower(string(unit[0])) {
+		case "byte":
+		case "bit":
+			isBit = true
+		default:
+			c.Conn().WriteError(util.SyntaxErr)
+			return 0, 0, false, false
+		}
+	}
+
+	return start, end, isBit, true
+}
+
+// bitRange converts the inclusive range of BITCOUNT and BITPOS over a
+// string of length bytes into a range of bits. The negative ends count
+// from the end of the string. The range is empty if start > end.
+func bitRange(start int64, end int64, length int, isBit bool) (int64, int64) {
+	total := int64(length)
+
+	if isBit {
+		total *= 8
+	}
+
+	if start < 0 {
+		start += total
+	}
+
+	if end < 0 {
+		end += total
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if end < 0 {
+		end = 0
+	}
+
+	if end >= total {
+		end = total - 1
+	}
+
+	// The range is empty but the conversion to bits must not overflow
+	if start > total {
+		start = total
+	}
+
+	if !isBit {
+		return start * 8, end*8 + 7
+	}
+
+	return start, end
+}
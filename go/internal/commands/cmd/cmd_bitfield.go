@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/types"
+	"github.com/hbina/radish/internal/util"
+)
+
+// The bit offsets of BITFIELD are limited to the size of a string of 512MB.
+const bitfieldMaxOffset = 512 * 1024 * 1024 * 8
+
+const (
+	bitfieldGet = iota
+	bitfieldSet
+	bitfieldIncrBy
+)
+
+// bitfieldOp is a subcommand of BITFIELD.
+type bitfieldOp struct {
+	kind     int
+	signed   bool
+	bits     int
+	offset   int
+	value    int64 // The value of SET or the increment of INCRBY
+	overflow int
+}
+
+// https://redis.io/commands/bitfield/
+// BITFIELD key [GET encoding offset | [OVERFLOW <WRAP | SAT | FAIL>]
+// <SET encoding offset value | INCRBY encoding offset increment>
+// [GET encoding offset | [OVERFLOW <WRAP | SAT | FAIL>]
+// <SET encoding offset value | INCRBY encoding offset increment> ...]]
+func BitfieldCommand(c *pkg.Client, args [][]byte) {
+	bitfield(c, args, false)
+}
+
+// https://redis.io/commands/bitfield_ro/
+// BITFIELD_RO key [GET encoding offset [GET encoding offset ...]]
+func BitfieldRoCommand(c *pkg.Client, args [][]byte) {
+	bitfield(c, args, true)
+}
+
+func bitfield(c *pkg.Client, args [][]byte, readOnly bool) {
+	if len(args) < 2 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	key := string(args[1])
+	ops := make([]bitfieldOp, 0)
+	overflow := util.BITFIELD_OVERFLOW_WRAP
+	// The length of the string needed by the writes
+	length := 0
+
+	for i := 2; i < len(args); i++ {
+		subcommand := strings.ToLower(string(args[i]))
+		moreArgs := len(args) - i - 1
+
+		if subcommand == "overflow" && moreArgs >= 1 {
+			switch strings.ToLower(string(args[i+1])) {
+			case "wrap":
+				overflow = util.BITFIELD_OVERFLOW_WRAP
+			case "sat":
+				overflow = util.BITFIELD_OVERFLOW_SAT
+			case "fail":
+				overflow = util.BITFIELD_OVERFLOW_FAIL
+			default:
+				c.Conn().WriteError("ERR Invalid OVERFLOW type specified")
+				return
+			}
+
+			i++
+			continue
+		}
+
+		op := bitfieldOp{overflow: overflow}
+
+		switch {
+		case subcommand == "get" && moreArgs >= 2:
+			op.kind = bitfieldGet
+		case subcommand == "set" && moreArgs >= 3:
+			op.kind = bitfieldSet
+		case subcommand == "incrby" && moreArgs >= 3:
+			op.kind = bitfieldIncrBy
+		default:
+			c.Conn().WriteError(util.SyntaxErr)
+			return
+		}
+
+		signed, bits, ok := parseBitfieldType(string(args[i+1]))
+
+		if !ok {
+			c.Conn().WriteError("ERR Invalid bitfield type. Use something like i16 u8. " +
+				"Note that u64 is not supported but i64 is.")
+			return
+		}
+
+		offset, ok := parseBitfieldOffset(string(args[i+2]), bits)
+
+		if !ok {
+			c.Conn().WriteError("ERR bit offset is not an integer or out of range")
+			return
+		}
+
+		op.signed, op.bits, op.offset = signed, bits, offset
+		i += 2
+
+		if op.kind != bitfieldGet {
+			if readOnly {
+				c.Conn().WriteError("ERR BITFIELD_RO only supports the GET subcommand")
+				return
+			}
+
+			value, err := strconv.ParseInt(string(args[i+1]), 10, 64)
+
+			if err != nil {
+				c.Conn().WriteError(util.InvalidIntErr)
+				return
+			}
+
+			op.value = value
+			i++
+
+			if end := (offset + bits + 7) / 8; end > length {
+				length = end
+			}
+		}
+
+		ops = append(ops, op)
+	}
+
+	db := c.Db()
+	maybeItem, ttl := db.Get(key)
+	var str []byte
+
+	if maybeItem != nil {
+		if maybeItem.Type() != types.ValueTypeString {
+			c.Conn().WriteError(util.WrongTypeErr)
+			return
+		}
+
+		str = maybeItem.(*types.String).AsBytes()
+	}
+
+	// The string is extended for the writes beforehand, even those that fail
+	grown := length > len(str)
+
+	if grown {
+		str = append(str, make([]byte, length-len(str))...)
+	}
+
+	changes := 0
+	c.Conn().WriteArray(len(ops))
+
+	for _, op := range ops {
+		if op.signed {
+			old := util.GetSignedBitfield(str, op.offset, op.bits)
+
+			if op.kind == bitfieldGet {
+				c.Conn().WriteInt64(old)
+				continue
+			}
+
+			value, incr := op.value, int64(0)
+
+			if op.kind == bitfieldIncrBy {
+				value, incr = old, op.value
+			}
+
+			overflowed, limit := util.CheckSignedBitfieldOverflow(value, incr, op.bits, op.overflow)
+
+			if overflowed && op.overflow == util.BITFIELD_OVERFLOW_FAIL {
+				writeBitfieldNull(c)
+				continue
+			}
+
+			if overflowed {
+				value = limit
+			} else {
+				value += incr
+			}
+
+			util.SetBitfield(str, op.offset, op.bits, uint64(value))
+			changes++
+
+			if op.kind == bitfieldSet {
+				c.Conn().WriteInt64(old)
+			} else {
+				c.Conn().WriteInt64(value)
+			}
+		} else {
+			old := util.GetUnsignedBitfield(str, op.offset, op.bits)
+
+			if op.kind == bitfieldGet {
+				c.Conn().WriteInt64(int64(old))
+				continue
+			}
+
+			value, incr := uint64(op.value), int64(0)
+
+			if op.kind == bitfieldIncrBy {
+				value, incr = old, op.value
+			}
+
+			overflowed, limit := util.CheckUnsignedBitfieldOverflow(value, incr, op.bits, op.overflow)
+
+			if overflowed && op.overflow == util.BITFIELD_OVERFLOW_FAIL {
+				writeBitfieldNull(c)
+				continue
+			}
+
+			if overflowed {
+				value = limit
+			} else {
+				value += uint64(incr)
+			}
+
+			util.SetBitfield(str, op.offset, op.bits, value)
+			changes++
+
+			if op.kind == bitfieldSet {
+				c.Conn().WriteInt64(int64(old))
+			} else {
+				c.Conn().WriteInt64(int64(value))
+			}
+		}
+	}
+
+	if changes > 0 || grown {
+		db.Set(key, types.NewStringFromBytes(str), ttl)
+	}
+}
+
+// parseBitfieldType parses an encoding such as i16 or u8. The unsigned
+// integers have up to 63 bits so that they fit in the integer replies.
+func parseBitfieldType(s string) (bool, int, bool) {
+	if len(s) < 2 || (s[0] != 'i' && s[0] != 'I' && s[0] != 'u' && s[0] != 'U') {
+		return false, 0, false
+	}
+
+	signed := s[0] == 'i' || s[0] == 'I'
+	bits, err := strconv.Atoi(s[1:])
+
+	if err != nil || bits < 1 || (signed && bits > 64) || (!signed && bits > 63) {
+		return false, 0, false
+	}
+
+	return signed, bits, true
+}
+
+// parseBitfieldOffset parses an offset in bits or, if it is prefixed with
+// #, in multiples of the width of the field.
+func parseBitfieldOffset(s string, bits int) (int, bool) {
+	multiply := strings.HasPrefix(s, "#")
+	offset, err := strconv.ParseInt(strings.TrimPrefix(s, "#"), 10, 64)
+
+	if err != nil || offset < 0 || offset >= bitfieldMaxOffset {
+		return 0, false
+	}
+
+	if multiply {
+		offset *= int64(bits)
+	}
+
+	if offset+int64(bits) > bitfieldMaxOffset {
+		return 0, false
+	}
+
+	return int(offset), true
+}
+
+func writeBitfieldNull(c *pkg.Client) {
+	if c.R3 {
+		c.Conn().WriteNull()
+	} else {
+		c.Conn().WriteNullBulk()
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/types"
+	"github.com/hbina/radish/internal/util"
+)
+
+var bitops = map[string]int{
+	"and":   util.BITOP_AND,
+	"or":    util.BITOP_OR,
+	"xor":   util.BITOP_XOR,
+	"not":   util.BITOP_NOT,
+	"diff":  util.BITOP_DIFF,
+	"andor": util.BITOP_ANDOR,
+	"one":   util.BITOP_ONE,
+}
+
+// https://redis.io/commands/bitop/
+// BITOP <AND | OR | XOR | NOT | DIFF | ANDOR | ONE> destkey key [key ...]
+func BitopCommand(c *pkg.Client, args [][]byte) {
+	if len(args) < 4 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	name := strings.ToLower(string(args[1]))
+	op, exists := bitops[name]
+
+	if !exists {
+		c.Conn().WriteError(util.SyntaxErr)
+		return
+	}
+
+	destination := string(args[2])
+	keys := args[3:]
+
+	if op == util.BITOP_NOT && len(keys) != 1 {
+		c.Conn().WriteError("ERR BITOP NOT must be called with a single source key.")
+		return
+	}
+
+	if (op == util.BITOP_DIFF || op == util.BITOP_ANDOR) && len(keys) < 2 {
+		c.Conn().WriteError(fmt.Sprintf("ERR BITOP %s must be called with at least two source keys.", strings.ToUpper(name)))
+		return
+	}
+
+	db := c.Db()
+	srcs := make([][]byte, 0, len(keys))
+
+	for _, key := range keys {
+		maybeItem, _ := db.Get(string(key))
+
+		// A missing key is an empty string
+		if maybeItem == nil {
+			srcs = append(srcs, nil)
+			continue
+		}
+
+		if maybeItem.Type() != types.ValueTypeString {
+			c.Conn().WriteError(util.WrongTypeErr)
+			return
+		}
+
+		srcs = append(srcs, maybeItem.(*types.String).AsBytes())
+	}
+
+	res := util.BitOp(op, srcs)
+
+	if len(res) == 0 {
+		db.Delete(destination)
+	} else {
+		db.Set(destination, types.NewString(string(res)), time.Time{})
+	}
+
+	c.Conn().WriteInt(len(res))
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/types"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/bitpos/
+// BITPOS key bit [start [end [BYTE | BIT]]]
+func BitposCommand(c *pkg.Client, args [][]byte) {
+	if len(args) < 3 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	if len(args) > 6 {
+		c.Conn().WriteError(util.SyntaxErr)
+		return
+	}
+
+	key := string(args[1])
+	bitStr := string(args[2])
+
+	if bitStr != "0" && bitStr != "1" {
+		c.Conn().WriteError("ERR The bit argument must be 1 or 0.")
+		return
+	}
+
+	bit := bitStr == "1"
+	start, end := int64(0), int64(-1)
+	endGiven := len(args) >= 5
+	isBit := false
+
+	switch len(args) {
+	case 3:
+	case 4:
+		var err error
+		start, err = strconv.ParseInt(string(args[3]), 10, 64)
+
+		if err != nil {
+			c.Conn().WriteError(util.InvalidIntErr)
+			return
+		}
+	default:
+		var ok bool
+		start, end, isBit, ok = parseBitRange(c, args[3], args[4], args[5:])
+
+		if !ok {
+			return
+		}
+	}
+
+	maybeItem, _ := c.Db().Get(key)
+
+	// A missing key is an empty string, which is padded with zeros
+	if maybeItem == nil {
+		if bit {
+			c.Conn().WriteInt(-1)
+		} else {
+			c.Conn().WriteInt(0)
+		}
+		return
+	}
+
+	if maybeItem.Type() != types.ValueTypeString {
+		c.Conn().WriteError(util.WrongTypeErr)
+		return
+	}
+
+	str := maybeItem.(*types.String).AsBytes()
+	start, end = bitRange(start, end, len(str), isBit)
+
+	if start > end {
+		c.Conn().WriteInt(-1)
+		return
+	}
+
+	pos := util.BitPos(str, bit, int(start), int(end))
+
+	// The string is padded with zeros unless the range has an end
+	if pos < 0 && !bit && !endGiven {
+		pos = int(end>>3+1) * 8
+	}
+
+	c.Conn().WriteInt(pos)
+}
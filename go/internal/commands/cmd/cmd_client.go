@@ -2,49 +2,501 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hbina/radish/internal/pkg"
 	"github.com/hbina/radish/internal/util"
 )
 
-// https://redis.io/commands/client-getname/
-// https://redis.io/commands/client-setname/
+// https://redis.io/commands/client-list/
+// CLIENT ID
+// CLIENT INFO
+// CLIENT LIST [TYPE normal|master|replica|pubsub] [ID client-id [client-id ...]]
+// CLIENT GETNAME
+// CLIENT SETNAME connection-name
+// CLIENT KILL ip:port
+// CLIENT KILL [ID client-id] [TYPE normal|master|slave|replica|pubsub] [USER username]
+// [ADDR ip:port] [LADDR ip:port] [SKIPME yes|no] [MAXAGE maxage]
+// CLIENT PAUSE timeout [WRITE|ALL]
+// CLIENT UNPAUSE
+// CLIENT REPLY ON|OFF|SKIP
+// CLIENT TRACKING ON|OFF [REDIRECT client-id] [PREFIX prefix [PREFIX prefix ...]]
+// [BCAST] [OPTIN] [OPTOUT] [NOLOOP]
+// CLIENT CACHING YES|NO
+// CLIENT GETREDIR
+// CLIENT TRACKINGINFO
+// CLIENT HELP
 func ClientCommand(c *pkg.Client, args [][]byte) {
 	if len(args) < 2 {
 		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
 		return
 	}
 
-	subcommand := string(args[1])
+	subcommand := strings.ToLower(string(args[1]))
 
-	if strings.ToLower(subcommand) == "getname" {
-		// Requires an extra argument for the name
-		if len(args) != 2 {
-			c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, string(args[0])))
-			return
+	switch {
+	case subcommand == "id" && len(args) == 2:
+		c.Conn().WriteInt64(int64(c.Id()))
+	case subcommand == "info" && len(args) == 2:
+		c.Conn().WriteBulkString(c.Info() + "\n")
+	case subcommand == "list":
+		clientList(c, args[2:])
+	case subcommand == "getname" && len(args) == 2:
+		if c.Name != nil {
+			c.Conn().WriteBulkString(*c.Name)
+		} else if c.R3 {
+			c.Conn().WriteNull()
+		} else {
+			c.Conn().WriteNullBulk()
 		}
+	case subcommand == "setname" && len(args) == 3:
+		newName := string(args[2])
 
-		if c.Name == nil {
-			c.Conn().WriteNull()
-			return
+		for _, ch := range newName {
+			if ch < '!' || ch > '~' {
+				c.Conn().WriteError("ERR Client names cannot contain spaces, newlines or special characters.")
+				return
+			}
+		}
+
+		if newName == "" {
+			c.Name = nil
 		} else {
-			c.Conn().WriteBulkString(*c.Name)
+			c.Name = &newName
+		}
+
+		c.Conn().WriteString("OK")
+	case subcommand == "kill" && len(args) >= 3:
+		clientKill(c, args[2:])
+	case subcommand == "pause" && (len(args) == 3 || len(args) == 4):
+		timeout, err := strconv.ParseInt(string(args[2]), 10, 64)
+
+		if err != nil || timeout < 0 {
+			c.Conn().WriteError("ERR timeout is not an integer or out of range")
+			return
+		}
+
+		all := true
+
+		if len(args) == 4 {
+			switch strings.ToLower(string(args[3])) {
+			case "write":
+				all = false
+			case "all":
+			default:
+				c.Conn().WriteError("ERR CLIENT PAUSE mode must be WRITE or ALL")
+				return
+			}
+		}
+
+		c.Redis().PauseClients(time.Duration(timeout)*time.Millisecond, all)
+		c.Conn().WriteString("OK")
+	case subcommand == "unpause" && len(args) == 2:
+		c.Redis().UnpauseClients()
+		c.Conn().WriteString("OK")
+	case subcommand == "reply" && len(args) == 3:
+		mode := strings.ToLower(string(args[2]))
+
+		if mode != "on" && mode != "off" && mode != "skip" {
+			c.Conn().WriteError(util.SyntaxErr)
 			return
 		}
-	} else if strings.ToLower(subcommand) == "setname" {
-		if len(args) < 3 {
-			c.Conn().WriteError(fmt.Sprintf("Unknown subcommand or wrong number of arguments for '%s'. Try CONFIG HELP.", string(args[1])))
+
+		c.SetReplyMode(mode)
+		c.Conn().WriteString("OK")
+	case subcommand == "tracking" && len(args) >= 3:
+		clientTracking(c, args[2:])
+	case subcommand == "caching" && len(args) == 3:
+		var err error
+
+		switch strings.ToLower(string(args[2])) {
+		case "yes":
+			err = c.Redis().SetTrackingCaching(c, true)
+		case "no":
+			err = c.Redis().SetTrackingCaching(c, false)
+		default:
+			c.Conn().WriteError(util.SyntaxErr)
 			return
 		}
 
-		newName := string(args[2])
-		c.Name = &newName
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
 
 		c.Conn().WriteString("OK")
+	case subcommand == "getredir" && len(args) == 2:
+		if tracking := c.Redis().GetTracking(c); tracking != nil {
+			c.Conn().WriteInt64(int64(tracking.Redirect))
+		} else {
+			c.Conn().WriteInt(-1)
+		}
+	case subcommand == "trackinginfo" && len(args) == 2:
+		clientTrackingInfo(c)
+	case subcommand == "help" && len(args) == 2:
+		writeHelp(c, "CLIENT", []string{
+			"GETNAME",
+			"    Return the name of the current connection.",
+			"CACHING (YES|NO)",
+			"    Enable/disable tracking of the keys for next command in OPTIN/OPTOUT modes.",
+			"GETREDIR",
+			"    Return the client ID we are redirecting to when tracking is enabled.",
+			"ID",
+			"    Return the ID of the current connection.",
+			"INFO",
+			"    Return information about the current client connection.",
+			"KILL <ip:port>",
+			"    Kill connection made from <ip:port>.",
+			"KILL <option> <value> [<option> <value> [...]]",
+			"    Kill connections. Options are:",
+			"    * ADDR (<ip:port>|<unixsocket>:0)",
+			"      Kill connections made from the specified address",
+			"    * LADDR (<ip:port>|<unixsocket>:0)",
+			"      Kill connections made to specified local address",
+			"    * TYPE (NORMAL|MASTER|REPLICA|PUBSUB)",
+			"      Kill connections by type.",
+			"    * USER <username>",
+			"      Kill connections authenticated by <username>.",
+			"    * SKIPME (YES|NO)",
+			"      Skip killing current connection (default: yes).",
+			"    * ID <client-id>",
+			"      Kill connections by client id.",
+			"    * MAXAGE <maxage>",
+			"      Kill connections older than the specified age.",
+			"LIST [options ...]",
+			"    Return information about client connections. Options:",
+			"    * TYPE (NORMAL|MASTER|REPLICA|PUBSUB)",
+			"      Return clients of specified type.",
+			"    * ID <client-id> [<client-id> ...]",
+			"      Return clients of specified IDs only.",
+			"PAUSE <timeout> [WRITE|ALL]",
+			"    Suspend all, or just write, clients for <timeout> milliseconds.",
+			"UNPAUSE",
+			"    Stop the current client pause, resuming traffic.",
+			"REPLY (ON|OFF|SKIP)",
+			"    Control the replies sent to the current connection.",
+			"SETNAME <name>",
+			"    Assign the name <name> to the current connection.",
+			"TRACKING (ON|OFF) [REDIRECT <id>] [BCAST] [PREFIX <prefix> [...]]",
+			"         [OPTIN] [OPTOUT] [NOLOOP]",
+			"    Control server assisted client side caching.",
+			"TRACKINGINFO",
+			"    Report tracking status for the current connection.",
+		})
+	default:
+		c.Conn().WriteError(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for '%s'. Try CLIENT HELP.", string(args[1])))
+	}
+}
+
+// normalizeClientType returns the type of client as returned by Client.Type,
+// or false if it is not a valid type.
+func normalizeClientType(arg []byte) (string, bool) {
+	switch t := strings.ToLower(string(arg)); t {
+	case "normal", "master", "pubsub":
+		return t, true
+	case "replica", "slave":
+		return "replica", true
+	}
+
+	return "", false
+}
+
+func clientList(c *pkg.Client, args [][]byte) {
+	clientType := ""
+	var ids map[uint64]struct{}
+
+	if len(args) == 2 && strings.ToLower(string(args[0])) == "type" {
+		t, ok := normalizeClientType(args[1])
+
+		if !ok {
+			c.Conn().WriteError(fmt.Sprintf("ERR Unknown client type '%s'", string(args[1])))
+			return
+		}
+
+		clientType = t
+	} else if len(args) >= 2 && strings.ToLower(string(args[0])) == "id" {
+		ids = make(map[uint64]struct{}, len(args)-1)
+
+		for _, arg := range args[1:] {
+			id, err := strconv.ParseUint(string(arg), 10, 64)
+
+			if err != nil || id == 0 {
+				c.Conn().WriteError("ERR Invalid client ID")
+				return
+			}
+
+			ids[id] = struct{}{}
+		}
+	} else if len(args) != 0 {
+		c.Conn().WriteError(util.SyntaxErr)
 		return
+	}
+
+	var str strings.Builder
+
+	for _, other := range c.Redis().Clients() {
+		if clientType != "" && other.Type() != clientType {
+			continue
+		}
+
+		if _, ok := ids[other.Id()]; ids != nil && !ok {
+			continue
+		}
+
+		str.WriteString(other.Info())
+		str.WriteString("\n")
+	}
+
+	c.Conn().WriteBulkString(str.String())
+}
+
+func clientKill(c *pkg.Client, args [][]byte) {
+	// Old form with only the address of the client
+	if len(args) == 1 {
+		addr := string(args[0])
+
+		for _, other := range c.Redis().Clients() {
+			if other.Addr() == addr {
+				killClient(c, other)
+				c.Conn().WriteString("OK")
+				return
+			}
+		}
+
+		c.Conn().WriteError("ERR No such client")
+		return
+	}
+
+	if len(args)%2 != 0 {
+		c.Conn().WriteError(util.SyntaxErr)
+		return
+	}
+
+	var id uint64
+	var maxAge int64
+	clientType, addr, laddr, user := "", "", "", ""
+	skipMe := true
+
+	for i := 0; i < len(args); i += 2 {
+		value := args[i+1]
+
+		switch strings.ToLower(string(args[i])) {
+		case "id":
+			n, err := strconv.ParseUint(string(value), 10, 64)
+
+			if err != nil || n == 0 {
+				c.Conn().WriteError("ERR client-id should be greater than 0")
+				return
+			}
+
+			id = n
+		case "type":
+			t, ok := normalizeClientType(value)
+
+			if !ok {
+				c.Conn().WriteError(fmt.Sprintf("ERR Unknown client type '%s'", string(value)))
+				return
+			}
+
+			clientType = t
+		case "addr":
+			addr = string(value)
+		case "laddr":
+			laddr = string(value)
+		case "user":
+			user = string(value)
+
+			if _, ok := c.Redis().AclGetUser(user); !ok {
+				c.Conn().WriteError(fmt.Sprintf("ERR No such user '%s'", user))
+				return
+			}
+		case "skipme":
+			switch strings.ToLower(string(value)) {
+			case "yes":
+				skipMe = true
+			case "no":
+				skipMe = false
+			default:
+				c.Conn().WriteError(util.SyntaxErr)
+				return
+			}
+		case "maxage":
+			n, err := strconv.ParseInt(string(value), 10, 64)
+
+			if err != nil || n <= 0 {
+				c.Conn().WriteError(util.SyntaxErr)
+				return
+			}
+
+			maxAge = n
+		default:
+			c.Conn().WriteError(util.SyntaxErr)
+			return
+		}
+	}
+
+	killed := 0
+
+	for _, other := range c.Redis().Clients() {
+		if (id != 0 && other.Id() != id) ||
+			(clientType != "" && other.Type() != clientType) ||
+			(addr != "" && other.Addr() != addr) ||
+			(laddr != "" && other.LocalAddr() != laddr) ||
+			(user != "" && other.User().Name() != user) ||
+			(maxAge != 0 && other.Age() < time.Duration(maxAge)*time.Second) ||
+			(skipMe && other == c) {
+			continue
+		}
+
+		killClient(c, other)
+		killed++
+	}
+
+	c.Conn().WriteInt(killed)
+}
+
+// killClient closes the other client, the current client is closed after its reply.
+func killClient(c *pkg.Client, other *pkg.Client) {
+	if other == c {
+		c.CloseAfterReply()
 	} else {
-		c.Conn().WriteError(fmt.Sprintf("Unknown subcommand '%s'. Try CONFIG HELP.", subcommand))
+		other.Close()
+	}
+}
+
+func clientTracking(c *pkg.Client, args [][]byte) {
+	on := false
+
+	switch strings.ToLower(string(args[0])) {
+	case "on":
+		on = true
+	case "off":
+	default:
+		c.Conn().WriteError(util.SyntaxErr)
 		return
 	}
+
+	var opts pkg.ClientTracking
+
+	for i := 1; i < len(args); i++ {
+		switch strings.ToLower(string(args[i])) {
+		case "redirect":
+			if i+1 >= len(args) {
+				c.Conn().WriteError(util.SyntaxErr)
+				return
+			}
+
+			if opts.Redirect != 0 {
+				c.Conn().WriteError("ERR A client can only redirect to a single other client")
+				return
+			}
+
+			id, err := strconv.ParseUint(string(args[i+1]), 10, 64)
+
+			if err != nil {
+				c.Conn().WriteError(util.InvalidIntErr)
+				return
+			}
+
+			opts.Redirect = id
+			i++
+		case "prefix":
+			if i+1 >= len(args) {
+				c.Conn().WriteError(util.SyntaxErr)
+				return
+			}
+
+			opts.Prefixes = append(opts.Prefixes, string(args[i+1]))
+			i++
+		case "bcast":
+			opts.Bcast = true
+		case "optin":
+			opts.OptIn = true
+		case "optout":
+			opts.OptOut = true
+		case "noloop":
+			opts.NoLoop = true
+		default:
+			c.Conn().WriteError(util.SyntaxErr)
+			return
+		}
+	}
+
+	if on {
+		if err := c.Redis().EnableTracking(c, opts); err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+	} else {
+		c.Redis().DisableTracking(c)
+	}
+
+	c.Conn().WriteString("OK")
+}
+
+func clientTrackingInfo(c *pkg.Client) {
+	tracking := c.Redis().GetTracking(c)
+	flags := []string{}
+	redirect := int64(-1)
+	prefixes := []string{}
+
+	if tracking == nil {
+		flags = append(flags, "off")
+	} else {
+		flags = append(flags, "on")
+		redirect = int64(tracking.Redirect)
+		prefixes = tracking.Prefixes
+
+		if tracking.Bcast {
+			flags = append(flags, "bcast")
+		}
+
+		if tracking.OptIn {
+			flags = append(flags, "optin")
+
+			if tracking.Caching() {
+				flags = append(flags, "caching-yes")
+			}
+		}
+
+		if tracking.OptOut {
+			flags = append(flags, "optout")
+
+			if tracking.Caching() {
+				flags = append(flags, "caching-no")
+			}
+		}
+
+		if tracking.NoLoop {
+			flags = append(flags, "noloop")
+		}
+
+		if tracking.RedirectBroken() {
+			flags = append(flags, "broken_redirect")
+		}
+	}
+
+	writeMapLen(c, 3)
+	c.Conn().WriteBulkString("flags")
+
+	if c.R3 {
+		c.Conn().WriteSet(len(flags))
+	} else {
+		c.Conn().WriteArray(len(flags))
+	}
+
+	for _, flag := range flags {
+		c.Conn().WriteBulkString(flag)
+	}
+
+	c.Conn().WriteBulkString("redirect")
+	c.Conn().WriteInt64(redirect)
+	c.Conn().WriteBulkString("prefixes")
+	c.Conn().WriteArray(len(prefixes))
+
+	for _, prefix := range prefixes {
+		c.Conn().WriteBulkString(prefix)
+	}
 }
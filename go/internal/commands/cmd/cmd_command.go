@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/command/
+// COMMAND
+// COMMAND COUNT
+// COMMAND DOCS [command-name [command-name ...]]
+// COMMAND GETKEYS command [arg [arg ...]]
+// COMMAND GETKEYSANDFLAGS command [arg [arg ...]]
+// COMMAND INFO [command-name [command-name ...]]
+// COMMAND LIST [FILTERBY <MODULE module-name | ACLCAT category | PATTERN pattern>]
+func CommandCommand(c *pkg.Client, args [][]byte) {
+	if len(args) == 1 {
+		infos := c.Redis().CommandInfos()
+		c.Conn().WriteArray(len(infos))
+
+		for _, info := range infos {
+			writeCommandInfo(c, info)
+		}
+		return
+	}
+
+	subcommand := strings.ToLower(string(args[1]))
+
+	switch subcommand {
+	case "count":
+		if len(args) != 2 {
+			c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, "command|count"))
+			return
+		}
+
+		c.Conn().WriteInt(len(c.Redis().CommandInfos()))
+	case "info":
+		infos := commandInfosOf(c, args[2:])
+		c.Conn().WriteArray(len(infos))
+
+		for _, info := range infos {
+			if info == nil && c.R3 {
+				c.Conn().WriteNull()
+			} else if info == nil {
+				c.Conn().WriteNullArray()
+			} else {
+				writeCommandInfo(c, info)
+			}
+		}
+	case "docs":
+		infos := commandInfosOf(c, args[2:])
+		count := 0
+
+		for _, info := range infos {
+			if info != nil {
+				count++
+			}
+		}
+
+		if c.R3 {
+			c.Conn().WriteMap(count * 2)
+		} else {
+			c.Conn().WriteArray(count * 2)
+		}
+
+		for _, info := range infos {
+			if info != nil {
+				c.Conn().WriteBulkString(info.Name)
+				writeCommandDocs(c, info)
+			}
+		}
+	case "getkeys", "getkeysandflags":
+		if len(args) < 3 {
+			c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, "command|"+subcommand))
+			return
+		}
+
+		info := c.Redis().LookupCommand(string(args[2]))
+
+		if info == nil {
+			c.Conn().WriteError("ERR Invalid command specified")
+			return
+		} else if !info.CheckArity(args[2:]) {
+			c.Conn().WriteError("ERR Invalid number of arguments specified for command")
+			return
+		}
+
+		indexes, flags := info.KeyIndexes(args[2:])
+
+		if len(indexes) == 0 {
+			c.Conn().WriteError("ERR The command has no key arguments")
+			return
+		}
+
+		c.Conn().WriteArray(len(indexes))
+
+		for i, idx := range indexes {
+			if subcommand == "getkeys" {
+				c.Conn().WriteBulkString(string(args[2+idx]))
+				continue
+			}
+
+			c.Conn().WriteArray(2)
+			c.Conn().WriteBulkString(string(args[2+idx]))
+			writeKeySpecFlags(c, flags[i])
+		}
+	case "list":
+		commandList(c, args)
+	default:
+		c.Conn().WriteError(fmt.Sprintf("ERR unknown subcommand '%s'. Try COMMAND HELP.", string(args[1])))
+	}
+}
+
+// commandInfosOf returns the metadata of the given commands or all
+// of them if there is none. Unknown commands are nil.
+func commandInfosOf(c *pkg.Client, names [][]byte) []*pkg.CommandInfo {
+	if len(names) == 0 {
+		return c.Redis().CommandInfos()
+	}
+
+	res := make([]*pkg.CommandInfo, 0, len(names))
+
+	for _, name := range names {
+		res = append(res, c.Redis().LookupCommand(string(name)))
+	}
+
+	return res
+}
+
+func commandList(c *pkg.Client, args [][]byte) {
+	filter := func(info *pkg.CommandInfo) bool { return true }
+
+	if len(args) == 5 && strings.ToLower(string(args[2])) == "filterby" {
+		value := string(args[4])
+
+		switch strings.ToLower(string(args[3])) {
+		case "module":
+			filter = func(info *pkg.CommandInfo) bool {
+				return info.Module == value
+			}
+		case "aclcat":
+			cat, _ := pkg.AclCategoryFlag(value)
+			filter = func(info *pkg.CommandInfo) bool {
+				return info.AclCategories()&cat != 0
+			}
+		case "pattern":
+			filter = func(info *pkg.CommandInfo) bool {
+				return util.StringMatch(value, info.Name, true)
+			}
+		default:
+			c.Conn().WriteError(util.SyntaxErr)
+			return
+		}
+	} else if len(args) != 2 {
+		c.Conn().WriteError(util.SyntaxErr)
+		return
+	}
+
+	names := make([]string, 0)
+
+	for _, info := range c.Redis().CommandInfos() {
+		if filter(info) {
+			names = append(names, info.Name)
+		}
+	}
+
+	writeBulkStrings(c, names)
+}
+
+func writeCommandInfo(c *pkg.Client, info *pkg.CommandInfo) {
+	first, last, step := info.LegacyKeyRange()
+
+	c.Conn().WriteArray(10)
+	c.Conn().WriteBulkString(info.Name)
+	c.Conn().WriteInt(info.Arity)
+	writeStatusSet(c, info.Flags())
+	c.Conn().WriteInt(first)
+	c.Conn().WriteInt(last)
+	c.Conn().WriteInt(step)
+
+	cats := pkg.AclCategoriesOf(info.AclCategories())
+
+	for i, cat := range cats {
+		cats[i] = "@" + cat
+	}
+
+	writeStatusSet(c, cats)
+
+	// Tips
+	c.Conn().WriteArray(0)
+
+	c.Conn().WriteArray(len(info.Keys))
+
+	for _, ks := range info.Keys {
+		writeMapLen(c, 3)
+		c.Conn().WriteBulkString("flags")
+		writeKeySpecFlags(c, ks.Flags)
+		c.Conn().WriteBulkString("begin_search")
+		writeMapLen(c, 2)
+		c.Conn().WriteBulkString("type")
+
+		if ks.Keyword != "" {
+			c.Conn().WriteBulkString("keyword")
+			c.Conn().WriteBulkString("spec")
+			writeMapLen(c, 2)
+			c.Conn().WriteBulkString("keyword")
+			c.Conn().WriteBulkString(ks.Keyword)
+			c.Conn().WriteBulkString("startfrom")
+			c.Conn().WriteInt(ks.First)
+		} else {
+			c.Conn().WriteBulkString("index")
+			c.Conn().WriteBulkString("spec")
+			writeMapLen(c, 1)
+			c.Conn().WriteBulkString("index")
+
+			if ks.NumKeys > 0 {
+				c.Conn().WriteInt(ks.NumKeys)
+			} else {
+				c.Conn().WriteInt(ks.First)
+			}
+		}
+
+		c.Conn().WriteBulkString("find_keys")
+		writeMapLen(c, 2)
+		c.Conn().WriteBulkString("type")
+
+		step := ks.Step
+
+		if step <= 0 {
+			step = 1
+		}
+
+		// The positions are relative to the index of begin_search
+		if ks.NumKeys > 0 {
+			c.Conn().WriteBulkString("keynum")
+			c.Conn().WriteBulkString("spec")
+			writeMapLen(c, 3)
+			c.Conn().WriteBulkString("keynumidx")
+			c.Conn().WriteInt(0)
+			c.Conn().WriteBulkString("firstkey")
+			c.Conn().WriteInt(ks.First - ks.NumKeys)
+			c.Conn().WriteBulkString("keystep")
+			c.Conn().WriteInt(step)
+		} else {
+			lastKey := ks.Last
+
+			if lastKey >= 0 && ks.Keyword == "" {
+				lastKey -= ks.First
+			}
+
+			c.Conn().WriteBulkString("range")
+			c.Conn().WriteBulkString("spec")
+			writeMapLen(c, 3)
+			c.Conn().WriteBulkString("lastkey")
+			c.Conn().WriteInt(lastKey)
+			c.Conn().WriteBulkString("keystep")
+			c.Conn().WriteInt(step)
+			c.Conn().WriteBulkString("limit")
+			c.Conn().WriteInt(0)
+		}
+	}
+
+	// Subcommands
+	c.Conn().WriteArray(0)
+}
+
+func writeCommandDocs(c *pkg.Client, info *pkg.CommandInfo) {
+	if info.Module != "" {
+		writeMapLen(c, 2)
+		c.Conn().WriteBulkString("group")
+		c.Conn().WriteBulkString(info.Group())
+		c.Conn().WriteBulkString("module")
+		c.Conn().WriteBulkString(info.Module)
+		return
+	}
+
+	writeMapLen(c, 1)
+	c.Conn().WriteBulkString("group")
+	c.Conn().WriteBulkString(info.Group())
+}
+
+func writeKeySpecFlags(c *pkg.Client, flags uint64) {
+	names := make([]string, 0, 3)
+
+	if flags&pkg.KEY_READ != 0 && flags&pkg.KEY_WRITE != 0 {
+		names = append(names, "RW", "access", "update")
+	} else if flags&pkg.KEY_WRITE != 0 {
+		names = append(names, "OW", "update")
+	} else {
+		names = append(names, "RO", "access")
+	}
+
+	writeStatusSet(c, names)
+}
+
+func writeStatusSet(c *pkg.Client, values []string) {
+	if c.R3 {
+		c.Conn().WriteSet(len(values))
+	} else {
+		c.Conn().WriteArray(len(values))
+	}
+
+	for _, v := range values {
+		c.Conn().WriteString(v)
+	}
+}
+
+func writeMapLen(c *pkg.Client, length int) {
+	if c.R3 {
+		c.Conn().WriteMap(length * 2)
+	} else {
+		c.Conn().WriteArray(length * 2)
+	}
+}
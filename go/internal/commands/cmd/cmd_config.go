@@ -10,6 +10,8 @@ import (
 
 // https://redis.io/commands/config-get/
 // https://redis.io/commands/config-set/
+// https://redis.io/commands/config-resetstat/
+// CONFIG SET parameter value [parameter value ...]
 func ConfigCommand(c *pkg.Client, args [][]byte) {
 	if len(args) < 2 {
 		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
@@ -37,16 +39,29 @@ func ConfigCommand(c *pkg.Client, args [][]byte) {
 			c.Conn().WriteBulkString(v)
 		}
 	} else if strings.ToLower(subcommand) == "set" {
-		if len(args) < 4 {
+		if len(args) < 4 || len(args)%2 != 0 {
 			c.Conn().WriteError(fmt.Sprintf("Unknown subcommand or wrong number of arguments for '%s'. Try CONFIG HELP.", string(args[1])))
 			return
 		}
 
-		k := string(args[2])
-		v := string(args[3])
+		keys := make([]string, 0, (len(args)-2)/2)
+		values := make([]string, 0, (len(args)-2)/2)
 
-		c.Redis().SetConfigValue(k, v)
+		for i := 2; i < len(args); i += 2 {
+			keys = append(keys, strings.ToLower(string(args[i])))
+			values = append(values, string(args[i+1]))
+		}
+
+		err := c.Redis().SetConfigValues(keys, values)
+
+		if err != nil {
+			c.Conn().WriteError(fmt.Sprintf("ERR CONFIG SET failed (possibly related to argument '%s') - %s", keys[0], err.Error()))
+			return
+		}
 
+		c.Conn().WriteString("OK")
+	} else if strings.ToLower(subcommand) == "resetstat" {
+		c.Redis().Stats().Reset()
 		c.Conn().WriteString("OK")
 	} else {
 		c.Conn().WriteError(fmt.Sprintf("Unknown subcommand '%s'. Try CONFIG HELP.", subcommand))
@@ -1,8 +1,30 @@
 package cmd
 
-import "github.com/hbina/radish/internal/pkg"
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
 
 // https://redis.io/commands/debug/
+// DEBUG SLEEP seconds
 func DebugCommand(c *pkg.Client, args [][]byte) {
+	if strings.ToLower(string(args[1])) == "sleep" && len(args) == 3 {
+		seconds, err := strconv.ParseFloat(string(args[2]), 64)
+
+		if err != nil {
+			c.Conn().WriteError(util.InvalidFloatErr)
+			return
+		}
+
+		// Sleeps with the database locked to simulate a slow command
+		time.Sleep(time.Duration(seconds * float64(time.Second)))
+		c.Conn().WriteString("OK")
+		return
+	}
+
 	c.Conn().WriteString("Not implemented")
 }
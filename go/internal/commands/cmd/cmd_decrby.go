@@ -2,11 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"math"
 	"strconv"
-	"time"
 
 	"github.com/hbina/radish/internal/pkg"
-	"github.com/hbina/radish/internal/types"
 	"github.com/hbina/radish/internal/util"
 )
 
@@ -17,9 +16,6 @@ func DecrByCommand(c *pkg.Client, args [][]byte) {
 		return
 	}
 
-	db := c.Db()
-
-	key := string(args[1])
 	decrBy, err := strconv.ParseInt(string(args[2]), 10, 64)
 
 	if err != nil {
@@ -27,30 +23,11 @@ func DecrByCommand(c *pkg.Client, args [][]byte) {
 		return
 	}
 
-	item, exists := db.Storage[key]
-
-	if !exists {
-		db.Set(key, types.NewString(fmt.Sprintf("%d", decrBy)), time.Time{})
-		c.Conn().WriteInt64(decrBy)
+	// The decrement can not be negated
+	if decrBy == math.MinInt64 {
+		c.Conn().WriteError("ERR decrement would overflow")
 		return
 	}
 
-	value, ok := item.Value().(string)
-
-	if !ok {
-		c.Conn().WriteError(util.WrongTypeErr)
-		return
-	}
-
-	intValue, err := strconv.ParseInt(value, 10, 64)
-
-	if err != nil {
-		c.Conn().WriteError(util.InvalidIntErr)
-		return
-	}
-
-	intValue -= decrBy
-
-	db.Set(key, types.NewString(fmt.Sprint(intValue)), time.Time{})
-	c.Conn().WriteInt64(intValue)
+	incrDecr(c, string(args[1]), -decrBy)
 }
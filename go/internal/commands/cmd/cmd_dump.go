@@ -1,11 +1,9 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/hbina/radish/internal/pkg"
-	"github.com/hbina/radish/internal/types"
 	"github.com/hbina/radish/internal/util"
 )
 
@@ -28,95 +26,12 @@ func DumpCommand(c *pkg.Client, args [][]byte) {
 		return
 	}
 
-	if value.Type() == types.ValueTypeString {
-		data, err := value.(*types.String).Marshal()
-
-		if err != nil {
-			c.Conn().WriteError(err.Error())
-			return
-		}
-
-		str, err := json.Marshal(pkg.Kvp{
-			Key:  key,
-			Type: value.TypeFancy(),
-			Data: data,
-		})
-
-		if err != nil {
-			c.Conn().WriteError(err.Error())
-			return
-		}
-
-		c.Conn().WriteBulkString(string(str))
-
-		return
-	} else if value.Type() == types.ValueTypeList {
-		data, err := value.(*types.List).Marshal()
-
-		if err != nil {
-			c.Conn().WriteError(err.Error())
-			return
-		}
-
-		str, err := json.Marshal(pkg.Kvp{
-			Key:  key,
-			Type: value.TypeFancy(),
-			Data: data,
-		})
-
-		if err != nil {
-			c.Conn().WriteError(err.Error())
-			return
-		}
-
-		c.Conn().WriteBulkString(string(str))
-
-		return
-	} else if value.Type() == types.ValueTypeSet {
-		data, err := value.(*types.Set).Marshal()
-
-		if err != nil {
-			c.Conn().WriteError(err.Error())
-			return
-		}
-
-		str, err := json.Marshal(pkg.Kvp{
-			Key:  key,
-			Type: value.TypeFancy(),
-			Data: data,
-		})
-
-		if err != nil {
-			c.Conn().WriteError(err.Error())
-			return
-		}
-
-		c.Conn().WriteBulkString(string(str))
-
-		return
-	} else if value.Type() == types.ValueTypeZSet {
-		data, err := value.(*types.ZSet).Marshal()
-
-		if err != nil {
-			c.Conn().WriteError(err.Error())
-			return
-		}
-
-		str, err := json.Marshal(pkg.Kvp{
-			Key:  key,
-			Type: value.TypeFancy(),
-			Data: data,
-		})
-
-		if err != nil {
-			c.Conn().WriteError(err.Error())
-			return
-		}
-
-		c.Conn().WriteBulkString(string(str))
+	payload, err := c.Redis().DumpPayload(key, value)
 
+	if err != nil {
+		c.Conn().WriteError(err.Error())
 		return
 	}
 
-	c.Conn().WriteError(fmt.Sprintf("Dump for %s is not yet implemented", value.TypeFancy()))
+	c.Conn().WriteBulkString(string(payload))
 }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/eval/
+// EVAL script numkeys [key [key ...]] [arg [arg ...]]
+func EvalCommand(c *pkg.Client, args [][]byte) {
+	eval(c, args, false, false)
+}
+
+// https://redis.io/commands/evalsha/
+// EVALSHA sha1 numkeys [key [key ...]] [arg [arg ...]]
+func EvalShaCommand(c *pkg.Client, args [][]byte) {
+	eval(c, args, true, false)
+}
+
+// https://redis.io/commands/eval_ro/
+// EVAL_RO script numkeys [key [key ...]] [arg [arg ...]]
+func EvalRoCommand(c *pkg.Client, args [][]byte) {
+	eval(c, args, false, true)
+}
+
+// https://redis.io/commands/evalsha_ro/
+// EVALSHA_RO sha1 numkeys [key [key ...]] [arg [arg ...]]
+func EvalShaRoCommand(c *pkg.Client, args [][]byte) {
+	eval(c, args, true, true)
+}
+
+func eval(c *pkg.Client, args [][]byte, isSha bool, readOnly bool) {
+	keys, argv, ok := parseScriptArgs(c, args)
+
+	if !ok {
+		return
+	}
+
+	sha := string(args[1])
+
+	if !isSha {
+		var err error
+		sha, err = c.Redis().ScriptLoad(string(args[1]))
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+	}
+
+	c.Redis().EvalSha(c, sha, keys, argv, readOnly)
+}
+
+// parseScriptArgs parses the keys and the arguments of EVAL and FCALL,
+// which follow the script or the function and the number of keys.
+func parseScriptArgs(c *pkg.Client, args [][]byte) ([]string, []string, bool) {
+	if len(args) < 3 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return nil, nil, false
+	}
+
+	numKeys, err := strconv.Atoi(string(args[2]))
+
+	if err != nil {
+		c.Conn().WriteError(util.InvalidIntErr)
+		return nil, nil, false
+	}
+
+	if numKeys > len(args)-3 {
+		c.Conn().WriteError("ERR Number of keys can't be greater than number of args")
+		return nil, nil, false
+	}
+
+	if numKeys < 0 {
+		c.Conn().WriteError("ERR Number of keys can't be negative")
+		return nil, nil, false
+	}
+
+	keys := make([]string, 0, numKeys)
+	argv := make([]string, 0, len(args)-3-numKeys)
+
+	for _, arg := range args[3 : 3+numKeys] {
+		keys = append(keys, string(arg))
+	}
+
+	for _, arg := range args[3+numKeys:] {
+		argv = append(argv, string(arg))
+	}
+
+	return keys, argv, true
+}
@@ -62,7 +62,7 @@ func ExpireCommand(c *pkg.Client, args [][]byte) {
 		}
 	}
 
-	newTtl, err := util.ParseTtlFromUnitTime(seconds, int64(time.Second))
+	newTtl, err := util.ParseTtlFromUnitTime(c.Redis().Now(), seconds, int64(time.Second))
 
 	if err != nil {
 		c.Conn().WriteError(util.InvalidIntErr)
@@ -0,0 +1,25 @@
+package cmd
+
+import "github.com/hbina/radish/internal/pkg"
+
+// https://redis.io/commands/fcall/
+// FCALL function numkeys [key [key ...]] [arg [arg ...]]
+func FCallCommand(c *pkg.Client, args [][]byte) {
+	fcall(c, args, false)
+}
+
+// https://redis.io/commands/fcall_ro/
+// FCALL_RO function numkeys [key [key ...]] [arg [arg ...]]
+func FCallRoCommand(c *pkg.Client, args [][]byte) {
+	fcall(c, args, true)
+}
+
+func fcall(c *pkg.Client, args [][]byte, readOnly bool) {
+	keys, argv, ok := parseScriptArgs(c, args)
+
+	if !ok {
+		return
+	}
+
+	c.Redis().FCall(c, string(args[1]), keys, argv, readOnly)
+}
@@ -1,8 +1,252 @@
 package cmd
 
-import "github.com/hbina/radish/internal/pkg"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
 
 // https://redis.io/commands/function/
+// FUNCTION DELETE library-name
+// FUNCTION DUMP
+// FUNCTION FLUSH [ASYNC | SYNC]
+// FUNCTION KILL
+// FUNCTION LIST [LIBRARYNAME library-name-pattern] [WITHCODE]
+// FUNCTION LOAD [REPLACE] function-code
+// FUNCTION RESTORE serialized-value [FLUSH | APPEND | REPLACE]
+// FUNCTION STATS
+// FUNCTION HELP
 func FunctionCommand(c *pkg.Client, args [][]byte) {
-	c.Conn().WriteString("Not implemented")
+	if len(args) < 2 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	subcommand := strings.ToLower(string(args[1]))
+
+	switch {
+	case subcommand == "help" && len(args) == 2:
+		writeHelp(c, "FUNCTION", []string{
+			"LOAD [REPLACE] <FUNCTION CODE>",
+			"    Create a new library with the given library name and code.",
+			"DELETE <LIBRARY NAME>",
+			"    Delete the given library.",
+			"LIST [LIBRARYNAME PATTERN] [WITHCODE]",
+			"    Return general information on all the libraries:",
+			"    * Library name",
+			"    * The engine used to run the Library",
+			"    * Library description",
+			"    * Functions list",
+			"    * Library code (if WITHCODE is given)",
+			"    It also possible to get only function that matches a pattern using LIBRARYNAME argument.",
+			"STATS",
+			"    Return information about the current function running:",
+			"    * Function name",
+			"    * Command used to run the function",
+			"    * Duration in MS that the function is running",
+			"    If no function is running, return nil",
+			"    In addition, returns a list of available engines.",
+			"KILL",
+			"    Kill the current running function.",
+			"FLUSH [ASYNC|SYNC]",
+			"    Delete all the libraries.",
+			"    When called without the optional mode argument, the behavior is determined by the",
+			"    lazyfree-lazy-user-flush configuration directive. Valid modes are:",
+			"    * ASYNC: Asynchronously flush the libraries.",
+			"    * SYNC: Synchronously flush the libraries.",
+			"DUMP",
+			"    Return a serialized payload representing the current libraries, can be restored using FUNCTION RESTORE command",
+			"RESTORE <PAYLOAD> [FLUSH|APPEND|REPLACE]",
+			"    Restore the libraries represented by the given payload, it is possible to give a restore policy to",
+			"    control how to handle existing libraries (default APPEND):",
+			"    * FLUSH: delete all existing libraries.",
+			"    * APPEND: appends the restored libraries to the existing libraries. On collision, abort.",
+			"    * REPLACE: appends the restored libraries to the existing libraries, On collision, replace the old",
+			"      libraries with the new libraries (notice that even on this option there is a chance of failure",
+			"      in case of functions name collision with another library).",
+		})
+	case subcommand == "load" && (len(args) == 3 || len(args) == 4):
+		replace := len(args) == 4
+
+		if replace && strings.ToLower(string(args[2])) != "replace" {
+			c.Conn().WriteError(fmt.Sprintf("ERR Unknown option given: %s", args[2]))
+			return
+		}
+
+		name, err := c.Redis().FunctionLoad(string(args[len(args)-1]), replace)
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+
+		c.Conn().WriteBulkString(name)
+	case subcommand == "delete" && len(args) == 3:
+		err := c.Redis().FunctionDelete(string(args[2]))
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+
+		c.Conn().WriteString("OK")
+	case subcommand == "list":
+		functionList(c, args)
+	case subcommand == "stats" && len(args) == 2:
+		functionStats(c)
+	case subcommand == "kill" && len(args) == 2:
+		err := c.Redis().ScriptKill(true)
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+
+		c.Conn().WriteString("OK")
+	case subcommand == "flush" && len(args) <= 3:
+		// The libraries are always flushed synchronously
+		if len(args) == 3 {
+			mode := strings.ToLower(string(args[2]))
+
+			if mode != "async" && mode != "sync" {
+				c.Conn().WriteError("ERR FUNCTION FLUSH only supports SYNC|ASYNC option")
+				return
+			}
+		}
+
+		err := c.Redis().FunctionFlush()
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+
+		c.Conn().WriteString("OK")
+	case subcommand == "dump" && len(args) == 2:
+		c.Conn().WriteBulkString(string(c.Redis().FunctionDump()))
+	case subcommand == "restore" && (len(args) == 3 || len(args) == 4):
+		policy := pkg.FUNCTION_RESTORE_APPEND
+
+		if len(args) == 4 {
+			switch strings.ToLower(string(args[3])) {
+			case "append":
+			case "replace":
+				policy = pkg.FUNCTION_RESTORE_REPLACE
+			case "flush":
+				policy = pkg.FUNCTION_RESTORE_FLUSH
+			default:
+				c.Conn().WriteError("ERR Wrong restore policy given, value should be either FLUSH, APPEND or REPLACE.")
+				return
+			}
+		}
+
+		err := c.Redis().FunctionRestore(args[2], policy)
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+
+		c.Conn().WriteString("OK")
+	default:
+		c.Conn().WriteError(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for '%s'. Try FUNCTION HELP.", string(args[1])))
+	}
+}
+
+func functionList(c *pkg.Client, args [][]byte) {
+	pattern := ""
+	withCode := false
+
+	for i := 2; i < len(args); i++ {
+		arg := strings.ToLower(string(args[i]))
+
+		switch {
+		case arg == "withcode" && !withCode:
+			withCode = true
+		case arg == "libraryname" && pattern == "" && i+1 < len(args):
+			pattern = string(args[i+1])
+			i++
+		default:
+			c.Conn().WriteError("ERR Unknown argument " + string(args[i]))
+			return
+		}
+	}
+
+	libs := c.Redis().FunctionList(pattern)
+	c.Conn().WriteArray(len(libs))
+
+	for _, lib := range libs {
+		if withCode {
+			writeMapLen(c, 4)
+		} else {
+			writeMapLen(c, 3)
+		}
+
+		c.Conn().WriteBulkString("library_name")
+		c.Conn().WriteBulkString(lib.Name)
+		c.Conn().WriteBulkString("engine")
+		c.Conn().WriteBulkString(lib.Engine)
+		c.Conn().WriteBulkString("functions")
+		c.Conn().WriteArray(len(lib.Functions))
+
+		for _, fn := range lib.Functions {
+			writeMapLen(c, 3)
+			c.Conn().WriteBulkString("name")
+			c.Conn().WriteBulkString(fn.Name)
+			c.Conn().WriteBulkString("description")
+
+			if fn.Description != nil {
+				c.Conn().WriteBulkString(*fn.Description)
+			} else if c.R3 {
+				c.Conn().WriteNull()
+			} else {
+				c.Conn().WriteNullBulk()
+			}
+
+			c.Conn().WriteBulkString("flags")
+			writeStatusSet(c, fn.Flags)
+		}
+
+		if withCode {
+			c.Conn().WriteBulkString("library_code")
+			c.Conn().WriteBulkString(lib.Code)
+		}
+	}
+}
+
+func functionStats(c *pkg.Client) {
+	stats := c.Redis().FunctionStats()
+
+	writeMapLen(c, 2)
+	c.Conn().WriteBulkString("running_script")
+
+	if run := stats.Running; run != nil {
+		writeMapLen(c, 3)
+		c.Conn().WriteBulkString("name")
+		c.Conn().WriteBulkString(run.Name)
+		c.Conn().WriteBulkString("command")
+		c.Conn().WriteArray(len(run.Command))
+
+		for _, arg := range run.Command {
+			c.Conn().WriteBulkString(arg)
+		}
+
+		c.Conn().WriteBulkString("duration_ms")
+		c.Conn().WriteInt64(run.Duration.Milliseconds())
+	} else if c.R3 {
+		c.Conn().WriteNull()
+	} else {
+		c.Conn().WriteNullBulk()
+	}
+
+	c.Conn().WriteBulkString("engines")
+	writeMapLen(c, 1)
+	c.Conn().WriteBulkString(pkg.FUNCTION_ENGINE_LUA)
+	writeMapLen(c, 2)
+	c.Conn().WriteBulkString("libraries_count")
+	c.Conn().WriteInt(stats.Libraries)
+	c.Conn().WriteBulkString("functions_count")
+	c.Conn().WriteInt(stats.Functions)
 }
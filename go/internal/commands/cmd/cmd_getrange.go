@@ -18,73 +18,75 @@ func GetrangeCommand(c *pkg.Client, args [][]byte) {
 	}
 
 	key := string(args[1])
-	startStr := string(args[2])
-	endStr := string(args[3])
 	db := c.Db()
 
-	// Parse start index
-	start64, err := strconv.ParseInt(startStr, 10, 64)
+	start, err := strconv.ParseInt(string(args[2]), 10, 64)
 
 	if err != nil {
-		c.Conn().WriteError("ERR start is not an integer or out of range")
+		c.Conn().WriteError(util.InvalidIntErr)
 		return
 	}
 
-	// TODO: this might be buggy in 32-bit computer
-	start := int(start64)
-
-	// Parse end index
-	end64, err := strconv.ParseInt(endStr, 10, 64)
+	end, err := strconv.ParseInt(string(args[3]), 10, 64)
 
 	if err != nil {
-		c.Conn().WriteError("ERR end is not an integer or out of range")
+		c.Conn().WriteError(util.InvalidIntErr)
 		return
 	}
 
-	// We need to add 1 because its inclusive on both ends
-	// TODO: this might be buggy in 32-bit computer
-	end := int(end64) + 1
-
 	maybeItem, _ := db.Get(key)
 
-	if maybeItem != nil && maybeItem.Type() != types.ValueTypeString {
+	if maybeItem == nil {
+		c.Conn().WriteBulkString("")
+		return
+	} else if maybeItem.Type() != types.ValueTypeString {
 		c.Conn().WriteError(util.WrongTypeErr)
-	} else {
-		if maybeItem == nil {
-			c.Conn().WriteBulkString("")
-			return
-		}
-
-		item := maybeItem.(*types.String)
-
-		// If start/end is negative, recalibrate to the absolute index
-		if start < 0 {
-			start = item.Len() + start
-			if start < 0 {
-				start = 0
-			}
-		}
-		if end <= 0 {
-			end = item.Len() + end
-			if start < 0 {
-				start = 0
-			}
-		}
-
-		if start > end {
-			c.Conn().WriteBulkString("")
-			return
-		}
-
-		if start >= item.Len() {
-			start = item.Len()
-		}
-
-		if end >= item.Len() {
-			end = item.Len()
-		}
-
-		str := item.SubString(start, end)
-		c.Conn().WriteBulkString(str)
+		return
 	}
+
+	item := maybeItem.(*types.String)
+	start, end, ok := stringRange(start, end, int64(item.Len()))
+
+	if !ok {
+		c.Conn().WriteBulkString("")
+		return
+	}
+
+	c.Conn().WriteBulkString(item.SubString(int(start), int(end)+1))
+}
+
+// stringRange converts the inclusive range of GETRANGE, whose negative
+// indices count from the end, to indices within the string. It returns
+// false if the range is empty.
+func stringRange(start int64, end int64, length int64) (int64, int64, bool) {
+	// Both indices are from the end and the range is reversed
+	if start < 0 && end < 0 && start > end {
+		return 0, 0, false
+	}
+
+	if start < 0 {
+		start += length
+	}
+
+	if end < 0 {
+		end += length
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if end < 0 {
+		end = 0
+	}
+
+	if end >= length {
+		end = length - 1
+	}
+
+	if start > end || length == 0 {
+		return 0, 0, false
+	}
+
+	return start, end, true
 }
@@ -1,44 +1,109 @@
 package cmd
 
-import "github.com/hbina/radish/internal/pkg"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
 
 // https://redis.io/commands/hello/
-// HELLO [protover [AUTH username password] [SETNAME clientname]]
+// HELLO [protover [AUTH username password] [SETNAME clientname]]
 // Stub implementation
 func HelloCommand(c *pkg.Client, args [][]byte) {
-	if len(args) == 1 {
-		writeStubResponse(c)
-	} else if len(args) == 2 {
-		version := string(args[1])
-
-		if version == "2" {
-			c.UseResp2()
-			writeStubResponse(c)
-		} else if version == "3" {
-			c.UseResp3()
-			writeStubResponse(c)
-		} else {
+	version := ""
+
+	if len(args) >= 2 {
+		version = string(args[1])
+
+		if version != "2" && version != "3" {
 			c.Conn().WriteError("NOPROTO unsupported protocol version")
+			return
 		}
-	} else {
-		c.Conn().WriteError("Unsupported operation")
 	}
+
+	var username, password, name *string
+
+	// Parse the rest of options
+	for i := 2; i < len(args); i++ {
+		arg := strings.ToLower(string(args[i]))
+		switch arg {
+		case "auth":
+			// We require 2 more arguments for the username and password
+			if len(args) <= i+2 {
+				c.Conn().WriteError(fmt.Sprintf("ERR Syntax error in HELLO option '%s'", string(args[i])))
+				return
+			}
+
+			u := string(args[i+1])
+			p := string(args[i+2])
+			username = &u
+			password = &p
+			i += 2
+		case "setname":
+			// We require 1 more argument for the name
+			if len(args) <= i+1 {
+				c.Conn().WriteError(fmt.Sprintf("ERR Syntax error in HELLO option '%s'", string(args[i])))
+				return
+			}
+
+			n := string(args[i+1])
+			name = &n
+			i++
+		default:
+			c.Conn().WriteError(fmt.Sprintf("ERR Syntax error in HELLO option '%s'", string(args[i])))
+			return
+		}
+	}
+
+	if username != nil && !c.Redis().Authenticate(c, *username, *password) {
+		c.Conn().WriteError(util.WrongPassErr)
+		return
+	}
+
+	if c.Redis().AuthRequired(c, nil) {
+		c.Conn().WriteError("NOAUTH HELLO must be called with the client already authenticated, " +
+			"otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate " +
+			"the client and select the RESP protocol version at the same time")
+		return
+	}
+
+	if name != nil {
+		c.Name = name
+	}
+
+	if version == "2" {
+		c.UseResp2()
+	} else if version == "3" {
+		c.UseResp3()
+	}
+
+	writeStubResponse(c)
 }
 
 func writeStubResponse(c *pkg.Client) {
-	c.Conn().WriteMap(7 * 2)
-	c.Conn().WriteString("server")
-	c.Conn().WriteString("redis")
-	c.Conn().WriteString("version")
-	c.Conn().WriteString("255.255.255")
-	c.Conn().WriteString("proto")
-	c.Conn().WriteInt(2)
-	c.Conn().WriteString("id")
-	c.Conn().WriteInt(12)
-	c.Conn().WriteString("mode")
-	c.Conn().WriteString("standalone")
-	c.Conn().WriteString("role")
-	c.Conn().WriteString("master")
-	c.Conn().WriteString("modules")
+	proto := 2
+
+	if c.R3 {
+		proto = 3
+		c.Conn().WriteMap(7 * 2)
+	} else {
+		c.Conn().WriteArray(7 * 2)
+	}
+
+	c.Conn().WriteBulkString("server")
+	c.Conn().WriteBulkString("redis")
+	c.Conn().WriteBulkString("version")
+	c.Conn().WriteBulkString(pkg.Version)
+	c.Conn().WriteBulkString("proto")
+	c.Conn().WriteInt(proto)
+	c.Conn().WriteBulkString("id")
+	c.Conn().WriteInt64(int64(c.Id()))
+	c.Conn().WriteBulkString("mode")
+	c.Conn().WriteBulkString("standalone")
+	c.Conn().WriteBulkString("role")
+	c.Conn().WriteBulkString("master")
+	c.Conn().WriteBulkString("modules")
 	c.Conn().WriteArray(0)
 }
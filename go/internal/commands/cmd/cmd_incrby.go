@@ -2,8 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"math"
 	"strconv"
-	"time"
 
 	"github.com/hbina/radish/internal/pkg"
 	"github.com/hbina/radish/internal/types"
@@ -17,9 +17,6 @@ func IncrByCommand(c *pkg.Client, args [][]byte) {
 		return
 	}
 
-	db := c.Db()
-
-	key := string(args[1])
 	incrBy, err := strconv.ParseInt(string(args[2]), 10, 64)
 
 	if err != nil {
@@ -27,30 +24,39 @@ func IncrByCommand(c *pkg.Client, args [][]byte) {
 		return
 	}
 
-	item, exists := db.Storage[key]
+	incrDecr(c, string(args[1]), incrBy)
+}
 
-	if !exists {
-		db.Set(key, types.NewString(fmt.Sprintf("%d", incrBy)), time.Time{})
-		c.Conn().WriteInt64(incrBy)
-		return
-	}
+// incrDecr adds the increment to the integer of the key, which is 0 if it
+// does not exist, and keeps its expiry. The counters are stored as integers.
+func incrDecr(c *pkg.Client, key string, incr int64) {
+	db := c.Db()
+	maybeItem, ttl := db.Get(key)
+	value := int64(0)
 
-	value, ok := item.Value().(string)
+	if maybeItem != nil {
+		if maybeItem.Type() != types.ValueTypeString {
+			c.Conn().WriteError(util.WrongTypeErr)
+			return
+		}
 
-	if !ok {
-		c.Conn().WriteError(util.WrongTypeErr)
-		return
-	}
+		n, ok := maybeItem.(*types.String).Int()
 
-	intValue, err := strconv.ParseInt(value, 10, 64)
+		if !ok {
+			c.Conn().WriteError(util.InvalidIntErr)
+			return
+		}
 
-	if err != nil {
-		c.Conn().WriteError(util.InvalidIntErr)
-		return
+		value = n
 	}
 
-	intValue += incrBy
+	if (incr < 0 && value < 0 && incr < math.MinInt64-value) ||
+		(incr > 0 && value > 0 && incr > math.MaxInt64-value) {
+		c.Conn().WriteError(util.IntOverflowErr)
+		return
+	}
 
-	db.Set(key, types.NewString(fmt.Sprint(intValue)), time.Time{})
-	c.Conn().WriteInt64(intValue)
+	value += incr
+	db.Set(key, types.NewIntString(value), ttl)
+	c.Conn().WriteInt64(value)
 }
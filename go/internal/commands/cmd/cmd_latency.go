@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/latency-latest/
+// LATENCY LATEST
+// LATENCY HISTORY event
+// LATENCY RESET [event [event ...]]
+// LATENCY GRAPH event
+// LATENCY DOCTOR
+// LATENCY HISTOGRAM [command [command ...]]
+// LATENCY HELP
+func LatencyCommand(c *pkg.Client, args [][]byte) {
+	if len(args) < 2 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	subcommand := strings.ToLower(string(args[1]))
+
+	switch {
+	case subcommand == "latest" && len(args) == 2:
+		entries := c.Redis().LatencyLatest()
+		c.Conn().WriteArray(len(entries))
+
+		for _, e := range entries {
+			c.Conn().WriteArray(4)
+			c.Conn().WriteBulkString(e.Event)
+			c.Conn().WriteInt64(e.Latest.Time.Unix())
+			c.Conn().WriteInt64(e.Latest.Latency.Milliseconds())
+			c.Conn().WriteInt64(e.Max.Milliseconds())
+		}
+	case subcommand == "history" && len(args) == 3:
+		samples := c.Redis().LatencyHistory(string(args[2]))
+		c.Conn().WriteArray(len(samples))
+
+		for _, s := range samples {
+			c.Conn().WriteArray(2)
+			c.Conn().WriteInt64(s.Time.Unix())
+			c.Conn().WriteInt64(s.Latency.Milliseconds())
+		}
+	case subcommand == "reset":
+		events := make([]string, 0, len(args)-2)
+
+		for _, arg := range args[2:] {
+			events = append(events, string(arg))
+		}
+
+		c.Conn().WriteInt(c.Redis().LatencyReset(events...))
+	case subcommand == "graph" && len(args) == 3:
+		graph, ok := c.Redis().LatencyGraph(string(args[2]))
+
+		if !ok {
+			c.Conn().WriteError(fmt.Sprintf("ERR No samples available for event '%s'", string(args[2])))
+			return
+		}
+
+		c.Conn().WriteBulkString(graph)
+	case subcommand == "doctor" && len(args) == 2:
+		c.Conn().WriteBulkString(c.Redis().LatencyDoctor())
+	case subcommand == "histogram":
+		latencyHistogram(c, args[2:])
+	case subcommand == "help" && len(args) == 2:
+		writeHelp(c, "LATENCY", []string{
+			"DOCTOR",
+			"    Return a human readable latency analysis report.",
+			"GRAPH <event>",
+			"    Return an ASCII latency graph for the <event> class.",
+			"HISTORY <event>",
+			"    Return time-latency samples for the <event> class.",
+			"LATEST",
+			"    Return the latest latency samples for all events.",
+			"RESET [<event> ...]",
+			"    Reset latency data of one or more <event> classes.",
+			"    (default: reset all data for all event classes)",
+			"HISTOGRAM [COMMAND ...]",
+			"    Return a cumulative distribution of latencies in the format of a histogram for the specified command names.",
+			"    If no commands are specified then all histograms are replied.",
+		})
+	default:
+		c.Conn().WriteError(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for '%s'. Try LATENCY HELP.", string(args[1])))
+	}
+}
+
+// latencyHistogram replies with the latency histograms of the given commands,
+// or of all the commands that have been called.
+func latencyHistogram(c *pkg.Client, names [][]byte) {
+	stats := c.Redis().Stats()
+	commands := make([]string, 0, len(names))
+
+	if len(names) == 0 {
+		commands, _ = stats.Commands()
+	} else {
+		for _, name := range names {
+			if info := c.Redis().LookupCommand(string(name)); info != nil {
+				commands = append(commands, info.Name)
+			}
+		}
+	}
+
+	type entry struct {
+		name    string
+		calls   uint64
+		buckets [][2]uint64
+	}
+
+	entries := make([]entry, 0, len(commands))
+
+	for _, name := range commands {
+		if calls, buckets, ok := stats.LatencyHistogram(name); ok {
+			entries = append(entries, entry{name, calls, buckets})
+		}
+	}
+
+	writeMapLen(c, len(entries))
+
+	for _, e := range entries {
+		c.Conn().WriteBulkString(e.name)
+		writeMapLen(c, 2)
+		c.Conn().WriteBulkString("calls")
+		c.Conn().WriteInt64(int64(e.calls))
+		c.Conn().WriteBulkString("histogram_usec")
+		writeMapLen(c, len(e.buckets))
+
+		for _, b := range e.buckets {
+			c.Conn().WriteInt64(int64(b[0]))
+			c.Conn().WriteInt64(int64(b[1]))
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/memory-usage/
+// MEMORY USAGE key [SAMPLES count]
+// MEMORY STATS
+// MEMORY DOCTOR
+// MEMORY MALLOC-STATS
+// MEMORY PURGE
+// MEMORY HELP
+func MemoryCommand(c *pkg.Client, args [][]byte) {
+	if len(args) < 2 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	subcommand := strings.ToLower(string(args[1]))
+
+	switch {
+	case subcommand == "usage" && len(args) >= 3:
+		memoryUsage(c, args[2:])
+	case subcommand == "stats" && len(args) == 2:
+		memoryStats(c)
+	case subcommand == "doctor" && len(args) == 2:
+		c.Conn().WriteBulkString(c.Redis().MemoryDoctor())
+	case subcommand == "malloc-stats" && len(args) == 2:
+		c.Conn().WriteBulkString("Stats not supported for the current allocator")
+	case subcommand == "purge" && len(args) == 2:
+		debug.FreeOSMemory()
+		c.Conn().WriteString("OK")
+	case subcommand == "help" && len(args) == 2:
+		writeHelp(c, "MEMORY", []string{
+			"DOCTOR",
+			"    Return memory problems reports.",
+			"MALLOC-STATS",
+			"    Return internal statistics report from the memory allocator.",
+			"PURGE",
+			"    Attempt to purge dirty pages for reclamation by the allocator.",
+			"STATS",
+			"    Return information about the memory usage of the server.",
+			"USAGE <key> [SAMPLES <count>]",
+			"    Return memory in bytes used by <key> and its value. Nested values are",
+			"    sampled up to <count> times (default: 5, 0 means sample all).",
+		})
+	default:
+		c.Conn().WriteError(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for '%s'. Try MEMORY HELP.", string(args[1])))
+	}
+}
+
+// memoryUsage replies with the memory used by a key.
+func memoryUsage(c *pkg.Client, args [][]byte) {
+	samples := int64(5)
+
+	for i := 1; i < len(args); i++ {
+		if strings.ToLower(string(args[i])) != "samples" || i+1 == len(args) {
+			c.Conn().WriteError(util.SyntaxErr)
+			return
+		}
+
+		i++
+		n, err := strconv.ParseInt(string(args[i]), 10, 64)
+
+		if err != nil || n < 0 {
+			c.Conn().WriteError(util.InvalidIntErr)
+			return
+		}
+
+		samples = n
+	}
+
+	usage, exists := c.Db().MemoryUsage(string(args[0]), int(samples))
+
+	if !exists {
+		if c.R3 {
+			c.Conn().WriteNull()
+		} else {
+			c.Conn().WriteNullBulk()
+		}
+		return
+	}
+
+	c.Conn().WriteInt64(usage)
+}
+
+// memoryStats replies with the breakdown of the memory used by the server.
+func memoryStats(c *pkg.Client) {
+	s := c.Redis().GetMemoryStats()
+
+	writeMapLen(c, 18+len(s.Dbs))
+	c.Conn().WriteBulkString("peak.allocated")
+	c.Conn().WriteInt64(int64(s.PeakAllocated))
+	c.Conn().WriteBulkString("total.allocated")
+	c.Conn().WriteInt64(int64(s.TotalAllocated))
+	c.Conn().WriteBulkString("startup.allocated")
+	c.Conn().WriteInt64(int64(s.StartupAllocated))
+	c.Conn().WriteBulkString("replication.backlog")
+	c.Conn().WriteInt64(0)
+	c.Conn().WriteBulkString("clients.slaves")
+	c.Conn().WriteInt64(s.ClientsReplicas)
+	c.Conn().WriteBulkString("clients.normal")
+	c.Conn().WriteInt64(s.ClientsNormal)
+	c.Conn().WriteBulkString("cluster.links")
+	c.Conn().WriteInt64(0)
+	c.Conn().WriteBulkString("aof.buffer")
+	c.Conn().WriteInt64(0)
+	c.Conn().WriteBulkString("lua.caches")
+	c.Conn().WriteInt64(s.LuaCaches)
+	c.Conn().WriteBulkString("functions.caches")
+	c.Conn().WriteInt64(s.FunctionsCaches)
+
+	for _, db := range s.Dbs {
+		c.Conn().WriteBulkString("db." + strconv.FormatUint(db.Id, 10))
+		writeMapLen(c, 2)
+		c.Conn().WriteBulkString("overhead.hashtable.main")
+		c.Conn().WriteInt64(db.Main)
+		c.Conn().WriteBulkString("overhead.hashtable.expires")
+		c.Conn().WriteInt64(db.Expires)
+	}
+
+	c.Conn().WriteBulkString("overhead.total")
+	c.Conn().WriteInt64(s.OverheadTotal)
+	c.Conn().WriteBulkString("keys.count")
+	c.Conn().WriteInt(s.KeysCount)
+	c.Conn().WriteBulkString("keys.bytes-per-key")
+	c.Conn().WriteInt64(s.BytesPerKey())
+	c.Conn().WriteBulkString("dataset.bytes")
+	c.Conn().WriteInt64(s.Dataset)
+	c.Conn().WriteBulkString("dataset.percentage")
+	writeDouble(c, s.DatasetPercentage())
+	c.Conn().WriteBulkString("peak.percentage")
+	writeDouble(c, s.PeakPercentage())
+	c.Conn().WriteBulkString("fragmentation")
+	writeDouble(c, s.Fragmentation())
+	c.Conn().WriteBulkString("fragmentation.bytes")
+	c.Conn().WriteInt64(int64(s.Resident) - int64(s.TotalAllocated))
+}
+
+// writeDouble replies with a double, which is a bulk string in RESP2.
+func writeDouble(c *pkg.Client, value float64) {
+	if c.R3 {
+		c.Conn().WriteFloat64(value)
+	} else {
+		c.Conn().WriteBulkString(strconv.FormatFloat(value, 'f', -1, 64))
+	}
+}
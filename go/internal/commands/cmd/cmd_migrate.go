@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/migrate/
+// MIGRATE host port key|"" destination-db timeout [COPY] [REPLACE]
+// [AUTH password | AUTH2 username password] [KEYS key [key ...]]
+func MigrateCommand(c *pkg.Client, args [][]byte) {
+	if len(args) < 6 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	opts := pkg.MigrateOptions{
+		Host: string(args[1]),
+		Port: string(args[2]),
+		Keys: []string{string(args[3])},
+	}
+
+	for i := 6; i < len(args); i++ {
+		arg := strings.ToLower(string(args[i]))
+		moreArgs := len(args) - i - 1
+
+		switch {
+		case arg == "copy":
+			opts.Copy = true
+		case arg == "replace":
+			opts.Replace = true
+		case arg == "auth" && moreArgs >= 1:
+			opts.Auth = []string{string(args[i+1])}
+			i++
+		case arg == "auth2" && moreArgs >= 2:
+			opts.Auth = []string{string(args[i+1]), string(args[i+2])}
+			i += 2
+		case arg == "keys":
+			if len(args[3]) != 0 {
+				c.Conn().WriteError("ERR When using MIGRATE KEYS option, the key argument must be set to the empty string")
+				return
+			}
+
+			opts.Keys = make([]string, 0, moreArgs)
+
+			for _, key := range args[i+1:] {
+				opts.Keys = append(opts.Keys, string(key))
+			}
+
+			i = len(args)
+		default:
+			c.Conn().WriteError(util.SyntaxErr)
+			return
+		}
+	}
+
+	dbId, err := strconv.ParseInt(string(args[4]), 10, 64)
+
+	if err != nil {
+		c.Conn().WriteError(util.InvalidIntErr)
+		return
+	}
+
+	timeout, err := strconv.ParseInt(string(args[5]), 10, 64)
+
+	if err != nil {
+		c.Conn().WriteError(util.InvalidIntErr)
+		return
+	}
+
+	opts.DbId = dbId
+	opts.Timeout = time.Duration(timeout) * time.Millisecond
+
+	err = c.Redis().Migrate(c.Db(), opts)
+
+	if err == pkg.ErrMigrateNoKey {
+		c.Conn().WriteString("NOKEY")
+		return
+	}
+
+	if err != nil {
+		c.Conn().WriteError(err.Error())
+		return
+	}
+
+	c.Conn().WriteString("OK")
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/module-list/
+// MODULE LIST
+// MODULE LOAD path [arg [arg ...]]
+// MODULE LOADEX path [CONFIG name value [CONFIG name value ...]] [ARGS args [args ...]]
+// MODULE UNLOAD name
+func ModuleCommand(c *pkg.Client, args [][]byte) {
+	if len(args) < 2 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	subcommand := strings.ToLower(string(args[1]))
+
+	switch subcommand {
+	case "list":
+		if len(args) != 2 {
+			c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, "module|list"))
+			return
+		}
+
+		modules := c.Redis().Modules()
+		c.Conn().WriteArray(len(modules))
+
+		for _, m := range modules {
+			if c.R3 {
+				c.Conn().WriteMap(4 * 2)
+			} else {
+				c.Conn().WriteArray(4 * 2)
+			}
+
+			c.Conn().WriteBulkString("name")
+			c.Conn().WriteBulkString(m.Name)
+			c.Conn().WriteBulkString("ver")
+			c.Conn().WriteInt(m.Version)
+			c.Conn().WriteBulkString("path")
+			c.Conn().WriteBulkString("")
+			c.Conn().WriteBulkString("args")
+			c.Conn().WriteArray(0)
+		}
+	case "load", "loadex":
+		// Modules are Go code registered by the embedder, there is nothing to load at runtime
+		c.Conn().WriteError("ERR Error loading the extension. Please check the server logs.")
+	case "unload":
+		c.Conn().WriteError("ERR Error unloading module: operation not possible.")
+	default:
+		c.Conn().WriteError(fmt.Sprintf("ERR unknown subcommand '%s'. Try MODULE HELP.", string(args[1])))
+	}
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/hbina/radish/internal/pkg"
+)
+
+// https://redis.io/commands/monitor/
+// MONITOR
+func MonitorCommand(c *pkg.Client, args [][]byte) {
+	// Already monitoring
+	if c.Monitor() {
+		return
+	}
+
+	c.Conn().WriteString("OK")
+	c.Redis().AddMonitor(c)
+}
@@ -1,14 +1,87 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+const (
+	objectFreqErr = "ERR An LFU maxmemory policy is not selected, access frequency not tracked. " +
+		"Please note that when switching between policies at runtime LRU and LFU data will take some time to adjust."
+	objectIdletimeErr = "ERR An LFU maxmemory policy is selected, idle time not tracked. " +
+		"Please note that when switching between policies at runtime LRU and LFU data will take some time to adjust."
 )
 
 // https://redis.io/commands/object/
+// OBJECT ENCODING key
+// OBJECT FREQ key
+// OBJECT IDLETIME key
+// OBJECT REFCOUNT key
+// OBJECT HELP
 func ObjectCommand(c *pkg.Client, args [][]byte) {
-	if c.R3 {
-		c.Conn().WriteNull()
-	} else {
-		c.Conn().WriteNullBulk()
+	if len(args) < 2 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	subcommand := strings.ToLower(string(args[1]))
+
+	if subcommand == "help" && len(args) == 2 {
+		writeHelp(c, "OBJECT", []string{
+			"ENCODING <key>",
+			"    Return the kind of internal representation used in order to store the value",
+			"    associated with a <key>.",
+			"FREQ <key>",
+			"    Return the access frequency index of the <key>. The returned integer is",
+			"    proportional to the logarithm of the recent access frequency of the key.",
+			"IDLETIME <key>",
+			"    Return the idle time of the <key>, that is the approximated number of",
+			"    seconds elapsed since the last access to the key.",
+			"REFCOUNT <key>",
+			"    Return the number of references of the value associated with the specified",
+			"    <key>.",
+		})
+		return
+	}
+
+	if len(args) != 3 || (subcommand != "encoding" && subcommand != "freq" &&
+		subcommand != "idletime" && subcommand != "refcount") {
+		c.Conn().WriteError(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for '%s'. Try OBJECT HELP.", string(args[1])))
+		return
+	}
+
+	info, exists := c.Db().Object(string(args[2]))
+
+	if !exists {
+		if c.R3 {
+			c.Conn().WriteNull()
+		} else {
+			c.Conn().WriteNullBulk()
+		}
+		return
+	}
+
+	switch subcommand {
+	case "encoding":
+		c.Conn().WriteBulkString(info.Encoding)
+	case "freq":
+		if !c.Redis().LfuPolicy() {
+			c.Conn().WriteError(objectFreqErr)
+			return
+		}
+
+		c.Conn().WriteInt(int(info.Freq))
+	case "idletime":
+		if c.Redis().LfuPolicy() {
+			c.Conn().WriteError(objectIdletimeErr)
+			return
+		}
+
+		c.Conn().WriteInt64(info.Idle)
+	case "refcount":
+		c.Conn().WriteInt64(info.Refcount)
 	}
 }
@@ -10,7 +10,17 @@ import (
 
 // https://redis.io/commands/ping/
 func PingCommand(c *pkg.Client, args [][]byte) {
-	if len(args) == 1 {
+	// RESP2 clients in the pubsub context can only be sent arrays
+	if !c.R3 && c.SubscriptionCount() > 0 && len(args) <= 2 {
+		c.Conn().WriteArray(2)
+		c.Conn().WriteBulkString("pong")
+
+		if len(args) == 2 {
+			c.Conn().WriteBulkString(string(args[1]))
+		} else {
+			c.Conn().WriteBulkString("")
+		}
+	} else if len(args) == 1 {
 		c.Conn().WriteString("PONG")
 	} else if len(args) == 2 {
 		var buf strings.Builder
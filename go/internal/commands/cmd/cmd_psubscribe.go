@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/hbina/radish/internal/pkg"
+)
+
+// https://redis.io/commands/psubscribe/
+// PSUBSCRIBE pattern [pattern ...]
+func PSubscribeCommand(c *pkg.Client, args [][]byte) {
+	names := make([]string, 0, len(args)-1)
+
+	for _, arg := range args[1:] {
+		names = append(names, string(arg))
+	}
+
+	c.Redis().Subscribe(c, names, true)
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/hbina/radish/internal/pkg"
 	"github.com/hbina/radish/internal/util"
@@ -34,5 +33,5 @@ func PttlCommand(c *pkg.Client, args [][]byte) {
 		return
 	}
 
-	c.Conn().WriteInt64(int64(time.Until(ttl).Milliseconds()))
+	c.Conn().WriteInt64(int64(ttl.Sub(c.Redis().Now()).Milliseconds()))
 }
@@ -0,0 +1,11 @@
+package cmd
+
+import (
+	"github.com/hbina/radish/internal/pkg"
+)
+
+// https://redis.io/commands/publish/
+// PUBLISH channel message
+func PublishCommand(c *pkg.Client, args [][]byte) {
+	c.Conn().WriteInt(c.Redis().Publish(string(args[1]), string(args[2])))
+}
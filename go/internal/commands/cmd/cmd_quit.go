@@ -0,0 +1,10 @@
+package cmd
+
+import "github.com/hbina/radish/internal/pkg"
+
+// https://redis.io/commands/quit/
+// QUIT
+func QuitCommand(c *pkg.Client, args [][]byte) {
+	c.Conn().WriteString("OK")
+	c.Close()
+}
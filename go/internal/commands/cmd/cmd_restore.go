@@ -1,13 +1,12 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hbina/radish/internal/pkg"
-	"github.com/hbina/radish/internal/types"
 	"github.com/hbina/radish/internal/util"
 )
 
@@ -20,7 +19,7 @@ func RestoreCommand(c *pkg.Client, args [][]byte) {
 	}
 
 	key := string(args[1])
-	ttl, err := util.ParseTtlFromUnitTime(string(args[2]), int64(time.Millisecond))
+	ttl, err := util.ParseTtlFromUnitTime(c.Redis().Now(), string(args[2]), int64(time.Millisecond))
 
 	// Do not fail on time.Time{}, RESTORE will simply ignore it
 	if err != nil {
@@ -29,6 +28,8 @@ func RestoreCommand(c *pkg.Client, args [][]byte) {
 	}
 
 	isRestore := false
+	// The access metadata of the key, -1 if not given
+	idle, freq := int64(-1), int64(-1)
 
 	// Parse the rest of options
 	for i := 4; i < len(args); i++ {
@@ -47,16 +48,45 @@ func RestoreCommand(c *pkg.Client, args [][]byte) {
 
 			ttl = newTtl
 		case "idletime":
-
-			// We need 1 more argument for the time
-			if len(args) == i+1 {
+			if len(args) == i+1 || freq != -1 {
 				c.Conn().WriteError(util.SyntaxErr)
+				return
 			}
 
 			i++
+			n, err := strconv.ParseInt(string(args[i]), 10, 64)
 
-			// TODO: Use the given idle time.
+			if err != nil {
+				c.Conn().WriteError(util.InvalidIntErr)
+				return
+			}
+
+			if n < 0 {
+				c.Conn().WriteError("ERR Invalid IDLETIME value, must be >= 0")
+				return
+			}
+
+			idle = n
 		case "freq":
+			if len(args) == i+1 || idle != -1 {
+				c.Conn().WriteError(util.SyntaxErr)
+				return
+			}
+
+			i++
+			n, err := strconv.ParseInt(string(args[i]), 10, 64)
+
+			if err != nil {
+				c.Conn().WriteError(util.InvalidIntErr)
+				return
+			}
+
+			if n < 0 || n > 255 {
+				c.Conn().WriteError("ERR Invalid FREQ value, must be >= 0 and <= 255")
+				return
+			}
+
+			freq = n
 		default:
 			c.Conn().WriteError(util.SyntaxErr)
 			return
@@ -71,50 +101,14 @@ func RestoreCommand(c *pkg.Client, args [][]byte) {
 		return
 	}
 
-	var kvp pkg.Kvp
-	err = json.Unmarshal(args[3], &kvp)
+	item, err := c.Redis().RestorePayload(args[3])
 
 	if err != nil {
-		c.Conn().WriteError(fmt.Sprintf(util.DeserializationErr, string(args[3])))
+		c.Conn().WriteError("ERR " + err.Error())
 		return
 	}
 
-	if kvp.Type == types.ValueTypeFancyString {
-		set, ok := types.StringUnmarshal(kvp.Data)
-
-		if !ok {
-			c.Conn().WriteError(fmt.Sprintf(util.DeserializationErr, string(args[3])))
-			return
-		}
-
-		db.Set(key, set, ttl)
-	} else if kvp.Type == types.ValueTypeFancyList {
-		set, ok := types.ListUnmarshal(kvp.Data)
-
-		if !ok {
-			c.Conn().WriteError(fmt.Sprintf(util.DeserializationErr, string(args[3])))
-			return
-		}
-
-		db.Set(key, set, ttl)
-	} else if kvp.Type == types.ValueTypeFancySet {
-		set, ok := types.SetUnmarshal(kvp.Data)
-
-		if !ok {
-			c.Conn().WriteError(fmt.Sprintf(util.DeserializationErr, string(args[3])))
-			return
-		}
-
-		db.Set(key, set, ttl)
-	} else if kvp.Type == types.ValueTypeFancyZSet {
-		set, ok := types.ZSetUnmarshal(kvp.Data)
-
-		if !ok {
-			c.Conn().WriteError(fmt.Sprintf(util.DeserializationErr, string(args[3])))
-			return
-		}
-
-		db.Set(key, set, ttl)
-	}
+	db.Set(key, item, ttl)
+	db.SetAccess(key, freq, idle)
 	c.Conn().WriteString("OK")
 }
@@ -17,7 +17,7 @@ func RPopCommand(c *pkg.Client, args [][]byte) {
 
 	key := string(args[1])
 	db := c.Db()
-	item, _ := db.Get(key)
+	item, ttl := db.Get(key)
 
 	if item == nil {
 		if c.R3 {
@@ -35,6 +35,8 @@ func RPopCommand(c *pkg.Client, args [][]byte) {
 	value, valid := l.RPop()
 
 	if valid {
+		// The list is deleted once it is empty
+		db.Set(key, l, ttl)
 		c.Conn().WriteBulkString(value)
 	} else {
 		db.Delete(key)
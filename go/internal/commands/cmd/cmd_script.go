@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/script/
+// SCRIPT EXISTS sha1 [sha1 ...]
+// SCRIPT FLUSH [ASYNC | SYNC]
+// SCRIPT KILL
+// SCRIPT LOAD script
+// SCRIPT HELP
+func ScriptCommand(c *pkg.Client, args [][]byte) {
+	if len(args) < 2 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	subcommand := strings.ToLower(string(args[1]))
+
+	switch {
+	case subcommand == "help" && len(args) == 2:
+		writeHelp(c, "SCRIPT", []string{
+			"EXISTS <sha1> [<sha1> ...]",
+			"    Return information about the existence of the scripts in the script cache.",
+			"FLUSH [ASYNC|SYNC]",
+			"    Flush the Lua scripts cache. Very dangerous on replicas.",
+			"    When called without the optional mode argument, the behavior is determined by the",
+			"    lazyfree-lazy-user-flush configuration directive. Valid modes are:",
+			"    * ASYNC: Asynchronously flush the scripts cache.",
+			"    * SYNC: Synchronously flush the scripts cache.",
+			"KILL",
+			"    Kill the currently executing Lua script.",
+			"LOAD <script>",
+			"    Load a script into the scripts cache without executing it.",
+		})
+	case subcommand == "exists" && len(args) > 2:
+		c.Conn().WriteArray(len(args) - 2)
+
+		for _, sha := range args[2:] {
+			if c.Redis().ScriptExists(string(sha)) {
+				c.Conn().WriteInt(1)
+			} else {
+				c.Conn().WriteInt(0)
+			}
+		}
+	case subcommand == "flush" && len(args) <= 3:
+		// The scripts are always flushed synchronously
+		if len(args) == 3 {
+			mode := strings.ToLower(string(args[2]))
+
+			if mode != "async" && mode != "sync" {
+				c.Conn().WriteError("ERR SCRIPT FLUSH only support SYNC|ASYNC option")
+				return
+			}
+		}
+
+		c.Redis().ScriptFlush()
+		c.Conn().WriteString("OK")
+	case subcommand == "kill" && len(args) == 2:
+		err := c.Redis().ScriptKill(false)
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+
+		c.Conn().WriteString("OK")
+	case subcommand == "load" && len(args) == 3:
+		sha, err := c.Redis().ScriptLoad(string(args[2]))
+
+		if err != nil {
+			c.Conn().WriteError(err.Error())
+			return
+		}
+
+		c.Conn().WriteBulkString(sha)
+	default:
+		c.Conn().WriteError(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for '%s'. Try SCRIPT HELP.", string(args[1])))
+	}
+}
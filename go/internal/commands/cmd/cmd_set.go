@@ -63,7 +63,7 @@ func SetCommand(c *pkg.Client, args [][]byte) {
 			}
 			i++
 
-			ttl, err := util.ParseTtlFromUnitTime(string(args[i]), int64(time.Second))
+			ttl, err := util.ParseTtlFromUnitTime(c.Redis().Now(), string(args[i]), int64(time.Second))
 
 			if ttl.IsZero() || err != nil {
 				c.Conn().WriteError(util.InvalidIntErr)
@@ -85,7 +85,7 @@ func SetCommand(c *pkg.Client, args [][]byte) {
 			}
 			i++
 
-			ttl, err := util.ParseTtlFromUnitTime(string(args[i]), int64(time.Millisecond))
+			ttl, err := util.ParseTtlFromUnitTime(c.Redis().Now(), string(args[i]), int64(time.Millisecond))
 
 			if ttl.IsZero() || err != nil {
 				c.Conn().WriteError(util.InvalidIntErr)
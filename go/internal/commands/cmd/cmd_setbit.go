@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"strconv"
-	"time"
 
 	"github.com/hbina/radish/internal/pkg"
 	"github.com/hbina/radish/internal/types"
@@ -53,41 +52,35 @@ func SetbitCommand(c *pkg.Client, args [][]byte) {
 		return
 	}
 
-	maybeItem, _ := db.Get(key)
+	maybeItem, ttl := db.Get(key)
 
 	if maybeItem != nil && maybeItem.Type() != types.ValueTypeString {
 		c.Conn().WriteError(util.WrongTypeErr)
 	} else {
-		// Some tricky bit operations.
-		// Please verify!
-
 		if maybeItem == nil {
-			maybeItem = types.NewString(string(make([]byte, byteOffset+1)))
+			maybeItem = types.NewStringFromBytes(nil)
 		}
 
 		mask := byte(0x80 >> byte(bitOffset))
 		item := maybeItem.(*types.String)
 
-		// Need to append item to have enough spaces for byteOffset
-		if item.Len() <= int(byteOffset) {
-			newStr := item.AsString() + string(make([]byte, byteOffset+1-item.Len()))
-			item = types.NewString(newStr)
-		}
-
-		bytes := item.AsBytes()
+		// The string is padded with zeros up to the byte and modified in place
+		item.Grow(byteOffset + 1)
+		value := item.Get(byteOffset)
 		oldBit := 0
 
-		if mask&bytes[byteOffset] > 0 {
+		if mask&value > 0 {
 			oldBit++
 		}
 
 		if bit {
-			bytes[byteOffset] = bytes[byteOffset] | mask
+			value |= mask
 		} else {
-			bytes[byteOffset] = bytes[byteOffset] & (0xFF ^ mask)
+			value &^= mask
 		}
 
-		db.Set(key, types.NewString(string(bytes)), time.Time{})
+		item.SetRange(byteOffset, []byte{value})
+		db.Set(key, item, ttl)
 		c.Conn().WriteInt(int(oldBit))
 	}
 }
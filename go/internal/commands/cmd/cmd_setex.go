@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/hbina/radish/internal/pkg"
@@ -13,25 +14,39 @@ import (
 // SETEX key seconds value
 // This is equivalent to calling `SET key value EX seconds`
 func SetexCommand(c *pkg.Client, args [][]byte) {
+	setex(c, args, time.Second)
+}
+
+// https://redis.io/commands/psetex/
+// PSETEX key milliseconds value
+// This is equivalent to calling `SET key value PX milliseconds`
+func PsetexCommand(c *pkg.Client, args [][]byte) {
+	setex(c, args, time.Millisecond)
+}
+
+func setex(c *pkg.Client, args [][]byte, unit time.Duration) {
 	if len(args) != 4 {
 		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
 		return
 	}
 
 	key := string(args[1])
-	seconds := string(args[2])
 	value := string(args[3])
-
-	newTtl, err := util.ParseTtlFromUnitTime(seconds, int64(time.Second))
+	expire, err := strconv.ParseInt(string(args[2]), 10, 64)
 
 	if err != nil {
 		c.Conn().WriteError(util.InvalidIntErr)
 		return
 	}
 
+	if expire <= 0 || expire > int64(time.Duration(1<<63-1)/unit) {
+		c.Conn().WriteError(fmt.Sprintf("ERR invalid expire time in '%s' command", args[0]))
+		return
+	}
+
 	db := c.Db()
 
-	db.Set(key, types.NewString(value), newTtl)
+	db.Set(key, types.NewString(value), c.Redis().Now().Add(time.Duration(expire)*unit))
 
 	c.Conn().WriteString("OK")
 }
@@ -3,13 +3,15 @@ package cmd
 import (
 	"fmt"
 	"strconv"
-	"time"
 
 	"github.com/hbina/radish/internal/pkg"
 	"github.com/hbina/radish/internal/types"
 	"github.com/hbina/radish/internal/util"
 )
 
+// The default of proto-max-bulk-len, which limits the length of the strings.
+const defaultProtoMaxBulkLen = 512 * 1024 * 1024
+
 // https://redis.io/commands/setrange/
 // SETRANGE key offset value
 func SetrangeCommand(c *pkg.Client, args [][]byte) {
@@ -19,55 +21,70 @@ func SetrangeCommand(c *pkg.Client, args [][]byte) {
 	}
 
 	key := string(args[1])
-	offsetStr := string(args[2])
-	value := string(args[3])
+	value := args[3]
 	db := c.Db()
 
-	// Parse byteOffset
-	byteOffset64, err := strconv.ParseInt(offsetStr, 10, 32)
+	offset, err := strconv.ParseInt(string(args[2]), 10, 64)
 
-	if err != nil || byteOffset64 < 0 {
-		c.Conn().WriteError("ERR bit offset is not an integer or out of range")
+	if err != nil {
+		c.Conn().WriteError(util.InvalidIntErr)
 		return
 	}
 
-	byteOffset := int(byteOffset64)
-
-	// Redis strings can only go up to 512MB
-	if byteOffset+len(value) > 536870911 {
-		c.Conn().WriteError("ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+	if offset < 0 {
+		c.Conn().WriteError("ERR offset is out of range")
 		return
 	}
 
-	maybeItem, _ := db.Get(key)
+	maybeItem, ttl := db.Get(key)
 
 	if maybeItem != nil && maybeItem.Type() != types.ValueTypeString {
 		c.Conn().WriteError(util.WrongTypeErr)
-	} else {
+		return
+	}
+
+	// An empty value does not create nor pad the string
+	if len(value) == 0 {
 		if maybeItem == nil {
+			c.Conn().WriteInt(0)
+		} else {
+			c.Conn().WriteInt(maybeItem.(*types.String).Len())
+		}
 
-			if len(value) == 0 {
-				db.Delete(key)
-				c.Conn().WriteInt(0)
-				return
-			}
+		return
+	}
 
-			maybeItem = types.NewString(string(make([]byte, byteOffset)))
-		}
+	if !checkStringLength(c, offset, int64(len(value))) {
+		return
+	}
 
-		item := maybeItem.(*types.String)
+	if maybeItem == nil {
+		maybeItem = types.NewStringFromBytes(nil)
+	}
 
-		// Need to append item to have enough spaces for byteOffset
-		// TODO: Optimize to use StringBuilder
-		if item.Len() <= int(byteOffset) {
-			newStr := item.AsString() + string(make([]byte, byteOffset-item.Len())) + value
-			item = types.NewString(newStr)
-		} else {
-			newStr := item.SubString(0, byteOffset) + value + item.SubString(byteOffset+len(value), item.Len())
-			item = types.NewString(newStr)
+	// The string is modified in place, Set only accounts for it
+	item := maybeItem.(*types.String)
+	item.SetRange(int(offset), value)
+	db.Set(key, item, ttl)
+	c.Conn().WriteInt(item.Len())
+}
+
+// checkStringLength returns whether writing length bytes at offset makes a
+// string allowed by proto-max-bulk-len, or else writes the error.
+func checkStringLength(c *pkg.Client, offset int64, length int64) bool {
+	limit := int64(defaultProtoMaxBulkLen)
+
+	if v := c.Redis().GetConfigValue("proto-max-bulk-len"); v != nil {
+		if n, err := strconv.ParseInt(*v, 10, 64); err == nil {
+			limit = n
 		}
+	}
 
-		db.Set(key, item, time.Time{})
-		c.Conn().WriteInt(item.Len())
+	// Compared without adding them, which could overflow
+	if offset > limit-length {
+		c.Conn().WriteError("ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+		return false
 	}
+
+	return true
 }
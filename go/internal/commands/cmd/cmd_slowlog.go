@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+// https://redis.io/commands/slowlog-get/
+// SLOWLOG GET [count]
+// SLOWLOG LEN
+// SLOWLOG RESET
+// SLOWLOG HELP
+func SlowlogCommand(c *pkg.Client, args [][]byte) {
+	if len(args) < 2 {
+		c.Conn().WriteError(fmt.Sprintf(util.WrongNumOfArgsErr, args[0]))
+		return
+	}
+
+	subcommand := strings.ToLower(string(args[1]))
+
+	switch {
+	case subcommand == "get" && len(args) <= 3:
+		count := int64(10)
+
+		if len(args) == 3 {
+			n, err := strconv.ParseInt(string(args[2]), 10, 64)
+
+			if err != nil || n < -1 {
+				c.Conn().WriteError("ERR count should be greater than or equal to -1")
+				return
+			}
+
+			count = n
+		}
+
+		entries := c.Redis().SlowlogGet(int(count))
+		c.Conn().WriteArray(len(entries))
+
+		for _, e := range entries {
+			c.Conn().WriteArray(6)
+			c.Conn().WriteInt64(e.Id)
+			c.Conn().WriteInt64(e.Time.Unix())
+			c.Conn().WriteInt64(e.Duration.Microseconds())
+			c.Conn().WriteArray(len(e.Args))
+
+			for _, arg := range e.Args {
+				c.Conn().WriteBulkString(arg)
+			}
+
+			c.Conn().WriteBulkString(e.ClientAddr)
+			c.Conn().WriteBulkString(e.ClientName)
+		}
+	case subcommand == "len" && len(args) == 2:
+		c.Conn().WriteInt(c.Redis().SlowlogLen())
+	case subcommand == "reset" && len(args) == 2:
+		c.Redis().SlowlogReset()
+		c.Conn().WriteString("OK")
+	case subcommand == "help" && len(args) == 2:
+		writeHelp(c, "SLOWLOG", []string{
+			"GET [<count>]",
+			"    Return top <count> entries from the slowlog (default: 10, -1 mean all).",
+			"    Entries are made of:",
+			"    id, timestamp, time in microseconds, arguments array, client IP and port,",
+			"    client name",
+			"LEN",
+			"    Return the length of the slowlog.",
+			"RESET",
+			"    Reset the slowlog.",
+		})
+	default:
+		c.Conn().WriteError(fmt.Sprintf("ERR unknown subcommand or wrong number of arguments for '%s'. Try SLOWLOG HELP.", string(args[1])))
+	}
+}
+
+// writeHelp replies with the help of a command with subcommands, as Redis formats it.
+func writeHelp(c *pkg.Client, command string, lines []string) {
+	c.Conn().WriteArray(len(lines) + 3)
+	c.Conn().WriteString(fmt.Sprintf("%s <subcommand> [<arg> [value] [opt] ...]. Subcommands are:", command))
+
+	for _, line := range lines {
+		c.Conn().WriteString(line)
+	}
+
+	c.Conn().WriteString("HELP")
+	c.Conn().WriteString("    Print this help.")
+}
@@ -25,6 +25,7 @@ func SpopCommand(c *pkg.Client, args [][]byte) {
 
 		if err != nil || count64 < 0 {
 			c.Conn().WriteError(util.InvalidIntErr)
+			return
 		}
 
 		count32 := int(count64)
@@ -33,7 +34,7 @@ func SpopCommand(c *pkg.Client, args [][]byte) {
 
 	db := c.Db()
 
-	maybeSet, _ := db.Get(key)
+	maybeSet, ttl := db.Get(key)
 
 	// If any of the sets are nil, then the intersections must be 0
 	if maybeSet == nil {
@@ -62,6 +63,11 @@ func SpopCommand(c *pkg.Client, args [][]byte) {
 			}
 		}
 
+		// The set is deleted once it is empty
+		if len(removed) > 0 {
+			db.Set(key, set, ttl)
+		}
+
 		c.Conn().WriteArray(len(removed))
 		for _, k := range removed {
 			c.Conn().WriteBulkString(k)
@@ -70,6 +76,7 @@ func SpopCommand(c *pkg.Client, args [][]byte) {
 		member := set.Pop()
 
 		if member != nil {
+			db.Set(key, set, ttl)
 			c.Conn().WriteBulkString(*member)
 		} else {
 			if c.R3 {
@@ -29,5 +29,5 @@ func TtlCommand(c *pkg.Client, args [][]byte) {
 		return
 	}
 
-	c.Conn().WriteInt64(int64(time.Until(ttl).Seconds()))
+	c.Conn().WriteInt64(int64(ttl.Sub(c.Redis().Now()).Seconds()))
 }
@@ -29,7 +29,7 @@ func ZremrangebylexCommand(c *pkg.Client, args [][]byte) {
 	}
 
 	db := c.Db()
-	maybeSet, _ := db.Get(key)
+	maybeSet, ttl := db.Get(key)
 
 	if maybeSet == nil {
 		maybeSet = types.NewZSet()
@@ -57,8 +57,9 @@ func ZremrangebylexCommand(c *pkg.Client, args [][]byte) {
 		}
 	}
 
-	if set.Len() == 0 {
-		db.Delete(key)
+	// The set is deleted once it is empty
+	if count > 0 {
+		db.Set(key, maybeSet, ttl)
 	}
 
 	c.Conn().WriteInt(count)
@@ -0,0 +1,1025 @@
+package pkg
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hbina/radish/internal/util"
+)
+
+// ACL categories. Please check the ACL documentation
+// for more information about the meaning of every category.
+const (
+	ACL_CATEGORY_KEYSPACE uint64 = 1 << iota
+	ACL_CATEGORY_READ
+	ACL_CATEGORY_WRITE
+	ACL_CATEGORY_SET
+	ACL_CATEGORY_SORTEDSET
+	ACL_CATEGORY_LIST
+	ACL_CATEGORY_HASH
+	ACL_CATEGORY_STRING
+	ACL_CATEGORY_BITMAP
+	ACL_CATEGORY_HYPERLOGLOG
+	ACL_CATEGORY_GEO
+	ACL_CATEGORY_STREAM
+	ACL_CATEGORY_PUBSUB
+	ACL_CATEGORY_ADMIN
+	ACL_CATEGORY_FAST
+	ACL_CATEGORY_SLOW
+	ACL_CATEGORY_BLOCKING
+	ACL_CATEGORY_DANGEROUS
+	ACL_CATEGORY_CONNECTION
+	ACL_CATEGORY_TRANSACTION
+	ACL_CATEGORY_SCRIPTING
+)
+
+// The ordering follows the output of ACL CAT in the reference implementation.
+var aclCategories = []struct {
+	name string
+	flag uint64
+}{
+	{"keyspace", ACL_CATEGORY_KEYSPACE},
+	{"read", ACL_CATEGORY_READ},
+	{"write", ACL_CATEGORY_WRITE},
+	{"set", ACL_CATEGORY_SET},
+	{"sortedset", ACL_CATEGORY_SORTEDSET},
+	{"list", ACL_CATEGORY_LIST},
+	{"hash", ACL_CATEGORY_HASH},
+	{"string", ACL_CATEGORY_STRING},
+	{"bitmap", ACL_CATEGORY_BITMAP},
+	{"hyperloglog", ACL_CATEGORY_HYPERLOGLOG},
+	{"geo", ACL_CATEGORY_GEO},
+	{"stream", ACL_CATEGORY_STREAM},
+	{"pubsub", ACL_CATEGORY_PUBSUB},
+	{"admin", ACL_CATEGORY_ADMIN},
+	{"fast", ACL_CATEGORY_FAST},
+	{"slow", ACL_CATEGORY_SLOW},
+	{"blocking", ACL_CATEGORY_BLOCKING},
+	{"dangerous", ACL_CATEGORY_DANGEROUS},
+	{"connection", ACL_CATEGORY_CONNECTION},
+	{"transaction", ACL_CATEGORY_TRANSACTION},
+	{"scripting", ACL_CATEGORY_SCRIPTING},
+}
+
+// Reasons of an ACL LOG entry.
+const (
+	ACL_DENIED_CMD     = "command"
+	ACL_DENIED_KEY     = "key"
+	ACL_DENIED_CHANNEL = "channel"
+	ACL_DENIED_AUTH    = "auth"
+)
+
+const (
+	AclNoFileErr      = "ERR This Redis instance is not configured to use an ACL file. You may want to specify users via the ACL SETUSER command and then issue a CONFIG REWRITE (assuming you have a Redis configuration file set) in order to store users in the Redis configuration."
+	AclSyntaxErr      = "Syntax error"
+	AclUnknownCmdErr  = "Unknown command or category name in ACL"
+	AclNoSuchPassErr  = "The password you are trying to remove from the user does not exist"
+	AclBadHashErr     = "The password hash must be exactly 64 characters and contain only lowercase hexadecimal characters"
+	AclSelectorErr    = "Selectors are not supported"
+	AclDefaultUserErr = "ERR The 'default' user cannot be removed"
+)
+
+// ACL LOG entries with the same reason, context, object and username
+// that happen within this duration are grouped together.
+const aclLogGroupingDuration = 60 * time.Second
+
+// AclCategoryFlag returns the flag of the given ACL category name.
+func AclCategoryFlag(name string) (uint64, bool) {
+	name = strings.ToLower(name)
+
+	for _, cat := range aclCategories {
+		if cat.name == name {
+			return cat.flag, true
+		}
+	}
+
+	return 0, false
+}
+
+// AclCategoryNames returns the names of all ACL categories.
+func AclCategoryNames() []string {
+	res := make([]string, 0, len(aclCategories))
+
+	for _, cat := range aclCategories {
+		res = append(res, cat.name)
+	}
+
+	return res
+}
+
+// AclCategoriesOf returns the names of the ACL categories in cats.
+func AclCategoriesOf(cats uint64) []string {
+	res := make([]string, 0)
+
+	for _, cat := range aclCategories {
+		if cats&cat.flag != 0 {
+			res = append(res, cat.name)
+		}
+	}
+
+	return res
+}
+
+type keyPattern struct {
+	pattern string
+	flags   uint64 // KEY_READ and/or KEY_WRITE
+}
+
+func (kp keyPattern) String() string {
+	switch kp.flags {
+	case KEY_READ:
+		return "%R~" + kp.pattern
+	case KEY_WRITE:
+		return "%W~" + kp.pattern
+	default:
+		return "~" + kp.pattern
+	}
+}
+
+// A User of the ACL system.
+type User struct {
+	name      string
+	enabled   bool
+	nopass    bool
+	passwords []string // SHA256 hex digest of the passwords
+	commands  []string // Command rules, evaluated in order
+	keys      []keyPattern
+	channels  []string
+	deleted   bool // Set when the user is removed so that its clients can be disconnected
+}
+
+func newUser(name string) *User {
+	return &User{
+		name:      name,
+		passwords: make([]string, 0),
+		commands:  []string{"-@all"},
+		keys:      make([]keyPattern, 0),
+		channels:  make([]string, 0),
+	}
+}
+
+func newDefaultUser() *User {
+	u := newUser("default")
+	u.enabled = true
+	u.nopass = true
+	u.commands = []string{"+@all"}
+	u.keys = []keyPattern{{pattern: "*", flags: KEY_READ | KEY_WRITE}}
+	u.channels = []string{"*"}
+	return u
+}
+
+// Name returns the name of the user.
+func (u *User) Name() string {
+	return u.name
+}
+
+func (u *User) clone() *User {
+	return &User{
+		name:      u.name,
+		enabled:   u.enabled,
+		nopass:    u.nopass,
+		passwords: append(make([]string, 0, len(u.passwords)), u.passwords...),
+		commands:  append(make([]string, 0, len(u.commands)), u.commands...),
+		keys:      append(make([]keyPattern, 0, len(u.keys)), u.keys...),
+		channels:  append(make([]string, 0, len(u.channels)), u.channels...),
+	}
+}
+
+// copyFrom replaces the rules of u with the rules of o while
+// keeping the identity of u since clients holds a reference to it.
+func (u *User) copyFrom(o *User) {
+	u.enabled = o.enabled
+	u.nopass = o.nopass
+	u.passwords = o.passwords
+	u.commands = o.commands
+	u.keys = o.keys
+	u.channels = o.channels
+}
+
+func hashPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func isValidPasswordHash(hash string) bool {
+	if len(hash) != 64 {
+		return false
+	}
+
+	for i := 0; i < len(hash); i++ {
+		if !(hash[i] >= '0' && hash[i] <= '9') && !(hash[i] >= 'a' && hash[i] <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (u *User) addPasswordHash(hash string) {
+	for _, p := range u.passwords {
+		if p == hash {
+			return
+		}
+	}
+
+	u.passwords = append(u.passwords, hash)
+	u.nopass = false
+}
+
+func (u *User) removePasswordHash(hash string) bool {
+	for i, p := range u.passwords {
+		if p == hash {
+			u.passwords = append(u.passwords[:i], u.passwords[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyRule applies a single ACL rule to the user.
+// The lookup function is used to validate command names.
+func (u *User) applyRule(rule string, lookup func(name string) *CommandInfo) error {
+	lower := strings.ToLower(rule)
+
+	switch lower {
+	case "on":
+		u.enabled = true
+	case "off":
+		u.enabled = false
+	case "nopass":
+		u.nopass = true
+		u.passwords = make([]string, 0)
+	case "resetpass":
+		u.nopass = false
+		u.passwords = make([]string, 0)
+	case "allkeys":
+		u.keys = []keyPattern{{pattern: "*", flags: KEY_READ | KEY_WRITE}}
+	case "resetkeys":
+		u.keys = make([]keyPattern, 0)
+	case "allchannels":
+		u.channels = []string{"*"}
+	case "resetchannels":
+		u.channels = make([]string, 0)
+	case "allcommands":
+		u.commands = []string{"+@all"}
+	case "nocommands":
+		u.commands = []string{"-@all"}
+	case "sanitize-payload", "skip-sanitize-payload":
+		// Payloads are not sanitized at the moment
+	case "reset":
+		u.enabled = false
+		u.nopass = false
+		u.passwords = make([]string, 0)
+		u.keys = make([]keyPattern, 0)
+		u.channels = make([]string, 0)
+		u.commands = []string{"-@all"}
+	default:
+		if len(rule) == 0 {
+			return errors.New(AclSyntaxErr)
+		}
+
+		switch {
+		case rule[0] == '>':
+			u.addPasswordHash(hashPassword(rule[1:]))
+		case rule[0] == '<':
+			if !u.removePasswordHash(hashPassword(rule[1:])) {
+				return errors.New(AclNoSuchPassErr)
+			}
+		case rule[0] == '#':
+			if !isValidPasswordHash(rule[1:]) {
+				return errors.New(AclBadHashErr)
+			}
+
+			u.addPasswordHash(rule[1:])
+		case rule[0] == '!':
+			if !isValidPasswordHash(rule[1:]) {
+				return errors.New(AclBadHashErr)
+			}
+
+			if !u.removePasswordHash(rule[1:]) {
+				return errors.New(AclNoSuchPassErr)
+			}
+		case rule[0] == '~':
+			u.keys = append(u.keys, keyPattern{pattern: rule[1:], flags: KEY_READ | KEY_WRITE})
+		case rule[0] == '%':
+			idx := strings.IndexByte(rule, '~')
+
+			if idx < 2 {
+				return errors.New(AclSyntaxErr)
+			}
+
+			var flags uint64
+
+			for _, f := range strings.ToUpper(rule[1:idx]) {
+				if f == 'R' {
+					flags |= KEY_READ
+				} else if f == 'W' {
+					flags |= KEY_WRITE
+				} else {
+					return errors.New(AclSyntaxErr)
+				}
+			}
+
+			u.keys = append(u.keys, keyPattern{pattern: rule[idx+1:], flags: flags})
+		case rule[0] == '&':
+			u.channels = append(u.channels, rule[1:])
+		case rule[0] == '+' || rule[0] == '-':
+			name := strings.ToLower(rule[1:])
+
+			if name == "@all" {
+				u.commands = []string{rule[:1] + name}
+				return nil
+			}
+
+			if strings.HasPrefix(name, "@") {
+				if _, ok := AclCategoryFlag(name[1:]); !ok {
+					return errors.New(AclUnknownCmdErr)
+				}
+			} else {
+				base := name
+
+				if idx := strings.IndexByte(name, '|'); idx >= 0 {
+					base = name[:idx]
+
+					if rule[0] == '-' || idx == len(name)-1 {
+						// Blocking subcommands is not allowed by the reference implementation either
+						return errors.New(AclSyntaxErr)
+					}
+				}
+
+				if lookup(base) == nil {
+					return errors.New(AclUnknownCmdErr)
+				}
+			}
+
+			u.commands = append(u.commands, rule[:1]+name)
+		case rule[0] == '(':
+			return errors.New(AclSelectorErr)
+		default:
+			return errors.New(AclSyntaxErr)
+		}
+	}
+
+	return nil
+}
+
+// flags returns the flags of the user in the format of ACL GETUSER.
+func (u *User) flags() []string {
+	res := make([]string, 0)
+
+	if u.enabled {
+		res = append(res, "on")
+	} else {
+		res = append(res, "off")
+	}
+
+	if u.nopass {
+		res = append(res, "nopass")
+	}
+
+	return res
+}
+
+func (u *User) keysDescription() string {
+	res := make([]string, 0, len(u.keys))
+
+	for _, k := range u.keys {
+		res = append(res, k.String())
+	}
+
+	return strings.Join(res, " ")
+}
+
+func (u *User) channelsDescription() string {
+	res := make([]string, 0, len(u.channels))
+
+	for _, ch := range u.channels {
+		res = append(res, "&"+ch)
+	}
+
+	return strings.Join(res, " ")
+}
+
+// describe returns the rules that would recreate the user.
+func (u *User) describe() string {
+	res := u.flags()
+
+	for _, p := range u.passwords {
+		res = append(res, "#"+p)
+	}
+
+	if len(u.keys) != 0 {
+		res = append(res, u.keysDescription())
+	}
+
+	if len(u.channels) != 0 {
+		res = append(res, u.channelsDescription())
+	} else {
+		res = append(res, "resetchannels")
+	}
+
+	res = append(res, u.commands...)
+
+	return strings.Join(res, " ")
+}
+
+func (u *User) checkPassword(password string) bool {
+	if !u.enabled {
+		return false
+	}
+
+	if u.nopass {
+		return true
+	}
+
+	hash := hashPassword(password)
+
+	for _, p := range u.passwords {
+		if p == hash {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (u *User) canRunCommand(ci *CommandInfo, args [][]byte) bool {
+	allowed := false
+	cats := ci.AclCategories()
+	sub := ""
+
+	if len(args) > 1 {
+		sub = strings.ToLower(string(args[1]))
+	}
+
+	for _, rule := range u.commands {
+		allow := rule[0] == '+'
+		name := rule[1:]
+
+		if name == "@all" {
+			allowed = allow
+		} else if strings.HasPrefix(name, "@") {
+			flag, _ := AclCategoryFlag(name[1:])
+
+			if cats&flag != 0 {
+				allowed = allow
+			}
+		} else if idx := strings.IndexByte(name, '|'); idx >= 0 {
+			if name[:idx] == ci.Name && name[idx+1:] == sub {
+				allowed = allow
+			}
+		} else if name == ci.Name {
+			allowed = allow
+		}
+	}
+
+	return allowed
+}
+
+func (u *User) canAccessKey(key string, flags uint64) bool {
+	for _, kp := range u.keys {
+		if kp.flags&flags == flags && util.StringMatch(kp.pattern, key, false) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (u *User) canAccessChannel(channel string, isPattern bool) bool {
+	for _, ch := range u.channels {
+		if ch == "*" {
+			return true
+		}
+
+		// Patterns must match literally otherwise a pattern could
+		// be used to receive messages from forbidden channels.
+		if isPattern && ch == channel {
+			return true
+		} else if !isPattern && util.StringMatch(ch, channel, false) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AclLogEntry is a single entry in the ACL LOG.
+type AclLogEntry struct {
+	Count      int
+	Reason     string
+	Context    string
+	Object     string
+	Username   string
+	ClientInfo string
+	EntryId    int64
+	Created    time.Time
+	Updated    time.Time
+}
+
+// AclUserInfo is the description of a user returned by ACL GETUSER.
+type AclUserInfo struct {
+	Flags     []string
+	Passwords []string
+	Commands  string
+	Keys      string
+	Channels  string
+}
+
+// Acl holds the users and the denied operations log.
+type Acl struct {
+	users     map[string]*User
+	log       []*AclLogEntry // Newest entries first
+	nextLogId int64
+	mu        *sync.RWMutex
+}
+
+func NewAcl() *Acl {
+	return &Acl{
+		users: map[string]*User{"default": newDefaultUser()},
+		log:   make([]*AclLogEntry, 0),
+		mu:    new(sync.RWMutex),
+	}
+}
+
+// DefaultUser returns the default user that new clients are authenticated as.
+func (r *Redis) DefaultUser() *User {
+	r.acl.mu.RLock()
+	defer r.acl.mu.RUnlock()
+
+	return r.acl.users["default"]
+}
+
+func (r *Redis) lookupCommandInfo(name string) *CommandInfo {
+	if cmd, ok := r.cmds[name]; ok {
+		return &cmd.CommandInfo
+	}
+
+	if bcmd, ok := r.bcmds[name]; ok {
+		return &bcmd.CommandInfo
+	}
+
+	return nil
+}
+
+// AclSetUser creates or modifies the user with the given rules.
+// Rules are applied atomically, either all of them succeed or the user is left untouched.
+func (r *Redis) AclSetUser(name string, rules []string) error {
+	r.acl.mu.Lock()
+	defer r.acl.mu.Unlock()
+
+	return r.aclSetUser(name, rules)
+}
+
+func (r *Redis) aclSetUser(name string, rules []string) error {
+	user, exists := r.acl.users[name]
+
+	var updated *User
+
+	if exists {
+		updated = user.clone()
+	} else {
+		updated = newUser(name)
+	}
+
+	for _, rule := range rules {
+		err := updated.applyRule(rule, r.lookupCommandInfo)
+
+		if err != nil {
+			return fmt.Errorf("ERR Error in ACL SETUSER modifier '%s': %s", rule, err.Error())
+		}
+	}
+
+	if exists {
+		user.copyFrom(updated)
+	} else {
+		r.acl.users[name] = updated
+	}
+
+	return nil
+}
+
+// AclGetUser returns the description of the user.
+func (r *Redis) AclGetUser(name string) (AclUserInfo, bool) {
+	r.acl.mu.RLock()
+	defer r.acl.mu.RUnlock()
+
+	user, exists := r.acl.users[name]
+
+	if !exists {
+		return AclUserInfo{}, false
+	}
+
+	return AclUserInfo{
+		Flags:     user.flags(),
+		Passwords: append(make([]string, 0, len(user.passwords)), user.passwords...),
+		Commands:  strings.Join(user.commands, " "),
+		Keys:      user.keysDescription(),
+		Channels:  user.channelsDescription(),
+	}, true
+}
+
+// AclDelUser deletes the users and returns the number of deleted users.
+// Clients authenticated as these users will be disconnected.
+func (r *Redis) AclDelUser(names ...string) (int, error) {
+	r.acl.mu.Lock()
+	defer r.acl.mu.Unlock()
+
+	for _, name := range names {
+		if name == "default" {
+			return 0, errors.New(AclDefaultUserErr)
+		}
+	}
+
+	count := 0
+
+	for _, name := range names {
+		user, exists := r.acl.users[name]
+
+		if exists {
+			user.deleted = true
+			delete(r.acl.users, name)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// AclUsernames returns the sorted names of all users.
+func (r *Redis) AclUsernames() []string {
+	r.acl.mu.RLock()
+	defer r.acl.mu.RUnlock()
+
+	res := make([]string, 0, len(r.acl.users))
+
+	for name := range r.acl.users {
+		res = append(res, name)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// AclList returns the description of all users in the ACL file format.
+func (r *Redis) AclList() []string {
+	names := r.AclUsernames()
+
+	r.acl.mu.RLock()
+	defer r.acl.mu.RUnlock()
+
+	res := make([]string, 0, len(names))
+
+	for _, name := range names {
+		user, exists := r.acl.users[name]
+
+		if exists {
+			res = append(res, fmt.Sprintf("user %s %s", name, user.describe()))
+		}
+	}
+
+	return res
+}
+
+// AclCategoryCommands returns the sorted names of commands within the category.
+func (r *Redis) AclCategoryCommands(category string) ([]string, bool) {
+	flag, ok := AclCategoryFlag(category)
+
+	if !ok {
+		return nil, false
+	}
+
+	res := make([]string, 0)
+
+	for name, cmd := range r.cmds {
+		if cmd.AclCategories()&flag != 0 {
+			res = append(res, name)
+		}
+	}
+
+	for name, bcmd := range r.bcmds {
+		if bcmd.AclCategories()&flag != 0 {
+			res = append(res, name)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res, true
+}
+
+// AclCheck checks that the client is allowed to execute the command with the given arguments.
+// Denied attempts are added to the ACL LOG and the returned string is the error to reply with.
+func (r *Redis) AclCheck(c *Client, ci *CommandInfo, args [][]byte) (string, bool) {
+	r.acl.mu.RLock()
+	user := c.user
+	reason := ""
+	object := ""
+
+	if !user.canRunCommand(ci, args) {
+		reason = ACL_DENIED_CMD
+		object = ci.Name
+
+		if len(args) > 1 {
+			for _, rule := range user.commands {
+				if strings.HasPrefix(rule[1:], ci.Name+"|") {
+					object = ci.Name + "|" + strings.ToLower(string(args[1]))
+					break
+				}
+			}
+		}
+	}
+
+	if reason == "" {
+		indexes, flags := ci.KeyIndexes(args)
+
+		for i, idx := range indexes {
+			if !user.canAccessKey(string(args[idx]), flags[i]) {
+				reason = ACL_DENIED_KEY
+				object = string(args[idx])
+				break
+			}
+		}
+	}
+
+	if reason == "" {
+		channels, isPattern := commandChannels(ci.Name, args)
+
+		for _, ch := range channels {
+			if !user.canAccessChannel(ch, isPattern) {
+				reason = ACL_DENIED_CHANNEL
+				object = ch
+				break
+			}
+		}
+	}
+
+	r.acl.mu.RUnlock()
+
+	if reason == "" {
+		return "", true
+	}
+
+	r.AclAddLogEntry(c, reason, "toplevel", object, user.name)
+
+	switch reason {
+	case ACL_DENIED_KEY:
+		return "NOPERM No permissions to access a key", false
+	case ACL_DENIED_CHANNEL:
+		return "NOPERM No permissions to access a channel", false
+	default:
+		return fmt.Sprintf("NOPERM User %s has no permissions to run the '%s' command", user.name, object), false
+	}
+}
+
+// commandChannels returns the pub/sub channels accessed by the command
+// and whether they are patterns.
+func commandChannels(name string, args [][]byte) ([]string, bool) {
+	res := make([]string, 0)
+
+	switch name {
+	case "publish":
+		if len(args) > 1 {
+			res = append(res, string(args[1]))
+		}
+	case "subscribe":
+		for _, arg := range args[1:] {
+			res = append(res, string(arg))
+		}
+	case "psubscribe":
+		for _, arg := range args[1:] {
+			res = append(res, string(arg))
+		}
+		return res, true
+	}
+
+	return res, false
+}
+
+// AclAddLogEntry adds an entry to the ACL LOG.
+func (r *Redis) AclAddLogEntry(c *Client, reason string, context string, object string, username string) {
+	r.acl.mu.Lock()
+	defer r.acl.mu.Unlock()
+
+	now := r.Now()
+
+	for _, e := range r.acl.log {
+		if e.Reason == reason && e.Context == context && e.Object == object &&
+			e.Username == username && now.Sub(e.Updated) < aclLogGroupingDuration {
+			e.Count++
+			e.Updated = now
+			e.ClientInfo = c.info()
+			return
+		}
+	}
+
+	entry := &AclLogEntry{
+		Count:      1,
+		Reason:     reason,
+		Context:    context,
+		Object:     object,
+		Username:   username,
+		ClientInfo: c.info(),
+		EntryId:    r.acl.nextLogId,
+		Created:    now,
+		Updated:    now,
+	}
+
+	r.acl.nextLogId++
+	r.acl.log = append([]*AclLogEntry{entry}, r.acl.log...)
+
+	maxLen := 128
+
+	if v := r.GetConfigValue("acllog-max-len"); v != nil {
+		if n, err := strconv.Atoi(*v); err == nil && n >= 0 {
+			maxLen = n
+		}
+	}
+
+	if len(r.acl.log) > maxLen {
+		r.acl.log = r.acl.log[:maxLen]
+	}
+}
+
+// AclLog returns up to count entries of the ACL LOG, newest first.
+func (r *Redis) AclLog(count int) []AclLogEntry {
+	r.acl.mu.RLock()
+	defer r.acl.mu.RUnlock()
+
+	if count > len(r.acl.log) || count < 0 {
+		count = len(r.acl.log)
+	}
+
+	res := make([]AclLogEntry, 0, count)
+
+	for _, e := range r.acl.log[:count] {
+		res = append(res, *e)
+	}
+
+	return res
+}
+
+// AclLogReset clears the ACL LOG.
+func (r *Redis) AclLogReset() {
+	r.acl.mu.Lock()
+	defer r.acl.mu.Unlock()
+
+	r.acl.log = make([]*AclLogEntry, 0)
+}
+
+func (r *Redis) aclFile() (string, bool) {
+	v := r.GetConfigValue("aclfile")
+
+	if v == nil || *v == "" {
+		return "", false
+	}
+
+	return *v, true
+}
+
+// AclSave saves the users into the configured ACL file.
+func (r *Redis) AclSave() error {
+	path, ok := r.aclFile()
+
+	if !ok {
+		return errors.New(AclNoFileErr)
+	}
+
+	var sb strings.Builder
+
+	for _, line := range r.AclList() {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+
+	// Write to a temporary file first so that the ACL file is never left half-written
+	tmp, err := os.CreateTemp(filepath.Dir(path), "temp-acl-*")
+
+	if err != nil {
+		return fmt.Errorf("ERR There was an error trying to save the ACLs. Please check the server logs for more information")
+	}
+
+	_, err = tmp.WriteString(sb.String())
+
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ERR There was an error trying to save the ACLs. Please check the server logs for more information")
+	}
+
+	return nil
+}
+
+// AclLoad replaces all users with the ones defined in the configured ACL file.
+// If there is any error, the current users are left untouched.
+func (r *Redis) AclLoad() error {
+	path, ok := r.aclFile()
+
+	if !ok {
+		return errors.New(AclNoFileErr)
+	}
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return fmt.Errorf("ERR Error loading ACLs, opening file '%s': %s", path, err.Error())
+	}
+
+	defer file.Close()
+
+	r.acl.mu.Lock()
+	defer r.acl.mu.Unlock()
+
+	users := make(map[string]*User)
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		args := strings.Fields(line)
+
+		if args[0] != "user" || len(args) < 2 {
+			return fmt.Errorf("ERR %s:%d: line should start with user keyword", path, lineNum)
+		}
+
+		name := args[1]
+
+		if _, exists := users[name]; exists {
+			return fmt.Errorf("ERR %s:%d: Duplicate user '%s' found", path, lineNum, name)
+		}
+
+		user := newUser(name)
+
+		for _, rule := range args[2:] {
+			err := user.applyRule(rule, r.lookupCommandInfo)
+
+			if err != nil {
+				return fmt.Errorf("ERR %s:%d: Error in user declaration '%s': %s", path, lineNum, rule, err.Error())
+			}
+		}
+
+		users[name] = user
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ERR Error loading ACLs, reading file '%s': %s", path, err.Error())
+	}
+
+	// The default user is always present
+	if _, exists := users["default"]; !exists {
+		users["default"] = newDefaultUser()
+	}
+
+	// Keep the identity of existing users since clients holds a reference to them
+	for name, user := range r.acl.users {
+		if u, exists := users[name]; exists {
+			user.copyFrom(u)
+			users[name] = user
+		} else {
+			user.deleted = true
+		}
+	}
+
+	r.acl.users = users
+
+	return nil
+}
+
+// setDefaultUserPassword is used to keep the default user in sync with requirepass.
+func (r *Redis) setDefaultUserPassword(password string) error {
+	r.acl.mu.Lock()
+	defer r.acl.mu.Unlock()
+
+	if password == "" {
+		return r.aclSetUser("default", []string{"resetpass", "nopass"})
+	}
+
+	return r.aclSetUser("default", []string{"resetpass", ">" + password})
+}
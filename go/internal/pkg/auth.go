@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"net"
+	"strings"
+)
+
+// RequiresPass returns whether the default user is protected by a password.
+func (r *Redis) RequiresPass() bool {
+	r.acl.mu.RLock()
+	defer r.acl.mu.RUnlock()
+
+	u := r.acl.users["default"]
+
+	return !u.nopass || !u.enabled
+}
+
+// AuthRequired returns whether the client must authenticate before it can call
+// the command. Commands flagged with CMD_NO_AUTH are always allowed.
+func (r *Redis) AuthRequired(c *Client, info *CommandInfo) bool {
+	if c.authenticated || !r.RequiresPass() {
+		return false
+	}
+
+	return info == nil || info.Flag&CMD_NO_AUTH == 0
+}
+
+// CheckPassword checks the username-password pair against the ACL users.
+func (r *Redis) CheckPassword(username string, password string) (*User, bool) {
+	r.acl.mu.RLock()
+	defer r.acl.mu.RUnlock()
+
+	user, exists := r.acl.users[username]
+
+	if !exists || !user.checkPassword(password) {
+		return nil, false
+	}
+
+	return user, true
+}
+
+// Authenticate authenticates the client as the user if the username-password pair is valid.
+// Failed attempts are added to the ACL LOG.
+func (r *Redis) Authenticate(c *Client, username string, password string) bool {
+	user, ok := r.CheckPassword(username, password)
+
+	if !ok {
+		r.AclAddLogEntry(c, ACL_DENIED_AUTH, "toplevel", "AUTH", username)
+		return false
+	}
+
+	c.user = user
+	c.authenticated = true
+	return true
+}
+
+// IsProtected returns whether the client should be denied because
+// protected mode is enabled, there is no password and the client
+// is not connecting from the loopback interface.
+func (r *Redis) IsProtected(c *Client) bool {
+	v := r.GetConfigValue("protected-mode")
+
+	if v == nil || strings.ToLower(*v) != "yes" || r.RequiresPass() {
+		return false
+	}
+
+	return !isLocalAddr(c.Conn().RemoteAddr())
+}
+
+func isLocalAddr(addr net.Addr) bool {
+	if addr == nil {
+		return true
+	}
+
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP.IsLoopback()
+	case *net.UnixAddr:
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+
+	if err != nil {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
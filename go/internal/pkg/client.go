@@ -2,6 +2,12 @@ package pkg
 
 import (
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hbina/radish/internal/types"
 	"github.com/hbina/radish/internal/util"
@@ -9,25 +15,210 @@ import (
 
 // A connected Client.
 type Client struct {
-	conn  *util.Conn
-	dbId  uint64
-	redis *Redis
-	R3    bool
-	Name  *string
+	id            uint64
+	conn          *util.Conn
+	dbId          uint64
+	redis         *Redis
+	R3            bool
+	Name          *string
+	authenticated bool
+	user          *User
+	failed        bool // Whether the current command has replied with an error
+	monitor       bool
+	writeMu       *sync.Mutex   // Held while a reply or a pushed message is written
+	outbox        chan []byte   // Messages waiting to be pushed
+	pending       atomic.Int64  // Bytes waiting to be pushed
+	closed        chan struct{} // Closed once the client is closed
+	closeOnce     *sync.Once
+	created       time.Time
+	lastActive    atomic.Int64 // Unix time in nanoseconds of the last command
+	lastCmd       atomic.Value // Name of the last command
+	qbuf          atomic.Int64 // Length of the unprocessed query buffer
+	replyOff      bool         // Set by CLIENT REPLY OFF
+	replySkip     bool         // Set by CLIENT REPLY SKIP
+	closeAfter    bool         // Close the connection once the reply is written
+	// Subscriptions to channels and patterns, protected by the lock of the pubsub
+	channels map[string]struct{}
+	patterns map[string]struct{}
 }
 
 func (c *Client) Read(buffer []byte) (int, error) {
 	return c.conn.Read(buffer)
 }
 
+// Id returns the unique id of the client within its redis.
+func (c *Client) Id() uint64 {
+	return c.id
+}
+
 func (c *Client) Redis() *Redis {
 	return c.redis
 }
 
 func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+
 	return c.conn.Close()
 }
 
+// CloseAfterReply closes the connection once the current command has been replied.
+func (c *Client) CloseAfterReply() {
+	c.closeAfter = true
+}
+
+// SetReplyMode sets the mode of CLIENT REPLY, which is "on", "off" or "skip".
+// The reply of the current command is skipped unless the mode is "on".
+func (c *Client) SetReplyMode(mode string) {
+	c.replyOff = mode == "off"
+	c.replySkip = mode == "skip"
+	c.conn.SetMuted(mode != "on")
+}
+
+// Age returns for how long the client has been connected.
+func (c *Client) Age() time.Duration {
+	return c.redis.Now().Sub(c.created)
+}
+
+// Type returns the type of the client used by CLIENT LIST and CLIENT KILL.
+func (c *Client) Type() string {
+	if c.SubscriptionCount() > 0 {
+		return "pubsub"
+	}
+
+	return "normal"
+}
+
+// flags returns the flags of the client as in CLIENT LIST.
+func (c *Client) flags(tracking *ClientTracking, subscriptions int) string {
+	flags := ""
+
+	if subscriptions > 0 {
+		flags += "P"
+	}
+
+	if c.monitor {
+		flags += "O"
+	}
+
+	if tracking != nil {
+		flags += "t"
+
+		if tracking.Bcast {
+			flags += "B"
+		}
+
+		if tracking.RedirectBroken() {
+			flags += "R"
+		}
+	}
+
+	if c.closeAfter {
+		flags += "A"
+	}
+
+	if flags == "" {
+		flags = "N"
+	}
+
+	return flags
+}
+
+// Info describes the client as a line of CLIENT LIST.
+func (c *Client) Info() string {
+	now := c.redis.Now()
+	name := ""
+
+	if c.Name != nil {
+		name = *c.Name
+	}
+
+	cmd, _ := c.lastCmd.Load().(string)
+
+	if cmd == "" {
+		cmd = "NULL"
+	}
+
+	resp := 2
+
+	if c.R3 {
+		resp = 3
+	}
+
+	oll := 0
+
+	if c.outbox != nil {
+		oll = len(c.outbox)
+	}
+
+	idle := now.Sub(time.Unix(0, c.lastActive.Load()))
+	tracking := c.redis.GetTracking(c)
+	sub, psub := c.subscriptionCounts()
+	redir := int64(-1)
+
+	if tracking != nil {
+		redir = int64(tracking.Redirect)
+	}
+
+	fields := []string{
+		"id=" + strconv.FormatUint(c.id, 10),
+		"addr=" + c.Addr(),
+		"laddr=" + c.LocalAddr(),
+		"fd=-1",
+		"name=" + name,
+		"age=" + strconv.FormatInt(int64(c.Age()/time.Second), 10),
+		"idle=" + strconv.FormatInt(int64(idle/time.Second), 10),
+		"flags=" + c.flags(tracking, sub+psub),
+		"db=" + strconv.FormatUint(c.dbId, 10),
+		"sub=" + strconv.Itoa(sub),
+		"psub=" + strconv.Itoa(psub),
+		"ssub=0",
+		"multi=-1",
+		"qbuf=" + strconv.FormatInt(c.qbuf.Load(), 10),
+		"qbuf-free=0",
+		"argv-mem=0",
+		"multi-mem=0",
+		"rbs=1024",
+		"rbp=0",
+		"obl=0",
+		"oll=" + strconv.Itoa(oll),
+		"omem=" + strconv.FormatInt(c.pending.Load(), 10),
+		"tot-mem=" + strconv.FormatInt(c.qbuf.Load()+c.pending.Load(), 10),
+		"events=r",
+		"cmd=" + cmd,
+		"user=" + c.user.name,
+		"redir=" + strconv.FormatInt(redir, 10),
+		"resp=" + strconv.Itoa(resp),
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// Addr returns the address of the client, the path of the socket for unix sockets.
+func (c *Client) Addr() string {
+	return formatAddr(c.conn.RemoteAddr(), c.conn.LocalAddr())
+}
+
+// LocalAddr returns the address that the client is connected to.
+func (c *Client) LocalAddr() string {
+	return formatAddr(c.conn.LocalAddr(), c.conn.LocalAddr())
+}
+
+// formatAddr formats the address as Redis does, unix sockets are named by the path of their listener.
+func formatAddr(addr net.Addr, local net.Addr) string {
+	if addr.Network() == "unix" {
+		return local.String() + ":0"
+	}
+
+	return addr.String()
+}
+
+// isUnixSocket returns whether the client is connected through a unix socket.
+func (c *Client) isUnixSocket() bool {
+	return strings.HasPrefix(c.conn.RemoteAddr().Network(), "unix")
+}
+
 func (c *Client) Conn() *util.Conn {
 	return c.conn
 }
@@ -46,6 +237,27 @@ func (c *Client) Db() *Db {
 	return c.redis.GetDb(c.dbId)
 }
 
+// Authenticated returns whether the client has been authenticated.
+func (c *Client) Authenticated() bool {
+	return c.authenticated
+}
+
+// User returns the ACL user that the client is authenticated as.
+func (c *Client) User() *User {
+	return c.user
+}
+
+// info returns a short description of the client for logging purposes.
+func (c *Client) info() string {
+	name := ""
+
+	if c.Name != nil {
+		name = *c.Name
+	}
+
+	return fmt.Sprintf("addr=%s name=%s db=%d user=%s", c.conn.RemoteAddr(), name, c.dbId, c.user.name)
+}
+
 func (c *Client) UseResp2() {
 	c.R3 = false
 }
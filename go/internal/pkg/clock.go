@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time used for the expiries and the blocking timeouts.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f once the duration has elapsed.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending call created by Clock.AfterFunc.
+type Timer interface {
+	// Stop prevents the call from firing.
+	// It returns false if the call has already fired or been stopped.
+	Stop() bool
+}
+
+// SystemClock is the clock backed by the time package.
+type SystemClock struct{}
+
+var _ Clock = SystemClock{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// ManualClock is a clock that only moves when it is advanced.
+type ManualClock struct {
+	mu     *sync.Mutex
+	now    time.Time
+	timers map[*manualTimer]struct{}
+}
+
+var _ Clock = &ManualClock{}
+
+type manualTimer struct {
+	clock    *ManualClock
+	deadline time.Time
+	f        func()
+}
+
+func (t *manualTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	_, exists := t.clock.timers[t]
+	delete(t.clock.timers, t)
+	return exists
+}
+
+// NewManualClock creates a clock that starts at now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{
+		mu:     new(sync.Mutex),
+		now:    now,
+		timers: make(map[*manualTimer]struct{}, 0),
+	}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &manualTimer{
+		clock:    c,
+		deadline: c.now.Add(d),
+		f:        f,
+	}
+	c.timers[t] = struct{}{}
+
+	return t
+}
+
+// Advance moves the clock forward and synchronously calls the functions
+// of the timers that are due, in the order of their deadlines.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+
+	c.now = c.now.Add(d)
+	due := make([]*manualTimer, 0)
+
+	for t := range c.timers {
+		if !t.deadline.After(c.now) {
+			due = append(due, t)
+			delete(c.timers, t)
+		}
+	}
+
+	c.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].deadline.Before(due[j].deadline)
+	})
+
+	for _, t := range due {
+		t.f()
+	}
+}
+
+// Now returns the current time of the redis clock.
+func (r *Redis) Now() time.Time {
+	return r.clock.Now()
+}
+
+// Clock returns the clock of the redis.
+func (r *Redis) Clock() Clock {
+	return r.clock
+}
+
+// SetClock replaces the clock of the redis.
+func (r *Redis) SetClock(clock Clock) {
+	r.clock = clock
+}
+
+// FastForward advances the manual clock of the redis and immediately
+// deletes the expired keys, times out the blocked commands and closes
+// the idle connections of MIGRATE.
+func (r *Redis) FastForward(d time.Duration) error {
+	clock, ok := r.clock.(*ManualClock)
+
+	if !ok {
+		return errors.New("fast forward requires a manual clock")
+	}
+
+	clock.Advance(d)
+
+	r.deleteExpiredKeys()
+	r.closeIdleMigrateSockets(false)
+	return nil
+}
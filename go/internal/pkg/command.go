@@ -1,12 +1,76 @@
 package pkg
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Command flags. Please check the command table defined in the redis.c file
 // for more information about the meaning of every flag.
 const (
-	CMD_WRITE    uint64 = 1 << 0
-	CMD_READONLY        = 1 << 1
+	CMD_WRITE uint64 = 1 << iota
+	CMD_READONLY
+	CMD_DENYOOM
+	CMD_MODULE
+	CMD_ADMIN
+	CMD_PUBSUB
+	CMD_NOSCRIPT
+	CMD_BLOCKING
+	CMD_LOADING
+	CMD_STALE
+	CMD_SKIP_MONITOR
+	CMD_SKIP_SLOWLOG
+	CMD_ASKING
+	CMD_FAST
+	CMD_NO_AUTH
+	CMD_MAY_REPLICATE
+	CMD_NO_MANDATORY_KEYS
+	CMD_NO_MULTI
+	CMD_ALLOW_BUSY
+)
+
+// Names of the command flags as reported by COMMAND INFO.
+var commandFlags = []struct {
+	name string
+	flag uint64
+}{
+	{"write", CMD_WRITE},
+	{"readonly", CMD_READONLY},
+	{"denyoom", CMD_DENYOOM},
+	{"module", CMD_MODULE},
+	{"admin", CMD_ADMIN},
+	{"pubsub", CMD_PUBSUB},
+	{"noscript", CMD_NOSCRIPT},
+	{"blocking", CMD_BLOCKING},
+	{"loading", CMD_LOADING},
+	{"stale", CMD_STALE},
+	{"skip_monitor", CMD_SKIP_MONITOR},
+	{"skip_slowlog", CMD_SKIP_SLOWLOG},
+	{"asking", CMD_ASKING},
+	{"fast", CMD_FAST},
+	{"no_auth", CMD_NO_AUTH},
+	{"may_replicate", CMD_MAY_REPLICATE},
+	{"no_mandatory_keys", CMD_NO_MANDATORY_KEYS},
+	{"no_multi", CMD_NO_MULTI},
+	{"allow_busy", CMD_ALLOW_BUSY},
+}
+
+// CommandFlag returns the command flag with the given name.
+func CommandFlag(name string) (uint64, bool) {
+	for _, f := range commandFlags {
+		if f.name == name {
+			return f.flag, true
+		}
+	}
+
+	return 0, false
+}
+
+// Key flags. Describes how a command accesses the keys of a key spec.
+const (
+	KEY_READ  uint64 = 1 << 0
+	KEY_WRITE        = 1 << 1
 )
 
 const (
@@ -17,31 +81,302 @@ const (
 type CommandHandler func(c *Client, cmd [][]byte)
 type BlockingCommandHandler func(c *Client, cmd [][]byte) *BlockedCommand
 
+// KeySpec describes where the keys of a command are located in its arguments.
+type KeySpec struct {
+	Flags   uint64 // How the keys are accessed
+	First   int    // Index of the first key
+	Last    int    // Index of the last key, negative values counts from the end
+	Step    int    // Distance between keys
+	NumKeys int    // Index of the argument holding the number of keys, 0 if there is none
+	Keyword string // The keys follow this argument, searched from First, if not empty
+}
+
+// NewKeySpec creates a key spec for keys within a fixed range of arguments.
+func NewKeySpec(flags uint64, first int, last int, step int) KeySpec {
+	return KeySpec{
+		Flags: flags,
+		First: first,
+		Last:  last,
+		Step:  step,
+	}
+}
+
+// NewKeyNumSpec creates a key spec for keys whose count is given by an argument.
+// E.g. ZUNION numkeys key [key ...].
+func NewKeyNumSpec(flags uint64, numKeys int, first int, step int) KeySpec {
+	return KeySpec{
+		Flags:   flags,
+		First:   first,
+		Step:    step,
+		NumKeys: numKeys,
+	}
+}
+
+// NewKeyKeywordSpec creates a key spec for the keys following a keyword,
+// which is searched from the argument at startFrom.
+// E.g. MIGRATE ... [KEYS key [key ...]].
+func NewKeyKeywordSpec(flags uint64, keyword string, startFrom int) KeySpec {
+	return KeySpec{
+		Flags:   flags,
+		First:   startFrom,
+		Last:    -1,
+		Step:    1,
+		Keyword: keyword,
+	}
+}
+
+// Indexes returns the indexes of the keys in the arguments.
+// Indexes that would be out of range are silently skipped.
+func (ks KeySpec) Indexes(args [][]byte) []int {
+	first, last := ks.First, ks.Last
+
+	if ks.Keyword != "" {
+		first = 0
+
+		for i := ks.First; i > 0 && i < len(args); i++ {
+			if strings.EqualFold(string(args[i]), ks.Keyword) {
+				first = i + 1
+				break
+			}
+		}
+
+		if first == 0 {
+			return nil
+		}
+	}
+
+	if ks.NumKeys > 0 {
+		if ks.NumKeys >= len(args) {
+			return nil
+		}
+
+		numKeys, err := strconv.Atoi(string(args[ks.NumKeys]))
+
+		if err != nil || numKeys <= 0 {
+			return nil
+		}
+
+		last = ks.First + (numKeys-1)*ks.Step
+	} else if last < 0 {
+		last = len(args) + last
+	}
+
+	if last >= len(args) {
+		last = len(args) - 1
+	}
+
+	step := ks.Step
+
+	if step <= 0 {
+		step = 1
+	}
+
+	res := make([]int, 0)
+
+	for i := first; i > 0 && i <= last; i += step {
+		res = append(res, i)
+	}
+
+	return res
+}
+
+// CommandInfo holds the metadata that are shared between normal and blocking commands.
+type CommandInfo struct {
+	Name       string
+	Arity      int // Number of arguments, a negative arity is the minimum number of arguments
+	Flag       uint64
+	Categories uint64    // ACL categories
+	Keys       []KeySpec // Where the keys are located
+	Module     string    // Name of the module that registered the command, if any
+}
+
+// CheckArity returns whether the number of arguments is allowed by the arity.
+func (ci *CommandInfo) CheckArity(args [][]byte) bool {
+	if ci.Arity > 0 {
+		return len(args) == ci.Arity
+	}
+
+	return len(args) >= -ci.Arity
+}
+
+// Flags returns the names of the command flags.
+func (ci *CommandInfo) Flags() []string {
+	res := make([]string, 0)
+
+	for _, f := range commandFlags {
+		if ci.Flag&f.flag != 0 {
+			res = append(res, f.name)
+		}
+	}
+
+	if ci.MovableKeys() {
+		res = append(res, "movablekeys")
+	}
+
+	return res
+}
+
+// AclCategories returns the ACL categories of the command including
+// the ones that are implied by the command flags.
+func (ci *CommandInfo) AclCategories() uint64 {
+	cats := ci.Categories
+
+	if ci.Flag&CMD_WRITE != 0 {
+		cats |= ACL_CATEGORY_WRITE
+	}
+
+	if ci.Flag&CMD_READONLY != 0 && cats&ACL_CATEGORY_SCRIPTING == 0 {
+		cats |= ACL_CATEGORY_READ
+	}
+
+	if ci.Flag&CMD_ADMIN != 0 {
+		cats |= ACL_CATEGORY_ADMIN | ACL_CATEGORY_DANGEROUS
+	}
+
+	if ci.Flag&CMD_PUBSUB != 0 {
+		cats |= ACL_CATEGORY_PUBSUB
+	}
+
+	if ci.Flag&CMD_FAST != 0 {
+		cats |= ACL_CATEGORY_FAST
+	}
+
+	if ci.Flag&CMD_BLOCKING != 0 {
+		cats |= ACL_CATEGORY_BLOCKING
+	}
+
+	if cats&ACL_CATEGORY_FAST == 0 {
+		cats |= ACL_CATEGORY_SLOW
+	}
+
+	return cats
+}
+
+// Group returns the group of the command as reported by COMMAND DOCS.
+func (ci *CommandInfo) Group() string {
+	if ci.Module != "" {
+		return "module"
+	}
+
+	for _, g := range commandGroups {
+		if ci.Categories&g.category != 0 {
+			return g.name
+		}
+	}
+
+	return "server"
+}
+
+// Groups of the commands by their ACL category, the first match wins.
+var commandGroups = []struct {
+	name     string
+	category uint64
+}{
+	{"string", ACL_CATEGORY_STRING},
+	{"list", ACL_CATEGORY_LIST},
+	{"set", ACL_CATEGORY_SET},
+	{"sorted-set", ACL_CATEGORY_SORTEDSET},
+	{"hash", ACL_CATEGORY_HASH},
+	{"bitmap", ACL_CATEGORY_BITMAP},
+	{"hyperloglog", ACL_CATEGORY_HYPERLOGLOG},
+	{"geo", ACL_CATEGORY_GEO},
+	{"stream", ACL_CATEGORY_STREAM},
+	{"pubsub", ACL_CATEGORY_PUBSUB},
+	{"scripting", ACL_CATEGORY_SCRIPTING},
+	{"transactions", ACL_CATEGORY_TRANSACTION},
+	{"connection", ACL_CATEGORY_CONNECTION},
+	{"generic", ACL_CATEGORY_KEYSPACE},
+}
+
+// MovableKeys returns whether the keys cannot be found with the legacy key range.
+func (ci *CommandInfo) MovableKeys() bool {
+	for _, ks := range ci.Keys {
+		if ks.NumKeys > 0 || ks.Keyword != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LegacyKeyRange returns the first key, the last key and the step
+// of the key specs with a fixed range, as COMMAND INFO reports them.
+func (ci *CommandInfo) LegacyKeyRange() (int, int, int) {
+	first, last, step := 0, 0, 0
+
+	for _, ks := range ci.Keys {
+		if ks.NumKeys > 0 || ks.Keyword != "" {
+			continue
+		}
+
+		ksStep := ks.Step
+
+		if ksStep <= 0 {
+			ksStep = 1
+		}
+
+		if first == 0 {
+			first, last, step = ks.First, ks.Last, ksStep
+			continue
+		}
+
+		// Only the specs that continue the range can be merged into it
+		if ksStep == step && last >= 0 && ks.First == last+step {
+			last = ks.Last
+		}
+	}
+
+	return first, last, step
+}
+
+// KeyIndexes returns the indexes of the keys in args together with how they are accessed.
+func (ci *CommandInfo) KeyIndexes(args [][]byte) ([]int, []uint64) {
+	indexes := make([]int, 0)
+	flags := make([]uint64, 0)
+
+	for _, ks := range ci.Keys {
+		for _, idx := range ks.Indexes(args) {
+			indexes = append(indexes, idx)
+			flags = append(flags, ks.Flags)
+		}
+	}
+
+	return indexes, flags
+}
+
 type Command struct {
-	Name    string
+	CommandInfo
 	Handler CommandHandler
-	Flag    uint64
 }
 
-func NewCommand(name string, handler CommandHandler, flag uint64) *Command {
+func NewCommand(name string, handler CommandHandler, arity int, flag uint64, categories uint64, keys ...KeySpec) *Command {
 	return &Command{
-		Name:    name,
+		CommandInfo: CommandInfo{
+			Name:       name,
+			Arity:      arity,
+			Flag:       flag,
+			Categories: categories,
+			Keys:       keys,
+		},
 		Handler: handler,
-		Flag:    flag,
 	}
 }
 
 type BlockingCommand struct {
-	Name    string
+	CommandInfo
 	Handler BlockingCommandHandler
-	Flag    uint64
 }
 
-func NewBlockingCommand(name string, handler BlockingCommandHandler, flag uint64) *BlockingCommand {
+func NewBlockingCommand(name string, handler BlockingCommandHandler, arity int, flag uint64, categories uint64, keys ...KeySpec) *BlockingCommand {
 	return &BlockingCommand{
-		Name:    name,
+		CommandInfo: CommandInfo{
+			Name:       name,
+			Arity:      arity,
+			Flag:       flag,
+			Categories: categories,
+			Keys:       keys,
+		},
 		Handler: handler,
-		Flag:    flag,
 	}
 }
 
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package pkg
+
+// The CPU usage is only available on unix systems.
+func infoCpu() [][2]string {
+	return [][2]string{
+		{"used_cpu_sys", "0.000000"},
+		{"used_cpu_user", "0.000000"},
+		{"used_cpu_sys_children", "0.000000"},
+		{"used_cpu_user_children", "0.000000"},
+	}
+}
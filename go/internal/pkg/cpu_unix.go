@@ -0,0 +1,25 @@
+//go:build unix
+
+package pkg
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func infoCpu() [][2]string {
+	var self, children syscall.Rusage
+	syscall.Getrusage(syscall.RUSAGE_SELF, &self)
+	syscall.Getrusage(syscall.RUSAGE_CHILDREN, &children)
+
+	return [][2]string{
+		{"used_cpu_sys", formatTimeval(self.Stime)},
+		{"used_cpu_user", formatTimeval(self.Utime)},
+		{"used_cpu_sys_children", formatTimeval(children.Stime)},
+		{"used_cpu_user_children", formatTimeval(children.Utime)},
+	}
+}
+
+func formatTimeval(tv syscall.Timeval) string {
+	return fmt.Sprintf("%d.%06d", tv.Sec, tv.Usec)
+}
@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hbina/radish/internal/types"
@@ -22,15 +23,30 @@ type Db struct {
 	Storage map[string]types.Item
 	Ttl     map[string]time.Time
 	mu      *sync.RWMutex // Lock to the database
+	redis   *Redis
+	// Whether the lookups are counted as keyspace hits and misses,
+	// it is only enabled while a read-only command is running.
+	countLookups bool
+	// Client running a command on the database, nil for the background jobs
+	caller *Client
+	meta   map[string]*KeyMeta // Access and memory of the keys
+	// The counters below are read by INFO and MEMORY STATS without the lock,
+	// which can not be taken while the caller holds the lock of its database.
+	used      atomic.Int64 // Bytes used by the keys
+	keys      atomic.Int64 // Number of keys
+	expires   atomic.Int64 // Number of keys with an expiry
+	expirySum atomic.Int64 // Sum of the expiry times in milliseconds
 }
 
 // NewRedisDb creates a new db.
-func NewRedisDb(id uint64) *Db {
+func NewRedisDb(id uint64, r *Redis) *Db {
 	return &Db{
 		id:      id,
 		Storage: make(map[string]types.Item, 0),
 		Ttl:     make(map[string]time.Time, 0),
 		mu:      new(sync.RWMutex),
+		redis:   r,
+		meta:    make(map[string]*KeyMeta, 0),
 	}
 }
 
@@ -50,36 +66,62 @@ func (db *Db) Set(key string, i types.Item, ttl time.Time) types.Item {
 	// operations on non-existent key is equivalent to zeroth of that
 	// object type.
 	// TODO: Should this be behavior of set or the specific commands?
+	empty := false
+
 	if i.Type() == types.ValueTypeString {
 		// Except or strings?
 	} else if i.Type() == types.ValueTypeList {
 		list := i.(*types.List)
-
-		if list.Len() == 0 {
-			db.Delete(key)
-			return nil
-		}
+		empty = list.Len() == 0
 	} else if i.Type() == types.ValueTypeSet {
 		set := i.(*types.Set)
-
-		if set.Len() == 0 {
-			db.Delete(key)
-			return nil
-		}
+		empty = set.Len() == 0
 	} else if i.Type() == types.ValueTypeZSet {
 		str := i.(*types.ZSet)
+		empty = str.Len() == 0
+	}
+
+	if empty {
+		// The command that emptied the item is notified before the deletion,
+		// which is always a del event whatever the command is
+		if _, exists := db.Storage[key]; exists {
+			db.notify(itemNotifyClass(i), commandEvent(db.caller, "set"), key)
+		}
 
-		if str.Len() == 0 {
-			db.Delete(key)
-			return nil
+		if db.delete(key, db.caller) {
+			db.notify(NOTIFY_GENERIC, "del", key)
 		}
+
+		return nil
 	}
 
 	old, exists := db.Storage[key]
+	oldTtl := db.Ttl[key]
+
+	// A value of another type is a new object
+	if exists && old.Type() != i.Type() {
+		db.forget(key)
+	}
 
 	// Insert new value to a key will overwrite everything about it
+	if !exists {
+		db.keys.Add(1)
+	}
+
 	db.Storage[key] = i
-	db.Ttl[key] = ttl
+	db.setTtl(key, ttl)
+	db.account(key, i)
+	db.signalModifiedKey(key)
+
+	if !exists {
+		db.notify(NOTIFY_NEW, "new", key)
+	}
+
+	db.notify(itemNotifyClass(i), commandEvent(db.caller, "set"), key)
+
+	if !ttl.IsZero() && !ttl.Equal(oldTtl) {
+		db.notify(NOTIFY_GENERIC, "expire", key)
+	}
 
 	if exists {
 		return old
@@ -97,7 +139,22 @@ func (db *Db) GetExpiry(key string) (time.Time, bool) {
 // SetExpiry sets the expiry of a key
 func (db *Db) SetExpiry(key string, ttl time.Time) (time.Time, bool) {
 	old, exists := db.Ttl[key]
-	db.Ttl[key] = ttl
+
+	// A time in the past deletes the key right away, as in Redis
+	if !ttl.IsZero() && !ttl.After(db.redis.Now()) {
+		db.Delete(key)
+		return old, exists
+	}
+
+	db.setTtl(key, ttl)
+	db.signalModifiedKey(key)
+
+	if ttl.IsZero() {
+		db.notify(NOTIFY_GENERIC, "persist", key)
+	} else {
+		db.notify(NOTIFY_GENERIC, "expire", key)
+	}
+
 	return old, exists
 }
 
@@ -105,12 +162,8 @@ func (db *Db) SetExpiry(key string, ttl time.Time) (time.Time, bool) {
 func (db *Db) Delete(keys ...string) int {
 	var c int
 	for _, k := range keys {
-		_, itemExists := db.Storage[k]
-		_, ttlExists := db.Ttl[k]
-		delete(db.Storage, k)
-		delete(db.Ttl, k)
-
-		if itemExists && ttlExists {
+		if db.delete(k, db.caller) {
+			db.notify(NOTIFY_GENERIC, "del", k)
 			c++
 		}
 	}
@@ -118,10 +171,59 @@ func (db *Db) Delete(keys ...string) int {
 	return c
 }
 
+// delete deletes a key on behalf of the caller, which is nil when the key has expired.
+func (db *Db) delete(key string, caller *Client) bool {
+	_, itemExists := db.Storage[key]
+	_, ttlExists := db.Ttl[key]
+	delete(db.Storage, key)
+	db.unsetTtl(key)
+	db.forget(key)
+
+	if itemExists {
+		db.keys.Add(-1)
+		db.redis.trackingInvalidateKey(caller, key)
+	}
+
+	return itemExists && ttlExists
+}
+
+// setTtl sets the expiry of a key, a zero ttl meaning that it has none.
+func (db *Db) setTtl(key string, ttl time.Time) {
+	db.unsetTtl(key)
+	db.Ttl[key] = ttl
+
+	if !ttl.IsZero() {
+		db.expires.Add(1)
+		db.expirySum.Add(ttl.UnixMilli())
+	}
+}
+
+// unsetTtl removes the expiry of a key.
+func (db *Db) unsetTtl(key string) {
+	if ttl, exists := db.Ttl[key]; exists && !ttl.IsZero() {
+		db.expires.Add(-1)
+		db.expirySum.Add(-ttl.UnixMilli())
+	}
+
+	delete(db.Ttl, key)
+}
+
+// signalModifiedKey is called whenever a key is modified by a command.
+func (db *Db) signalModifiedKey(key string) {
+	db.redis.trackingInvalidateKey(db.caller, key)
+}
+
+// notify publishes a keyspace event about a key of the database.
+func (db *Db) notify(class uint64, event string, key string) {
+	db.redis.NotifyKeyspaceEvent(class, event, key, db.id)
+}
+
 func (db *Db) DeleteExpired(keys ...string) int {
 	var c int
 	for _, k := range keys {
-		if db.Expired(k) && db.Delete(k) > 0 {
+		if db.Expired(k) && db.delete(k, nil) {
+			db.notify(NOTIFY_EXPIRED, "expired", k)
+			db.redis.stats.expiredKeys.Add(1)
 			c++
 		}
 	}
@@ -132,16 +234,37 @@ func (db *Db) DeleteExpired(keys ...string) int {
 // TODO: Should this return the exists bool or its enough to return nil?
 func (db *Db) Get(key string) (types.Item, time.Time) {
 	value, exists := db.Storage[key]
-	if !exists {
+	if !exists || db.DeleteExpired(key) > 0 {
+		db.countLookup(false)
+
+		// Only the lookups of the read-only commands are misses
+		if db.countLookups {
+			db.notify(NOTIFY_KEY_MISS, "keymiss", key)
+		}
+
 		return nil, time.Time{}
 	}
-	if db.Expired(key) {
-		db.Delete(key)
-		return nil, time.Time{}
+	db.countLookup(true)
+
+	if meta, ok := db.meta[key]; ok {
+		db.touch(meta)
 	}
+
 	return value, db.Ttl[key]
 }
 
+func (db *Db) countLookup(hit bool) {
+	if !db.countLookups {
+		return
+	}
+
+	if hit {
+		db.redis.stats.keyspaceHits.Add(1)
+	} else {
+		db.redis.stats.keyspaceMisses.Add(1)
+	}
+}
+
 // IsEmpty checks if db is empty.
 func (db *Db) IsEmpty() bool {
 	return len(db.Storage) == 0
@@ -168,7 +291,7 @@ func (db *Db) Expired(key string) bool {
 	if !exists || time.Time.IsZero(ttl) {
 		return false
 	}
-	return time.Now().After(ttl)
+	return db.redis.Now().After(ttl)
 }
 
 // Expiry gets the expiry of the key has one.
@@ -191,6 +314,12 @@ func (db *Db) Clear() {
 		delete(db.Storage, k)
 		delete(db.Ttl, k)
 	}
+
+	db.meta = make(map[string]*KeyMeta, 0)
+	db.redis.eviction.used.Add(-db.used.Swap(0))
+	db.keys.Store(0)
+	db.expires.Store(0)
+	db.expirySum.Store(0)
 }
 
 // Number of keys in the storage
@@ -0,0 +1,530 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hbina/radish/internal/types"
+	"github.com/hbina/radish/internal/util"
+)
+
+const (
+	// Version of the RDB format of the payloads of DUMP.
+	RDB_VERSION = 10
+	// Latest version of the RDB format whose payloads can be restored.
+	RDB_LOAD_VERSION = 11
+)
+
+// Types of the objects in the RDB format.
+const (
+	RDB_TYPE_STRING           = 0
+	RDB_TYPE_LIST             = 1
+	RDB_TYPE_SET              = 2
+	RDB_TYPE_ZSET             = 3
+	RDB_TYPE_ZSET_2           = 5
+	RDB_TYPE_MODULE_2         = 7
+	RDB_TYPE_LIST_ZIPLIST     = 10
+	RDB_TYPE_SET_INTSET       = 11
+	RDB_TYPE_ZSET_ZIPLIST     = 12
+	RDB_TYPE_LIST_QUICKLIST   = 14
+	RDB_TYPE_ZSET_LISTPACK    = 17
+	RDB_TYPE_LIST_QUICKLIST_2 = 18
+	RDB_TYPE_SET_LISTPACK     = 20
+)
+
+// Opcodes of the values saved by the modules.
+const (
+	RDB_MODULE_OPCODE_EOF    = 0
+	RDB_MODULE_OPCODE_STRING = 5
+)
+
+// Containers of the nodes of RDB_TYPE_LIST_QUICKLIST_2.
+const (
+	QUICKLIST_NODE_CONTAINER_PLAIN  = 1
+	QUICKLIST_NODE_CONTAINER_PACKED = 2
+)
+
+// Characters of the names of the module types, which are encoded in 6 bits.
+const moduleTypeCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+var (
+	ErrDumpPayload = errors.New("DUMP payload version or checksum are wrong")
+	ErrBadData     = errors.New("Bad data format")
+)
+
+// dumpPayloadJson returns whether DUMP serializes the items as JSON, which is
+// easier to read while debugging but cannot be restored by Redis.
+func (r *Redis) dumpPayloadJson() bool {
+	return strings.EqualFold(r.configOrDefault("dump-payload-format", "rdb"), "json")
+}
+
+// DumpPayload serializes the item as DUMP does: its RDB type and value
+// followed by the RDB version and the CRC64 of the payload.
+func (r *Redis) DumpPayload(key string, i types.Item) ([]byte, error) {
+	if r.dumpPayloadJson() {
+		return r.dumpJson(key, i)
+	}
+
+	w := &rdbWriter{}
+
+	switch item := i.(type) {
+	case *types.String:
+		w.writeByte(RDB_TYPE_STRING)
+		w.writeString(item.AsString())
+	case *types.List:
+		w.writeByte(RDB_TYPE_LIST)
+		w.writeLen(uint64(item.Len()))
+		item.ForEachF(func(a string) bool {
+			w.writeString(a)
+			return true
+		})
+	case *types.Set:
+		members := make([]string, 0, item.Len())
+		item.ForEachF(func(a string) bool {
+			members = append(members, a)
+			return true
+		})
+
+		// The payload must not depend on the iteration order of the map
+		sort.Strings(members)
+
+		w.writeByte(RDB_TYPE_SET)
+		w.writeLen(uint64(len(members)))
+
+		for _, member := range members {
+			w.writeString(member)
+		}
+	case *types.ZSet:
+		zset := item.Value().(*types.SortedSet)
+		options := types.DefaultRangeOptions()
+		options.Reverse = true
+
+		// From the greatest to the smallest score, as Redis does
+		w.writeByte(RDB_TYPE_ZSET_2)
+		w.writeLen(uint64(zset.Len()))
+
+		for _, node := range zset.GetRangeByRank(1, zset.Len(), options) {
+			w.writeString(node.Key)
+			w.writeBinaryDouble(node.Score)
+		}
+	default:
+		ct, exists := r.CustomType(i.TypeFancy())
+
+		if !exists || ct.Marshal == nil {
+			return nil, fmt.Errorf("Dump for %s is not yet implemented", i.TypeFancy())
+		}
+
+		data, err := ct.Marshal(i)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// The name of the type is saved along the data because the id
+		// cannot hold the names that do not have 9 characters
+		w.writeByte(RDB_TYPE_MODULE_2)
+		w.writeLen(moduleTypeId(ct.Name, 0))
+		w.writeLen(RDB_MODULE_OPCODE_STRING)
+		w.writeString(ct.Name)
+		w.writeLen(RDB_MODULE_OPCODE_STRING)
+		w.writeString(string(data))
+		w.writeLen(RDB_MODULE_OPCODE_EOF)
+	}
+
+	w.buf = binary.LittleEndian.AppendUint16(w.buf, RDB_VERSION)
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, util.Crc64(0, w.buf))
+
+	return w.buf, nil
+}
+
+// moduleTypeId encodes the name of a module type and the version of its
+// encoding as Redis does. Names are padded or truncated to 9 characters.
+func moduleTypeId(name string, encver uint64) uint64 {
+	var id uint64
+
+	for j := 0; j < 9; j++ {
+		idx := strings.IndexByte(moduleTypeCharset, '_')
+
+		if j < len(name) && strings.IndexByte(moduleTypeCharset, name[j]) >= 0 {
+			idx = strings.IndexByte(moduleTypeCharset, name[j])
+		}
+
+		id = id<<6 | uint64(idx)
+	}
+
+	return id<<10 | encver&1023
+}
+
+// VerifyDumpPayload checks the RDB version and the CRC64 of a payload.
+func VerifyDumpPayload(payload []byte) bool {
+	if len(payload) < 10 {
+		return false
+	}
+
+	footer := payload[len(payload)-10:]
+	version := binary.LittleEndian.Uint16(footer)
+
+	if version > RDB_LOAD_VERSION {
+		return false
+	}
+
+	crc := binary.LittleEndian.Uint64(footer[2:])
+	return crc == util.Crc64(0, payload[:len(payload)-8])
+}
+
+// RestorePayload deserializes an item serialized by DUMP. It returns
+// ErrDumpPayload if the payload is corrupted and ErrBadData if the value
+// cannot be deserialized.
+func (r *Redis) RestorePayload(payload []byte) (types.Item, error) {
+	// The payloads serialized as JSON start with a brace, which is not an RDB type
+	if len(payload) > 0 && payload[0] == '{' {
+		return r.restoreJson(payload)
+	}
+
+	if !VerifyDumpPayload(payload) {
+		return nil, ErrDumpPayload
+	}
+
+	reader := &rdbReader{buf: payload[:len(payload)-10]}
+	item, err := r.readObject(reader)
+
+	if err != nil || reader.pos != len(reader.buf) {
+		return nil, ErrBadData
+	}
+
+	return item, nil
+}
+
+// readObject deserializes an object and its type.
+func (r *Redis) readObject(reader *rdbReader) (types.Item, error) {
+	typ, err := reader.readByte()
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case RDB_TYPE_STRING:
+		s, err := reader.readString()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewString(s), nil
+	case RDB_TYPE_LIST, RDB_TYPE_SET:
+		n, err := reader.readCount()
+
+		if err != nil {
+			return nil, err
+		}
+
+		elements := make([]string, 0, n)
+
+		for j := uint64(0); j < n; j++ {
+			s, err := reader.readString()
+
+			if err != nil {
+				return nil, err
+			}
+
+			elements = append(elements, s)
+		}
+
+		if typ == RDB_TYPE_LIST {
+			return newListFromEntries(elements)
+		}
+
+		return newSetFromEntries(elements)
+	case RDB_TYPE_ZSET, RDB_TYPE_ZSET_2:
+		n, err := reader.readCount()
+
+		if err != nil {
+			return nil, err
+		}
+
+		zset := types.NewZSet()
+
+		for j := uint64(0); j < n; j++ {
+			member, err := reader.readString()
+
+			if err != nil {
+				return nil, err
+			}
+
+			var score float64
+
+			if typ == RDB_TYPE_ZSET {
+				score, err = reader.readDouble()
+			} else {
+				score, err = reader.readBinaryDouble()
+			}
+
+			if err != nil || math.IsNaN(score) {
+				return nil, errRdbBadData
+			}
+
+			// Duplicated members are a corruption
+			if !zset.AddOrUpdate(member, score) {
+				return nil, errRdbBadData
+			}
+		}
+
+		if zset.Len() == 0 {
+			return nil, errRdbBadData
+		}
+
+		return zset, nil
+	case RDB_TYPE_LIST_ZIPLIST, RDB_TYPE_SET_INTSET, RDB_TYPE_SET_LISTPACK, RDB_TYPE_ZSET_ZIPLIST, RDB_TYPE_ZSET_LISTPACK:
+		blob, err := reader.readString()
+
+		if err != nil {
+			return nil, err
+		}
+
+		var entries []string
+
+		switch typ {
+		case RDB_TYPE_LIST_ZIPLIST, RDB_TYPE_ZSET_ZIPLIST:
+			entries, err = ziplistEntries([]byte(blob))
+		case RDB_TYPE_SET_INTSET:
+			entries, err = intsetEntries([]byte(blob))
+		default:
+			entries, err = listpackEntries([]byte(blob))
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch typ {
+		case RDB_TYPE_LIST_ZIPLIST:
+			return newListFromEntries(entries)
+		case RDB_TYPE_SET_INTSET, RDB_TYPE_SET_LISTPACK:
+			return newSetFromEntries(entries)
+		}
+
+		return newZSetFromEntries(entries)
+	case RDB_TYPE_LIST_QUICKLIST, RDB_TYPE_LIST_QUICKLIST_2:
+		n, err := reader.readCount()
+
+		if err != nil {
+			return nil, err
+		}
+
+		elements := make([]string, 0)
+
+		for j := uint64(0); j < n; j++ {
+			container := uint64(QUICKLIST_NODE_CONTAINER_PACKED)
+
+			if typ == RDB_TYPE_LIST_QUICKLIST_2 {
+				var encoded bool
+
+				if container, encoded, err = reader.readLen(); err != nil || encoded {
+					return nil, errRdbBadData
+				}
+			}
+
+			blob, err := reader.readString()
+
+			if err != nil {
+				return nil, err
+			}
+
+			var entries []string
+
+			switch {
+			case container == QUICKLIST_NODE_CONTAINER_PLAIN:
+				entries = []string{blob}
+			case container != QUICKLIST_NODE_CONTAINER_PACKED:
+				return nil, errRdbBadData
+			case typ == RDB_TYPE_LIST_QUICKLIST:
+				entries, err = ziplistEntries([]byte(blob))
+			default:
+				entries, err = listpackEntries([]byte(blob))
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			elements = append(elements, entries...)
+		}
+
+		return newListFromEntries(elements)
+	case RDB_TYPE_MODULE_2:
+		return r.readModuleObject(reader)
+	}
+
+	return nil, errRdbBadData
+}
+
+// readModuleObject deserializes a value of a module type saved by DUMP.
+func (r *Redis) readModuleObject(reader *rdbReader) (types.Item, error) {
+	// The id is not needed because the name of the type follows it
+	if _, encoded, err := reader.readLen(); err != nil || encoded {
+		return nil, errRdbBadData
+	}
+
+	fields := make([]string, 0, 2)
+
+	for {
+		opcode, encoded, err := reader.readLen()
+
+		if err != nil || encoded {
+			return nil, errRdbBadData
+		}
+
+		if opcode == RDB_MODULE_OPCODE_EOF {
+			break
+		}
+
+		if opcode != RDB_MODULE_OPCODE_STRING || len(fields) == 2 {
+			return nil, errRdbBadData
+		}
+
+		s, err := reader.readString()
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, s)
+	}
+
+	if len(fields) != 2 {
+		return nil, errRdbBadData
+	}
+
+	ct, exists := r.CustomType(fields[0])
+
+	if !exists || ct.Unmarshal == nil {
+		return nil, errRdbBadData
+	}
+
+	return ct.Unmarshal([]byte(fields[1]))
+}
+
+func newListFromEntries(entries []string) (types.Item, error) {
+	if len(entries) == 0 {
+		return nil, errRdbBadData
+	}
+
+	list := types.NewList()
+	list.RPush(entries...)
+
+	return list, nil
+}
+
+func newSetFromEntries(entries []string) (types.Item, error) {
+	members := make(map[string]struct{}, len(entries))
+
+	for _, e := range entries {
+		if _, exists := members[e]; exists {
+			return nil, errRdbBadData
+		}
+
+		members[e] = struct{}{}
+	}
+
+	if len(members) == 0 {
+		return nil, errRdbBadData
+	}
+
+	return types.NewSetFromMap(members), nil
+}
+
+// newZSetFromEntries returns the sorted set of the alternating members and
+// scores of a ziplist or a listpack.
+func newZSetFromEntries(entries []string) (types.Item, error) {
+	if len(entries) == 0 || len(entries)%2 != 0 {
+		return nil, errRdbBadData
+	}
+
+	zset := types.NewZSet()
+
+	for j := 0; j < len(entries); j += 2 {
+		score, err := strconv.ParseFloat(entries[j+1], 64)
+
+		if err != nil || math.IsNaN(score) || !zset.AddOrUpdate(entries[j], score) {
+			return nil, errRdbBadData
+		}
+	}
+
+	return zset, nil
+}
+
+// dumpJson serializes the item as a JSON Kvp.
+func (r *Redis) dumpJson(key string, i types.Item) ([]byte, error) {
+	var data []byte
+	var err error
+
+	switch item := i.(type) {
+	case *types.String:
+		data, err = item.Marshal()
+	case *types.List:
+		data, err = item.Marshal()
+	case *types.Set:
+		data, err = item.Marshal()
+	case *types.ZSet:
+		data, err = item.Marshal()
+	default:
+		ct, exists := r.CustomType(i.TypeFancy())
+
+		if !exists || ct.Marshal == nil {
+			return nil, fmt.Errorf("Dump for %s is not yet implemented", i.TypeFancy())
+		}
+
+		data, err = ct.Marshal(i)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(Kvp{
+		Key:  key,
+		Type: i.TypeFancy(),
+		Data: data,
+	})
+}
+
+// restoreJson deserializes an item serialized as a JSON Kvp.
+func (r *Redis) restoreJson(payload []byte) (types.Item, error) {
+	var kvp Kvp
+
+	if err := json.Unmarshal(payload, &kvp); err != nil {
+		return nil, ErrBadData
+	}
+
+	var item types.Item
+	ok := false
+
+	switch kvp.Type {
+	case types.ValueTypeFancyString:
+		item, ok = types.StringUnmarshal(kvp.Data)
+	case types.ValueTypeFancyList:
+		item, ok = types.ListUnmarshal(kvp.Data)
+	case types.ValueTypeFancySet:
+		item, ok = types.SetUnmarshal(kvp.Data)
+	case types.ValueTypeFancyZSet:
+		item, ok = types.ZSetUnmarshal(kvp.Data)
+	default:
+		if ct, exists := r.CustomType(kvp.Type); exists && ct.Unmarshal != nil {
+			i, err := ct.Unmarshal(kvp.Data)
+			item, ok = i, err == nil
+		}
+	}
+
+	if !ok {
+		return nil, ErrBadData
+	}
+
+	return item, nil
+}
@@ -0,0 +1,377 @@
+package pkg
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hbina/radish/internal/types"
+	"github.com/hbina/radish/internal/util"
+)
+
+// Policies of maxmemory-policy.
+const (
+	MAXMEMORY_NO_EVICTION = iota
+	MAXMEMORY_ALLKEYS_LRU
+	MAXMEMORY_ALLKEYS_LFU
+	MAXMEMORY_ALLKEYS_RANDOM
+	MAXMEMORY_VOLATILE_LRU
+	MAXMEMORY_VOLATILE_LFU
+	MAXMEMORY_VOLATILE_RANDOM
+	MAXMEMORY_VOLATILE_TTL
+)
+
+var maxmemoryPolicies = map[string]int32{
+	"noeviction":      MAXMEMORY_NO_EVICTION,
+	"allkeys-lru":     MAXMEMORY_ALLKEYS_LRU,
+	"allkeys-lfu":     MAXMEMORY_ALLKEYS_LFU,
+	"allkeys-random":  MAXMEMORY_ALLKEYS_RANDOM,
+	"volatile-lru":    MAXMEMORY_VOLATILE_LRU,
+	"volatile-lfu":    MAXMEMORY_VOLATILE_LFU,
+	"volatile-random": MAXMEMORY_VOLATILE_RANDOM,
+	"volatile-ttl":    MAXMEMORY_VOLATILE_TTL,
+}
+
+const (
+	// Initial value of the LFU counter of a new key, so that it is not evicted right away.
+	LFU_INIT_VAL = 5
+	// Approximate bytes used by a key besides its value: its entries in the
+	// storage, the ttls and the metadata.
+	keyOverhead = 96
+	// Number of elements sampled to estimate the size of an aggregate item.
+	memorySamples = 5
+)
+
+// Eviction holds the configuration of maxmemory and the memory used by the keys.
+type Eviction struct {
+	maxmemory    atomic.Uint64
+	policy       atomic.Int32
+	samples      atomic.Int32
+	lfuLogFactor atomic.Int64
+	lfuDecayTime atomic.Int64 // In minutes
+	used         atomic.Int64 // Bytes used by the keys of all the databases
+}
+
+func newEviction() *Eviction {
+	e := &Eviction{}
+	e.samples.Store(5)
+	e.lfuLogFactor.Store(10)
+	e.lfuDecayTime.Store(1)
+
+	return e
+}
+
+// KeyMeta is the metadata kept for each key.
+type KeyMeta struct {
+	Size   int64 // Approximate bytes used by the key and its value
+	Access int64 // Unix time in milliseconds of the last access
+	Freq   uint8 // Logarithmic access counter of LFU
+	Decay  int64 // Unix time in minutes of the last decrement of the LFU counter
+	// Representation of the value that Redis would use, see OBJECT ENCODING
+	Encoding string
+}
+
+// setMaxmemory is the hook of maxmemory.
+func (r *Redis) setMaxmemory(value string) error {
+	n, ok := util.ParseMemory(value)
+
+	if !ok {
+		return errors.New("argument must be a memory value")
+	}
+
+	r.eviction.maxmemory.Store(n)
+	return nil
+}
+
+// setMaxmemoryPolicy is the hook of maxmemory-policy.
+func (r *Redis) setMaxmemoryPolicy(value string) error {
+	policy, ok := maxmemoryPolicies[strings.ToLower(value)]
+
+	if !ok {
+		return errors.New("argument(s) must be one of the following: volatile-lru, " +
+			"volatile-lfu, volatile-random, volatile-ttl, allkeys-lru, allkeys-lfu, " +
+			"allkeys-random, noeviction")
+	}
+
+	r.eviction.policy.Store(policy)
+	return nil
+}
+
+// setMaxmemorySamples is the hook of maxmemory-samples.
+func (r *Redis) setMaxmemorySamples(value string) error {
+	n, err := strconv.ParseInt(value, 10, 32)
+
+	if err != nil || n < 1 || n > 64 {
+		return errors.New("argument must be between 1 and 64 inclusive")
+	}
+
+	r.eviction.samples.Store(int32(n))
+	return nil
+}
+
+// setLfuLogFactor is the hook of lfu-log-factor.
+func (r *Redis) setLfuLogFactor(value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || n < 0 {
+		return errors.New("argument must be greater or equal to 0")
+	}
+
+	r.eviction.lfuLogFactor.Store(n)
+	return nil
+}
+
+// setLfuDecayTime is the hook of lfu-decay-time.
+func (r *Redis) setLfuDecayTime(value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || n < 0 {
+		return errors.New("argument must be greater or equal to 0")
+	}
+
+	r.eviction.lfuDecayTime.Store(n)
+	return nil
+}
+
+// UsedMemory returns the approximate number of bytes used by the keys.
+func (r *Redis) UsedMemory() int64 {
+	return r.eviction.used.Load()
+}
+
+// ItemMemoryUsage returns the approximate number of bytes used by the item,
+// estimated from a sample of its elements, or all of them if samples is 0.
+func (r *Redis) ItemMemoryUsage(i types.Item, samples int) int64 {
+	if sized, ok := i.(types.Sized); ok {
+		return int64(sized.MemoryUsage(samples))
+	}
+
+	if ct, ok := r.CustomType(i.TypeFancy()); ok && ct.MemUsage != nil {
+		return int64(ct.MemUsage(i))
+	}
+
+	return 0
+}
+
+// LfuPolicy returns whether the keys are evicted by their access frequency.
+func (r *Redis) LfuPolicy() bool {
+	policy := r.eviction.policy.Load()
+	return policy == MAXMEMORY_ALLKEYS_LFU || policy == MAXMEMORY_VOLATILE_LFU
+}
+
+// lfuLogIncr increments the LFU counter logarithmically, so that the
+// counter is less likely to be incremented the greater it is.
+func (r *Redis) lfuLogIncr(counter uint8) uint8 {
+	if counter == math.MaxUint8 {
+		return counter
+	}
+
+	baseval := float64(counter) - LFU_INIT_VAL
+
+	if baseval < 0 {
+		baseval = 0
+	}
+
+	p := 1.0 / (baseval*float64(r.eviction.lfuLogFactor.Load()) + 1)
+
+	if rand.Float64() < p {
+		counter++
+	}
+
+	return counter
+}
+
+// lfuDecrAndReturn returns the LFU counter decremented once for every
+// lfu-decay-time minutes elapsed since it was last decremented.
+func (r *Redis) lfuDecrAndReturn(meta *KeyMeta, now time.Time) uint8 {
+	decayTime := r.eviction.lfuDecayTime.Load()
+
+	if decayTime == 0 {
+		return meta.Freq
+	}
+
+	periods := (now.Unix()/60 - meta.Decay) / decayTime
+
+	if periods >= int64(meta.Freq) {
+		return 0
+	}
+
+	if periods < 0 {
+		return meta.Freq
+	}
+
+	return meta.Freq - uint8(periods)
+}
+
+// touch updates the access metadata of a key that has been looked up.
+func (db *Db) touch(meta *KeyMeta) {
+	r := db.redis
+	now := r.Now()
+
+	if r.LfuPolicy() {
+		meta.Freq = r.lfuLogIncr(r.lfuDecrAndReturn(meta, now))
+		meta.Decay = now.Unix() / 60
+	} else {
+		meta.Access = now.UnixMilli()
+	}
+}
+
+// account updates the memory used by a key whose value has been set.
+func (db *Db) account(key string, i types.Item) {
+	r := db.redis
+	size := int64(keyOverhead+len(key)) + r.ItemMemoryUsage(i, memorySamples)
+	meta, exists := db.meta[key]
+
+	if !exists {
+		now := r.Now()
+		meta = &KeyMeta{
+			Access: now.UnixMilli(),
+			Freq:   LFU_INIT_VAL,
+			Decay:  now.Unix() / 60,
+		}
+		db.meta[key] = meta
+	}
+
+	db.used.Add(size - meta.Size)
+	r.eviction.used.Add(size - meta.Size)
+	meta.Size = size
+	meta.Encoding = r.itemEncoding(i, meta.Encoding, commandEvent(db.caller, ""))
+}
+
+// forget removes the metadata of a key that has been deleted.
+func (db *Db) forget(key string) {
+	meta, exists := db.meta[key]
+
+	if !exists {
+		return
+	}
+
+	db.used.Add(-meta.Size)
+	db.redis.eviction.used.Add(-meta.Size)
+	delete(db.meta, key)
+}
+
+// OutOfMemory returns whether the keys use more memory than maxmemory.
+func (r *Redis) OutOfMemory() bool {
+	maxmemory := r.eviction.maxmemory.Load()
+	return maxmemory != 0 && r.UsedMemory() > int64(maxmemory)
+}
+
+// evictionCandidate is a key sampled by the eviction, the greater its score
+// the better it is to evict it.
+type evictionCandidate struct {
+	db    *Db
+	key   string
+	score float64
+}
+
+// PerformEvictions evicts keys according to maxmemory-policy until the keys
+// fit in maxmemory. It returns false if not enough keys could be evicted.
+// The databases must not be locked by the caller.
+func (r *Redis) PerformEvictions() bool {
+	if !r.OutOfMemory() {
+		return true
+	}
+
+	if r.eviction.policy.Load() == MAXMEMORY_NO_EVICTION {
+		return false
+	}
+
+	start := time.Now()
+	defer func() {
+		r.LatencyAddSampleIfNeeded(LATENCY_EVENT_EVICTION_CYCLE, time.Since(start))
+	}()
+
+	for r.OutOfMemory() {
+		best := r.sampleEvictionCandidate()
+
+		if best == nil {
+			return false
+		}
+
+		best.db.Lock()
+
+		if _, exists := best.db.Storage[best.key]; exists {
+			best.db.evict(best.key)
+		}
+
+		best.db.Unlock()
+	}
+
+	return true
+}
+
+// sampleEvictionCandidate samples maxmemory-samples keys from every database
+// and returns the best one to evict, or nil if there is none.
+func (r *Redis) sampleEvictionCandidate() *evictionCandidate {
+	policy := r.eviction.policy.Load()
+	samples := int(r.eviction.samples.Load())
+	volatile := policy == MAXMEMORY_VOLATILE_LRU || policy == MAXMEMORY_VOLATILE_LFU ||
+		policy == MAXMEMORY_VOLATILE_RANDOM || policy == MAXMEMORY_VOLATILE_TTL
+	now := r.Now()
+
+	dbs := make([]*Db, 0, len(r.dbs))
+
+	for _, db := range r.RedisDbs() {
+		dbs = append(dbs, db)
+	}
+
+	sort.Slice(dbs, func(i, j int) bool {
+		return dbs[i].id < dbs[j].id
+	})
+
+	var best *evictionCandidate
+
+	for _, db := range dbs {
+		db.Lock()
+
+		sampled := 0
+
+		// The iteration order of the maps is random, which makes for the sampling
+		for key, meta := range db.meta {
+			if sampled >= samples {
+				break
+			}
+
+			ttl := db.Ttl[key]
+
+			if volatile && ttl.IsZero() {
+				continue
+			}
+
+			sampled++
+			var score float64
+
+			switch policy {
+			case MAXMEMORY_ALLKEYS_LRU, MAXMEMORY_VOLATILE_LRU:
+				score = float64(now.UnixMilli() - meta.Access)
+			case MAXMEMORY_ALLKEYS_LFU, MAXMEMORY_VOLATILE_LFU:
+				score = float64(math.MaxUint8 - r.lfuDecrAndReturn(meta, now))
+			case MAXMEMORY_VOLATILE_TTL:
+				score = -float64(ttl.UnixMilli())
+			case MAXMEMORY_ALLKEYS_RANDOM, MAXMEMORY_VOLATILE_RANDOM:
+				score = rand.Float64()
+			}
+
+			if best == nil || score > best.score {
+				best = &evictionCandidate{db: db, key: key, score: score}
+			}
+		}
+
+		db.Unlock()
+	}
+
+	return best
+}
+
+// evict deletes a key to free memory.
+func (db *Db) evict(key string) {
+	if db.delete(key, nil) {
+		db.notify(NOTIFY_EVICTED, "evicted", key)
+		db.redis.stats.evictedKeys.Add(1)
+	}
+}
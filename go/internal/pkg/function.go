@@ -0,0 +1,826 @@
+package pkg
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hbina/radish/internal/util"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+const (
+	// The only engine of the libraries.
+	FUNCTION_ENGINE_LUA = "LUA"
+	// The file of dir where the libraries are saved.
+	FUNCTIONS_FILENAME = "functions.rdb"
+	// The time a library has to register its functions.
+	FUNCTION_LOAD_TIMEOUT = 500 * time.Millisecond
+	// The opcode of a library in the RDB format.
+	RDB_OPCODE_FUNCTION2 = 245
+)
+
+// Flags of the functions, given to redis.register_function.
+const (
+	FUNCTION_FLAG_NO_WRITES = 1 << iota
+	FUNCTION_FLAG_ALLOW_OOM
+	FUNCTION_FLAG_ALLOW_STALE
+	FUNCTION_FLAG_NO_CLUSTER
+	FUNCTION_FLAG_ALLOW_CROSS_SLOT_KEYS
+)
+
+var functionFlagNames = []struct {
+	flag int
+	name string
+}{
+	{FUNCTION_FLAG_NO_WRITES, "no-writes"},
+	{FUNCTION_FLAG_ALLOW_OOM, "allow-oom"},
+	{FUNCTION_FLAG_ALLOW_STALE, "allow-stale"},
+	{FUNCTION_FLAG_NO_CLUSTER, "no-cluster"},
+	{FUNCTION_FLAG_ALLOW_CROSS_SLOT_KEYS, "allow-cross-slot-keys"},
+}
+
+// Policies of FUNCTION RESTORE for the libraries that already exist.
+type FunctionRestorePolicy int
+
+const (
+	FUNCTION_RESTORE_APPEND FunctionRestorePolicy = iota
+	FUNCTION_RESTORE_REPLACE
+	FUNCTION_RESTORE_FLUSH
+)
+
+var (
+	ErrLibraryNotFound   = errors.New("ERR Library not found")
+	errFunctionNotFound  = errors.New("ERR Function not found")
+	errFunctionsPayload  = errors.New("ERR payload version or checksum are wrong")
+	errFunctionsNotSaved = errors.New("ERR There was an error trying to save the functions. " +
+		"Please check the server logs for more information")
+)
+
+// Functions holds the libraries of functions loaded by FUNCTION LOAD.
+type Functions struct {
+	mu   *sync.Mutex
+	libs *libraries // Replaced as a whole by every change
+	// The interpreter where the libraries are loaded and their functions
+	// run, nil until the first library is loaded
+	ls      *luaState
+	stateMu *sync.Mutex // Held while the interpreter runs and while the libraries change
+}
+
+func newFunctions() *Functions {
+	return &Functions{
+		mu:      new(sync.Mutex),
+		libs:    newLibraries(),
+		stateMu: new(sync.Mutex),
+	}
+}
+
+// libraries are the loaded libraries and their functions.
+type libraries struct {
+	byName    map[string]*library
+	functions map[string]*function // The functions of all the libraries by their name
+}
+
+func newLibraries() *libraries {
+	return &libraries{
+		byName:    make(map[string]*library, 0),
+		functions: make(map[string]*function, 0),
+	}
+}
+
+// library is a library of functions loaded by FUNCTION LOAD.
+type library struct {
+	name      string
+	code      string
+	functions map[string]*function
+}
+
+// function is a function registered by a library.
+type function struct {
+	name        string
+	callback    *lua.LFunction
+	description *string
+	flags       int
+}
+
+// LibraryInfo describes a library for FUNCTION LIST.
+type LibraryInfo struct {
+	Name      string
+	Engine    string
+	Code      string
+	Functions []FunctionInfo
+}
+
+// FunctionInfo describes a function of a library for FUNCTION LIST.
+type FunctionInfo struct {
+	Name        string
+	Description *string
+	Flags       []string
+}
+
+// FunctionStats is reported by FUNCTION STATS.
+type FunctionStats struct {
+	Running   *RunningFunction // Nil if no function is running
+	Libraries int
+	Functions int
+}
+
+// RunningFunction is a function being run by FCALL.
+type RunningFunction struct {
+	Name     string
+	Command  []string
+	Duration time.Duration
+}
+
+func (libs *libraries) clone() *libraries {
+	res := newLibraries()
+
+	for name, lib := range libs.byName {
+		res.byName[name] = lib
+	}
+
+	for name, fn := range libs.functions {
+		res.functions[name] = fn
+	}
+
+	return res
+}
+
+// add adds the library. The library of the same name is replaced only if
+// replace is set. It fails if a function of another library has the name
+// of one of its functions.
+func (libs *libraries) add(lib *library, replace bool) error {
+	if old, exists := libs.byName[lib.name]; exists {
+		if !replace {
+			return fmt.Errorf("ERR Library '%s' already exists", lib.name)
+		}
+
+		libs.remove(old)
+	}
+
+	for name := range lib.functions {
+		if _, exists := libs.functions[name]; exists {
+			return fmt.Errorf("ERR Function %s already exists", name)
+		}
+	}
+
+	libs.byName[lib.name] = lib
+
+	for name, fn := range lib.functions {
+		libs.functions[name] = fn
+	}
+
+	return nil
+}
+
+func (libs *libraries) remove(lib *library) {
+	delete(libs.byName, lib.name)
+
+	for name := range lib.functions {
+		delete(libs.functions, name)
+	}
+}
+
+// sorted returns the libraries ordered by their name.
+func (libs *libraries) sorted() []*library {
+	res := make([]*library, 0, len(libs.byName))
+
+	for _, lib := range libs.byName {
+		res = append(res, lib)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].name < res[j].name
+	})
+
+	return res
+}
+
+// validFunctionName returns whether the name of a library or a function
+// is only made of letters, numbers and underscores.
+func validFunctionName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseLibraryMetadata parses the first line of the code of a library,
+// e.g. #!lua name=mylib. It returns the name of the library and the code
+// without the metadata.
+func parseLibraryMetadata(code string) (string, string, error) {
+	if !strings.HasPrefix(code, "#!") {
+		return "", "", errors.New("ERR Missing library metadata")
+	}
+
+	line, body, _ := strings.Cut(code, "\n")
+	parts := strings.Fields(line[2:])
+
+	if len(parts) == 0 {
+		return "", "", errors.New("ERR Missing library metadata")
+	}
+
+	if !strings.EqualFold(parts[0], FUNCTION_ENGINE_LUA) {
+		return "", "", fmt.Errorf("ERR Engine '%s' not found", parts[0])
+	}
+
+	name := ""
+
+	for _, part := range parts[1:] {
+		if !strings.HasPrefix(part, "name=") {
+			return "", "", fmt.Errorf("ERR Invalid metadata value given: %s", part)
+		}
+
+		name = strings.TrimPrefix(part, "name=")
+	}
+
+	if name == "" {
+		return "", "", errors.New("ERR Library name was not given")
+	}
+
+	if !validFunctionName(name) {
+		return "", "", errors.New("ERR Library names can only contain letters, numbers, " +
+			"or underscores(_) and must be at least one character long")
+	}
+
+	// The first line is left empty so that the errors report the right lines
+	return name, "\n" + body, nil
+}
+
+// functionsState returns the interpreter of the libraries.
+// The lock of the interpreter must be held.
+func (r *Redis) functionsState() *luaState {
+	f := r.functions
+
+	if f.ls == nil {
+		f.ls = r.newLuaState()
+	}
+
+	return f.ls
+}
+
+// createLibrary loads the code of a library into the interpreter of the
+// libraries, which registers its functions.
+// The lock of the interpreter must be held.
+func (r *Redis) createLibrary(code string) (*library, error) {
+	name, body, err := parseLibraryMetadata(code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	chunk, err := parse.Parse(strings.NewReader(body), luaFunctionChunk)
+
+	if err != nil {
+		return nil, compileError("function", err)
+	}
+
+	proto, err := lua.Compile(chunk, luaFunctionChunk)
+
+	if err != nil {
+		return nil, compileError("function", err)
+	}
+
+	lib := &library{
+		name:      name,
+		code:      code,
+		functions: make(map[string]*function, 0),
+	}
+
+	ls := r.functionsState()
+
+	// The libraries can not take too long to register their functions
+	ctx, cancel := context.WithTimeout(context.Background(), FUNCTION_LOAD_TIMEOUT)
+	defer cancel()
+
+	ls.loading = lib
+	ls.L.SetContext(ctx)
+	ls.L.Push(ls.L.NewFunctionFromProto(proto))
+	err = ls.L.PCall(0, 0, nil)
+	ls.L.RemoveContext()
+	ls.L.SetTop(0)
+	ls.loading = nil
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, errors.New("ERR FUNCTION LOAD timeout")
+	}
+
+	if err != nil {
+		msg := err.Error()
+
+		if apiErr, ok := err.(*lua.ApiError); ok {
+			msg = apiErr.Object.String()
+		}
+
+		return nil, errors.New("ERR Error registering functions: " + msg)
+	}
+
+	if len(lib.functions) == 0 {
+		return nil, errors.New("ERR No functions registered")
+	}
+
+	return lib, nil
+}
+
+// registerFunction is redis.register_function, which registers a function
+// of the library being loaded. It takes either the name and the callback
+// of the function or a table of named arguments.
+func (ls *luaState) registerFunction(L *lua.LState) int {
+	lib := ls.loading
+
+	if lib == nil {
+		L.RaiseError("redis.register_function can only be called on FUNCTION LOAD command")
+	}
+
+	fn := &function{}
+	var name, callback lua.LValue
+
+	switch L.GetTop() {
+	case 1:
+		t, ok := L.Get(1).(*lua.LTable)
+
+		if !ok {
+			L.RaiseError("calling redis.register_function with a single argument is only applicable " +
+				"to Lua table (representing named arguments).")
+		}
+
+		var flags, description lua.LValue
+		unknown := false
+
+		t.ForEach(func(k lua.LValue, v lua.LValue) {
+			switch k.String() {
+			case "function_name":
+				name = v
+			case "callback":
+				callback = v
+			case "flags":
+				flags = v
+			case "description":
+				description = v
+			default:
+				unknown = true
+			}
+		})
+
+		if unknown {
+			L.RaiseError("unknown argument given to redis.register_function")
+		}
+
+		if description != nil {
+			s, ok := description.(lua.LString)
+
+			if !ok {
+				L.RaiseError("description argument given to redis.register_function must be a string")
+			}
+
+			value := string(s)
+			fn.description = &value
+		}
+
+		if flags != nil {
+			t, ok := flags.(*lua.LTable)
+
+			if !ok {
+				L.RaiseError("flags argument to redis.register_function must be a table representing function flags")
+			}
+
+			for i := 1; i <= t.Len(); i++ {
+				flag := functionFlag(t.RawGetInt(i))
+
+				if flag == 0 {
+					L.RaiseError("unknown flag given")
+				}
+
+				fn.flags |= flag
+			}
+		}
+	case 2:
+		name, callback = L.Get(1), L.Get(2)
+	default:
+		L.RaiseError("wrong number of arguments to redis.register_function")
+	}
+
+	if s, ok := name.(lua.LString); ok {
+		fn.name = string(s)
+	} else {
+		L.RaiseError("function_name argument given to redis.register_function must be a string")
+	}
+
+	if f, ok := callback.(*lua.LFunction); ok {
+		fn.callback = f
+	} else {
+		L.RaiseError("callback argument given to redis.register_function must be a function")
+	}
+
+	if !validFunctionName(fn.name) {
+		L.RaiseError("Function names can only contain letters, numbers, or underscores(_) " +
+			"and must be at least one character long")
+	}
+
+	if _, exists := lib.functions[fn.name]; exists {
+		L.RaiseError("Function already exists in the library")
+	}
+
+	lib.functions[fn.name] = fn
+	return 0
+}
+
+// functionFlag returns the flag of its name, 0 if it is unknown.
+func functionFlag(value lua.LValue) int {
+	s, ok := value.(lua.LString)
+
+	if !ok {
+		return 0
+	}
+
+	for _, f := range functionFlagNames {
+		if f.name == string(s) {
+			return f.flag
+		}
+	}
+
+	return 0
+}
+
+// commitLibraries saves the libraries and makes them the loaded ones.
+// The libraries are not changed if they cannot be saved.
+func (r *Redis) commitLibraries(libs *libraries) error {
+	err := r.saveFunctions(libs)
+
+	if err != nil {
+		return err
+	}
+
+	f := r.functions
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.libs = libs
+	return nil
+}
+
+// FunctionLoad loads a library and returns its name.
+// The library of the same name is replaced only if replace is set.
+func (r *Redis) FunctionLoad(code string, replace bool) (string, error) {
+	f := r.functions
+	f.stateMu.Lock()
+	defer f.stateMu.Unlock()
+
+	lib, err := r.createLibrary(code)
+
+	if err != nil {
+		return "", err
+	}
+
+	libs := r.loadedLibraries().clone()
+	err = libs.add(lib, replace)
+
+	if err != nil {
+		return "", err
+	}
+
+	return lib.name, r.commitLibraries(libs)
+}
+
+// FunctionDelete deletes the library and its functions.
+func (r *Redis) FunctionDelete(name string) error {
+	f := r.functions
+	f.stateMu.Lock()
+	defer f.stateMu.Unlock()
+
+	libs := r.loadedLibraries().clone()
+	lib, exists := libs.byName[name]
+
+	if !exists {
+		return ErrLibraryNotFound
+	}
+
+	libs.remove(lib)
+	return r.commitLibraries(libs)
+}
+
+// FunctionFlush deletes all the libraries.
+func (r *Redis) FunctionFlush() error {
+	f := r.functions
+	f.stateMu.Lock()
+	defer f.stateMu.Unlock()
+
+	err := r.commitLibraries(newLibraries())
+
+	if err != nil {
+		return err
+	}
+
+	// Nothing refers to the functions of the interpreter anymore
+	if f.ls != nil {
+		f.ls.L.Close()
+		f.ls = nil
+	}
+
+	return nil
+}
+
+func (r *Redis) loadedLibraries() *libraries {
+	f := r.functions
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.libs
+}
+
+// FunctionList returns the libraries whose name matches the pattern,
+// ordered by their name. All the libraries match an empty pattern.
+func (r *Redis) FunctionList(pattern string) []LibraryInfo {
+	res := make([]LibraryInfo, 0)
+
+	for _, lib := range r.loadedLibraries().sorted() {
+		if pattern != "" && !util.StringMatch(pattern, lib.name, false) {
+			continue
+		}
+
+		info := LibraryInfo{
+			Name:      lib.name,
+			Engine:    FUNCTION_ENGINE_LUA,
+			Code:      lib.code,
+			Functions: make([]FunctionInfo, 0, len(lib.functions)),
+		}
+
+		for _, fn := range lib.functions {
+			flags := make([]string, 0)
+
+			for _, f := range functionFlagNames {
+				if fn.flags&f.flag != 0 {
+					flags = append(flags, f.name)
+				}
+			}
+
+			info.Functions = append(info.Functions, FunctionInfo{
+				Name:        fn.name,
+				Description: fn.description,
+				Flags:       flags,
+			})
+		}
+
+		sort.Slice(info.Functions, func(i, j int) bool {
+			return info.Functions[i].Name < info.Functions[j].Name
+		})
+
+		res = append(res, info)
+	}
+
+	return res
+}
+
+// FunctionStats returns the running function and the number of libraries
+// and functions.
+func (r *Redis) FunctionStats() FunctionStats {
+	libs := r.loadedLibraries()
+	stats := FunctionStats{
+		Libraries: len(libs.byName),
+		Functions: len(libs.functions),
+	}
+
+	s := r.scripting
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for run := range s.running {
+		if run.function {
+			stats.Running = &RunningFunction{
+				Name:     run.name,
+				Command:  run.command,
+				Duration: time.Since(run.start),
+			}
+		}
+	}
+
+	return stats
+}
+
+// FunctionCacheStats returns the number of libraries and functions and
+// the size of the code of the libraries.
+func (r *Redis) FunctionCacheStats() (int, int, int64) {
+	libs := r.loadedLibraries()
+	size := int64(0)
+
+	for _, lib := range libs.byName {
+		size += int64(len(lib.code))
+	}
+
+	return len(libs.byName), len(libs.functions), size
+}
+
+// FunctionDump serializes the libraries as FUNCTION DUMP does: the code of
+// every library followed by the RDB version and the CRC64 of the payload.
+func (r *Redis) FunctionDump() []byte {
+	return dumpLibraries(r.loadedLibraries())
+}
+
+func dumpLibraries(libs *libraries) []byte {
+	w := &rdbWriter{}
+
+	for _, lib := range libs.sorted() {
+		w.writeByte(RDB_OPCODE_FUNCTION2)
+		w.writeString(lib.code)
+	}
+
+	w.buf = binary.LittleEndian.AppendUint16(w.buf, RDB_VERSION)
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, util.Crc64(0, w.buf))
+
+	return w.buf
+}
+
+// FunctionRestore loads the libraries serialized by FUNCTION DUMP.
+// Either all of them are loaded or none is.
+func (r *Redis) FunctionRestore(payload []byte, policy FunctionRestorePolicy) error {
+	if !VerifyDumpPayload(payload) {
+		return errFunctionsPayload
+	}
+
+	codes := make([]string, 0)
+	reader := &rdbReader{buf: payload[:len(payload)-10]}
+
+	for reader.pos < len(reader.buf) {
+		opcode, err := reader.readByte()
+
+		if err != nil || opcode != RDB_OPCODE_FUNCTION2 {
+			return errors.New("ERR given type is not a function")
+		}
+
+		code, err := reader.readString()
+
+		if err != nil {
+			return errFunctionsPayload
+		}
+
+		codes = append(codes, code)
+	}
+
+	f := r.functions
+	f.stateMu.Lock()
+	defer f.stateMu.Unlock()
+
+	libs := newLibraries()
+
+	if policy != FUNCTION_RESTORE_FLUSH {
+		libs = r.loadedLibraries().clone()
+	}
+
+	for _, code := range codes {
+		lib, err := r.createLibrary(code)
+
+		if err != nil {
+			return err
+		}
+
+		err = libs.add(lib, policy == FUNCTION_RESTORE_REPLACE)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.commitLibraries(libs)
+}
+
+// functionsFile returns the path of the file where the libraries are
+// saved. They are only saved if dir is set.
+func (r *Redis) functionsFile() (string, bool) {
+	v := r.GetConfigValue("dir")
+
+	if v == nil || *v == "" {
+		return "", false
+	}
+
+	return filepath.Join(*v, FUNCTIONS_FILENAME), true
+}
+
+// saveFunctions saves the libraries into the functions file of dir.
+func (r *Redis) saveFunctions(libs *libraries) error {
+	path, ok := r.functionsFile()
+
+	if !ok {
+		return nil
+	}
+
+	// Write to a temporary file first so that the functions file is never left half-written
+	tmp, err := os.CreateTemp(filepath.Dir(path), "temp-functions-*")
+
+	if err != nil {
+		r.logger.Printf("Failed saving the functions: %s", err)
+		return errFunctionsNotSaved
+	}
+
+	_, err = tmp.Write(dumpLibraries(libs))
+
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		r.logger.Printf("Failed saving the functions: %s", err)
+		return errFunctionsNotSaved
+	}
+
+	return nil
+}
+
+// LoadFunctions loads the libraries saved into the functions file of dir,
+// if there is one.
+func (r *Redis) LoadFunctions() error {
+	path, ok := r.functionsFile()
+
+	if !ok {
+		return nil
+	}
+
+	payload, err := os.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = r.FunctionRestore(payload, FUNCTION_RESTORE_FLUSH)
+
+	if err != nil {
+		return fmt.Errorf("failed loading %s: %s", path, err)
+	}
+
+	return nil
+}
+
+// FCall runs the function with the keys and the arguments and replies to
+// the client with its result. The function must not write if readOnly is
+// set. The database of the client must be locked, as for EvalSha.
+func (r *Redis) FCall(c *Client, name string, keys []string, args []string, readOnly bool) {
+	f := r.functions
+
+	// The functions are looked up with the interpreter locked so that they
+	// are not flushed in the meantime
+	f.stateMu.Lock()
+	defer f.stateMu.Unlock()
+
+	fn, exists := r.loadedLibraries().functions[name]
+
+	if !exists {
+		c.Conn().WriteError(errFunctionNotFound.Error())
+		return
+	}
+
+	noWrites := fn.flags&FUNCTION_FLAG_NO_WRITES != 0
+	allowOom := fn.flags&FUNCTION_FLAG_ALLOW_OOM != 0
+
+	if readOnly && !noWrites {
+		c.Conn().WriteError("ERR Can not execute a script with write flag using *_ro command.")
+		return
+	}
+
+	if !noWrites && !allowOom && r.OutOfMemory() {
+		c.Conn().WriteError(util.OOMErr)
+		return
+	}
+
+	command := "fcall"
+
+	if readOnly {
+		command = "fcall_ro"
+	}
+
+	run := r.newScriptRun(c, name, readOnly || noWrites)
+	run.function = true
+	run.allowOom = allowOom
+	run.command = append([]string{command, name, strconv.Itoa(len(keys))}, keys...)
+	run.command = append(run.command, args...)
+
+	ls := r.functionsState()
+	r.runScript(c, ls, run, fn.callback, luaStringTable(ls.L, keys), luaStringTable(ls.L, args))
+}
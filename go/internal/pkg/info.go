@@ -0,0 +1,424 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the version of Redis that radish reports.
+const Version = "7.0.0"
+
+// The sections of INFO in the order that they are printed.
+var infoSections = []string{
+	"server",
+	"clients",
+	"memory",
+	"persistence",
+	"stats",
+	"replication",
+	"cpu",
+	"modules",
+	"commandstats",
+	"errorstats",
+	"latencystats",
+	"cluster",
+	"keyspace",
+}
+
+// The sections that are only printed if requested explicitly or with "all".
+var infoNonDefaultSections = map[string]struct{}{
+	"commandstats": {},
+	"latencystats": {},
+}
+
+// Info generates the reply of INFO with the given sections.
+// No section, or "default", selects the default sections
+// while "all" and "everything" select all of them.
+func (r *Redis) Info(sections []string) string {
+	selected := make(map[string]struct{}, 0)
+
+	if len(sections) == 0 {
+		sections = []string{"default"}
+	}
+
+	for _, section := range sections {
+		section = strings.ToLower(section)
+
+		switch section {
+		case "all", "everything":
+			for _, s := range infoSections {
+				selected[s] = struct{}{}
+			}
+		case "default":
+			for _, s := range infoSections {
+				if _, ok := infoNonDefaultSections[s]; !ok {
+					selected[s] = struct{}{}
+				}
+			}
+		default:
+			selected[section] = struct{}{}
+		}
+	}
+
+	var str strings.Builder
+
+	for _, section := range infoSections {
+		if _, ok := selected[section]; !ok {
+			continue
+		}
+
+		if str.Len() > 0 {
+			str.WriteString("\r\n")
+		}
+
+		str.WriteString("# ")
+
+		if section == "cpu" {
+			str.WriteString("CPU")
+		} else {
+			str.WriteString(strings.ToUpper(section[:1]) + section[1:])
+		}
+
+		str.WriteString("\r\n")
+
+		for _, field := range r.infoSection(section) {
+			str.WriteString(field[0])
+			str.WriteString(":")
+			str.WriteString(field[1])
+			str.WriteString("\r\n")
+		}
+	}
+
+	return str.String()
+}
+
+func (r *Redis) infoSection(section string) [][2]string {
+	switch section {
+	case "server":
+		return r.infoServer()
+	case "clients":
+		return r.infoClients()
+	case "memory":
+		return r.infoMemory()
+	case "persistence":
+		return r.infoPersistence()
+	case "stats":
+		return r.infoStats()
+	case "replication":
+		return r.infoReplication()
+	case "cpu":
+		return infoCpu()
+	case "modules":
+		return r.infoModules()
+	case "commandstats":
+		return r.infoCommandStats()
+	case "errorstats":
+		return r.infoErrorStats()
+	case "latencystats":
+		return r.infoLatencyStats()
+	case "cluster":
+		return [][2]string{{"cluster_enabled", "0"}}
+	case "keyspace":
+		return r.infoKeyspace()
+	}
+
+	return nil
+}
+
+func (r *Redis) configOrDefault(key string, def string) string {
+	if v := r.GetConfigValue(key); v != nil {
+		return *v
+	}
+
+	return def
+}
+
+func (r *Redis) infoServer() [][2]string {
+	uptime := time.Since(r.stats.startTime)
+
+	return [][2]string{
+		{"redis_version", Version},
+		{"redis_git_sha1", "00000000"},
+		{"redis_git_dirty", "0"},
+		{"redis_mode", "standalone"},
+		{"os", runtime.GOOS + " " + runtime.GOARCH},
+		{"arch_bits", strconv.Itoa(strconv.IntSize)},
+		{"multiplexing_api", "go"},
+		{"go_version", runtime.Version()},
+		{"process_id", strconv.Itoa(os.Getpid())},
+		{"run_id", r.stats.runId},
+		{"tcp_port", r.configOrDefault("port", "0")},
+		{"server_time_usec", strconv.FormatInt(time.Now().UnixMicro(), 10)},
+		{"uptime_in_seconds", strconv.FormatInt(int64(uptime/time.Second), 10)},
+		{"uptime_in_days", strconv.FormatInt(int64(uptime/(24*time.Hour)), 10)},
+		{"hz", r.configOrDefault("hz", "10")},
+		{"executable", executable()},
+		{"config_file", ""},
+	}
+}
+
+func executable() string {
+	path, err := os.Executable()
+
+	if err != nil {
+		return ""
+	}
+
+	return path
+}
+
+func (r *Redis) infoClients() [][2]string {
+	r.cliLock.Lock()
+	connected := len(r.clients)
+	blocked := len(r.rlist)
+	r.cliLock.Unlock()
+
+	trackingClients, _, _, _ := r.trackingStats()
+
+	return [][2]string{
+		{"connected_clients", strconv.Itoa(connected)},
+		{"maxclients", r.configOrDefault("maxclients", "10000")},
+		{"blocked_clients", strconv.Itoa(blocked)},
+		{"tracking_clients", strconv.Itoa(trackingClients)},
+	}
+}
+
+func (r *Redis) infoMemory() [][2]string {
+	m := r.readMemStats()
+	peak := r.stats.peakAllocated.Load()
+	maxmemory := r.eviction.maxmemory.Load()
+	dataset := uint64(r.UsedMemory())
+	scripts, scriptsSize := r.ScriptCacheStats()
+	libraries, functions, functionsSize := r.FunctionCacheStats()
+
+	return [][2]string{
+		{"used_memory", strconv.FormatUint(m.HeapAlloc, 10)},
+		{"used_memory_human", humanBytes(m.HeapAlloc)},
+		{"used_memory_peak", strconv.FormatUint(peak, 10)},
+		{"used_memory_peak_human", humanBytes(peak)},
+		{"used_memory_startup", strconv.FormatUint(r.stats.startupAllocated, 10)},
+		// The approximate memory of the keys, which is limited by maxmemory
+		{"used_memory_dataset", strconv.FormatUint(dataset, 10)},
+		{"used_memory_dataset_human", humanBytes(dataset)},
+		{"used_memory_rss", strconv.FormatUint(m.Sys, 10)},
+		{"used_memory_rss_human", humanBytes(m.Sys)},
+		{"total_system_memory", "0"},
+		{"number_of_cached_scripts", strconv.Itoa(scripts)},
+		{"used_memory_scripts", strconv.FormatInt(scriptsSize, 10)},
+		{"used_memory_scripts_human", humanBytes(uint64(scriptsSize))},
+		{"used_memory_functions", strconv.FormatInt(functionsSize, 10)},
+		{"number_of_functions", strconv.Itoa(functions)},
+		{"number_of_libraries", strconv.Itoa(libraries)},
+		{"maxmemory", strconv.FormatUint(maxmemory, 10)},
+		{"maxmemory_human", humanBytes(maxmemory)},
+		{"maxmemory_policy", r.configOrDefault("maxmemory-policy", "noeviction")},
+		{"mem_allocator", "go"},
+	}
+}
+
+// humanBytes formats bytes as INFO does, e.g. 1.50K.
+func humanBytes(n uint64) string {
+	units := []string{"B", "K", "M", "G", "T", "P"}
+	v := float64(n)
+	i := 0
+
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+
+	if i == 0 {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	return fmt.Sprintf("%.2f%s", v, units[i])
+}
+
+func (r *Redis) infoPersistence() [][2]string {
+	return [][2]string{
+		{"loading", "0"},
+		{"async_loading", "0"},
+		{"rdb_changes_since_last_save", strconv.FormatUint(r.stats.dirty.Load(), 10)},
+		{"rdb_bgsave_in_progress", "0"},
+		{"rdb_last_save_time", strconv.FormatInt(r.stats.startTime.Unix(), 10)},
+		{"rdb_last_bgsave_status", "ok"},
+		{"aof_enabled", "0"},
+		{"aof_rewrite_in_progress", "0"},
+		{"aof_last_write_status", "ok"},
+	}
+}
+
+func (r *Redis) infoStats() [][2]string {
+	s := r.stats
+	_, trackingKeys, trackingItems, trackingPrefixes := r.trackingStats()
+	channels, patterns := r.pubSubStats()
+
+	return [][2]string{
+		{"total_connections_received", strconv.FormatUint(s.TotalConnections(), 10)},
+		{"total_commands_processed", strconv.FormatUint(s.TotalCommands(), 10)},
+		{"instantaneous_ops_per_sec", strconv.FormatInt(int64(s.InstantaneousOps()), 10)},
+		{"total_net_input_bytes", strconv.FormatUint(s.NetInputBytes(), 10)},
+		{"total_net_output_bytes", strconv.FormatUint(s.NetOutputBytes(), 10)},
+		{"instantaneous_input_kbps", fmt.Sprintf("%.2f", s.InstantaneousInputKbps())},
+		{"instantaneous_output_kbps", fmt.Sprintf("%.2f", s.InstantaneousOutputKbps())},
+		{"rejected_connections", "0"},
+		{"expired_keys", strconv.FormatUint(s.ExpiredKeys(), 10)},
+		{"evicted_keys", strconv.FormatUint(s.EvictedKeys(), 10)},
+		{"keyspace_hits", strconv.FormatUint(s.KeyspaceHits(), 10)},
+		{"keyspace_misses", strconv.FormatUint(s.KeyspaceMisses(), 10)},
+		{"pubsub_channels", strconv.Itoa(channels)},
+		{"pubsub_patterns", strconv.Itoa(patterns)},
+		{"migrate_cached_sockets", strconv.Itoa(r.MigrateCachedSockets())},
+		{"tracking_total_keys", strconv.Itoa(trackingKeys)},
+		{"tracking_total_items", strconv.Itoa(trackingItems)},
+		{"tracking_total_prefixes", strconv.Itoa(trackingPrefixes)},
+		{"total_error_replies", strconv.FormatUint(s.TotalErrors(), 10)},
+	}
+}
+
+func (r *Redis) infoReplication() [][2]string {
+	return [][2]string{
+		{"role", "master"},
+		{"connected_slaves", "0"},
+		{"master_replid", r.stats.runId},
+		{"master_repl_offset", "0"},
+	}
+}
+
+func (r *Redis) infoModules() [][2]string {
+	res := make([][2]string, 0)
+
+	for _, m := range r.Modules() {
+		res = append(res, [2]string{
+			"module",
+			fmt.Sprintf("name=%s,ver=%d,api=1,filters=0,usedby=[],using=[],options=[]", m.Name, m.Version),
+		})
+	}
+
+	return res
+}
+
+func (r *Redis) infoCommandStats() [][2]string {
+	names, stats := r.stats.Commands()
+	res := make([][2]string, 0, len(names))
+
+	for i, name := range names {
+		cs := stats[i]
+		perCall := 0.0
+
+		if cs.Calls > 0 {
+			perCall = float64(cs.Usec) / float64(cs.Calls)
+		}
+
+		res = append(res, [2]string{
+			"cmdstat_" + name,
+			fmt.Sprintf("calls=%d,usec=%d,usec_per_call=%.2f,rejected_calls=%d,failed_calls=%d",
+				cs.Calls, cs.Usec, perCall, cs.RejectedCalls, cs.FailedCalls),
+		})
+	}
+
+	return res
+}
+
+func (r *Redis) infoErrorStats() [][2]string {
+	codes, counts := r.stats.Errors()
+	res := make([][2]string, 0, len(codes))
+
+	for i, code := range codes {
+		res = append(res, [2]string{"errorstat_" + code, fmt.Sprintf("count=%d", counts[i])})
+	}
+
+	return res
+}
+
+// latencyPercentiles returns the percentiles reported by the latencystats section.
+func (r *Redis) latencyPercentiles() []float64 {
+	res := make([]float64, 0)
+
+	for _, s := range strings.Fields(r.configOrDefault("latency-tracking-info-percentiles", "50 99 99.9")) {
+		p, err := strconv.ParseFloat(s, 64)
+
+		if err == nil && p >= 0 && p <= 100 {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+func (r *Redis) infoLatencyStats() [][2]string {
+	if r.configOrDefault("latency-tracking", "yes") != "yes" {
+		return nil
+	}
+
+	percentiles := r.latencyPercentiles()
+	names, stats := r.stats.Commands()
+	res := make([][2]string, 0, len(names))
+
+	for i, name := range names {
+		if stats[i].Calls == 0 {
+			continue
+		}
+
+		values := make([]string, 0, len(percentiles))
+
+		for j, v := range r.stats.Percentiles(name, percentiles) {
+			values = append(values, fmt.Sprintf("p%s=%.3f",
+				strconv.FormatFloat(percentiles[j], 'f', -1, 64), v))
+		}
+
+		res = append(res, [2]string{"latency_percentiles_usec_" + name, strings.Join(values, ",")})
+	}
+
+	return res
+}
+
+func (r *Redis) infoKeyspace() [][2]string {
+	dbs := r.RedisDbs()
+	ids := make([]uint64, 0, len(dbs))
+
+	for id := range dbs {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	now := r.Now().UnixMilli()
+	res := make([][2]string, 0, len(ids))
+
+	// The databases are not locked since the caller holds the lock of its own
+	for _, id := range ids {
+		db := dbs[id]
+		keys := db.keys.Load()
+		expires := db.expires.Load()
+
+		if keys == 0 {
+			continue
+		}
+
+		var avgTtl int64
+
+		if expires > 0 {
+			avgTtl = (db.expirySum.Load() - expires*now) / expires
+		}
+
+		// The keys that have expired but are not deleted yet
+		if avgTtl < 0 {
+			avgTtl = 0
+		}
+
+		res = append(res, [2]string{
+			fmt.Sprintf("db%d", id),
+			fmt.Sprintf("keys=%d,expires=%d,avg_ttl=%d", keys, expires, avgTtl),
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,470 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Number of samples kept for every latency event, as in Redis.
+const LatencyTsLen = 160
+
+// Latency events monitored by the server.
+const (
+	LATENCY_EVENT_COMMAND        = "command"
+	LATENCY_EVENT_FAST_COMMAND   = "fast-command"
+	LATENCY_EVENT_EXPIRE_CYCLE   = "expire-cycle"
+	LATENCY_EVENT_EVICTION_CYCLE = "eviction-cycle"
+)
+
+// LatencySample is the highest latency of an event within a second.
+type LatencySample struct {
+	Time    time.Time
+	Latency time.Duration
+}
+
+// latencyEvent is a ring buffer of the latency samples of an event.
+type latencyEvent struct {
+	samples [LatencyTsLen]LatencySample
+	idx     int // Index of the next sample
+	max     time.Duration
+}
+
+// LatencyMonitor records the events that take longer than latency-monitor-threshold.
+type LatencyMonitor struct {
+	threshold atomic.Int64 // In milliseconds, 0 to disable the monitor
+	mu        *sync.Mutex
+	events    map[string]*latencyEvent
+}
+
+func newLatencyMonitor() *LatencyMonitor {
+	return &LatencyMonitor{
+		mu:     new(sync.Mutex),
+		events: make(map[string]*latencyEvent, 0),
+	}
+}
+
+// setLatencyThreshold is the hook of latency-monitor-threshold.
+func (r *Redis) setLatencyThreshold(value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || n < 0 {
+		return errors.New("argument must be between 0 and 9223372036854775807 inclusive")
+	}
+
+	r.latency.threshold.Store(n)
+	return nil
+}
+
+// LatencyAddSampleIfNeeded records the latency of the event if it reaches the threshold.
+func (r *Redis) LatencyAddSampleIfNeeded(event string, latency time.Duration) {
+	threshold := r.latency.threshold.Load()
+
+	if threshold <= 0 || latency.Milliseconds() < threshold {
+		return
+	}
+
+	r.latency.addSample(event, r.Now(), latency)
+}
+
+func (l *LatencyMonitor) addSample(event string, now time.Time, latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, exists := l.events[event]
+
+	if !exists {
+		e = &latencyEvent{}
+		l.events[event] = e
+	}
+
+	if latency > e.max {
+		e.max = latency
+	}
+
+	// Samples within the same second are merged into the highest one
+	prev := &e.samples[(e.idx+LatencyTsLen-1)%LatencyTsLen]
+
+	if prev.Time.Unix() == now.Unix() {
+		if latency > prev.Latency {
+			prev.Latency = latency
+		}
+
+		return
+	}
+
+	e.samples[e.idx] = LatencySample{Time: now, Latency: latency}
+	e.idx = (e.idx + 1) % LatencyTsLen
+}
+
+// history returns the samples of the event from the oldest to the newest.
+func (e *latencyEvent) history() []LatencySample {
+	res := make([]LatencySample, 0, LatencyTsLen)
+
+	for i := 0; i < LatencyTsLen; i++ {
+		s := e.samples[(e.idx+i)%LatencyTsLen]
+
+		if !s.Time.IsZero() {
+			res = append(res, s)
+		}
+	}
+
+	return res
+}
+
+// LatencyLatestEntry is the latest sample of an event with its all time maximum.
+type LatencyLatestEntry struct {
+	Event  string
+	Latest LatencySample
+	Max    time.Duration
+}
+
+// LatencyLatest returns the latest sample of every event ordered by their name.
+func (r *Redis) LatencyLatest() []LatencyLatestEntry {
+	l := r.latency
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	res := make([]LatencyLatestEntry, 0, len(l.events))
+
+	for name, e := range l.events {
+		res = append(res, LatencyLatestEntry{
+			Event:  name,
+			Latest: e.samples[(e.idx+LatencyTsLen-1)%LatencyTsLen],
+			Max:    e.max,
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Event < res[j].Event
+	})
+
+	return res
+}
+
+// LatencyHistory returns the samples of the event from the oldest to the newest.
+func (r *Redis) LatencyHistory(event string) []LatencySample {
+	l := r.latency
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, exists := l.events[event]
+
+	if !exists {
+		return []LatencySample{}
+	}
+
+	return e.history()
+}
+
+// LatencyReset removes the samples of the given events, or of all of them if
+// none is given, and returns the number of events that have been reset.
+func (r *Redis) LatencyReset(events ...string) int {
+	l := r.latency
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(events) == 0 {
+		count := len(l.events)
+		l.events = make(map[string]*latencyEvent, 0)
+		return count
+	}
+
+	count := 0
+
+	for _, event := range events {
+		if _, exists := l.events[event]; exists {
+			delete(l.events, event)
+			count++
+		}
+	}
+
+	return count
+}
+
+// LatencyGraph renders the samples of the event as an ASCII graph.
+func (r *Redis) LatencyGraph(event string) (string, bool) {
+	l := r.latency
+	l.mu.Lock()
+	e, exists := l.events[event]
+
+	if !exists {
+		l.mu.Unlock()
+		return "", false
+	}
+
+	samples := e.history()
+	max := e.max
+	l.mu.Unlock()
+
+	high, low := time.Duration(0), time.Duration(math.MaxInt64)
+
+	for _, s := range samples {
+		if s.Latency > high {
+			high = s.Latency
+		}
+
+		if s.Latency < low {
+			low = s.Latency
+		}
+	}
+
+	var str strings.Builder
+	header := fmt.Sprintf("%s - high %d ms, low %d ms (all time high %d ms)\n",
+		event, high.Milliseconds(), low.Milliseconds(), max.Milliseconds())
+	str.WriteString(header)
+	str.WriteString(strings.Repeat("-", len(header)-1))
+	str.WriteString("\n")
+
+	labels := make([]string, 0, len(samples))
+	now := r.Now()
+
+	for _, s := range samples {
+		labels = append(labels, latencyAgeLabel(now.Sub(s.Time)))
+	}
+
+	str.WriteString(renderSparkline(samples, labels, low, high))
+	return str.String(), true
+}
+
+// latencyAgeLabel formats the age of a sample as LATENCY GRAPH does, e.g. 15s or 2m.
+func latencyAgeLabel(age time.Duration) string {
+	secs := int64(age / time.Second)
+
+	switch {
+	case secs < 60:
+		return fmt.Sprintf("%ds", secs)
+	case secs < 3600:
+		return fmt.Sprintf("%dm", secs/60)
+	case secs < 86400:
+		return fmt.Sprintf("%dh", secs/3600)
+	default:
+		return fmt.Sprintf("%dd", secs/86400)
+	}
+}
+
+// Number of rows of the graphs and the characters that fill them,
+// every row can show three levels.
+const sparklineRows = 4
+
+var sparklineCharset = []byte("_o#")
+
+// renderSparkline draws one column per sample, the highest samples are the
+// tallest, followed by the labels written vertically.
+func renderSparkline(samples []LatencySample, labels []string, low time.Duration, high time.Duration) string {
+	steps := len(sparklineCharset) * sparklineRows
+	heights := make([]int, len(samples))
+
+	for i, s := range samples {
+		relative := 1.0
+
+		if high > low {
+			relative = float64(s.Latency-low) / float64(high-low)
+		}
+
+		heights[i] = int(relative * float64(steps-1))
+	}
+
+	var str strings.Builder
+
+	for row := sparklineRows - 1; row >= 0; row-- {
+		for _, h := range heights {
+			level := h - row*len(sparklineCharset)
+
+			switch {
+			case level < 0:
+				str.WriteByte(' ')
+			case level >= len(sparklineCharset):
+				str.WriteByte('|')
+			default:
+				str.WriteByte(sparklineCharset[level])
+			}
+		}
+
+		str.WriteString("\n")
+	}
+
+	str.WriteString("\n")
+
+	maxLabel := 0
+
+	for _, label := range labels {
+		if len(label) > maxLabel {
+			maxLabel = len(label)
+		}
+	}
+
+	for i := 0; i < maxLabel; i++ {
+		for _, label := range labels {
+			if i < len(label) {
+				str.WriteByte(label[i])
+			} else {
+				str.WriteByte(' ')
+			}
+		}
+
+		str.WriteString("\n")
+	}
+
+	return str.String()
+}
+
+// LatencyDoctor returns a human readable analysis of the latency events.
+func (r *Redis) LatencyDoctor() string {
+	l := r.latency
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.events) == 0 {
+		return "Dave, no latency spike was observed during the lifetime of this Redis instance, " +
+			"not in the slightest bit. I honestly think you ought to sleep tonight.\n"
+	}
+
+	names := make([]string, 0, len(l.events))
+
+	for name := range l.events {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var str strings.Builder
+	str.WriteString("Dave, I have observed latency spikes in this Redis instance. " +
+		"You don't mind talking about it, do you Dave?\n\n")
+
+	advices := make(map[string]struct{}, 0)
+
+	for i, name := range names {
+		e := l.events[name]
+		samples := e.history()
+
+		var sum time.Duration
+
+		for _, s := range samples {
+			sum += s.Latency
+		}
+
+		avg := sum / time.Duration(len(samples))
+
+		var deviation time.Duration
+
+		for _, s := range samples {
+			d := s.Latency - avg
+
+			if d < 0 {
+				d = -d
+			}
+
+			deviation += d
+		}
+
+		deviation /= time.Duration(len(samples))
+
+		period := 0.0
+
+		if len(samples) > 1 {
+			period = samples[len(samples)-1].Time.Sub(samples[0].Time).Seconds() / float64(len(samples)-1)
+		}
+
+		str.WriteString(fmt.Sprintf("%d. %s: %d latency spikes (average %dms, mean deviation %dms, period %.2f sec). "+
+			"Worst all time event %dms.\n",
+			i+1, name, len(samples), avg.Milliseconds(), deviation.Milliseconds(), period, e.max.Milliseconds()))
+
+		switch name {
+		case LATENCY_EVENT_COMMAND, LATENCY_EVENT_FAST_COMMAND:
+			advices["slowlog"] = struct{}{}
+
+			if name == LATENCY_EVENT_FAST_COMMAND {
+				advices["fast-command"] = struct{}{}
+			}
+		case LATENCY_EVENT_EXPIRE_CYCLE:
+			advices["expire-cycle"] = struct{}{}
+		case LATENCY_EVENT_EVICTION_CYCLE:
+			advices["eviction-cycle"] = struct{}{}
+		}
+	}
+
+	str.WriteString("\nI have a few advices for you:\n\n")
+
+	if _, ok := advices["slowlog"]; ok {
+		str.WriteString("- Check your Slow Log to understand what are the commands you are running " +
+			"which are too slow to execute. Please check https://redis.io/commands/slowlog for more information.\n")
+	}
+
+	if _, ok := advices["fast-command"]; ok {
+		str.WriteString("- The system is slow to execute Redis code paths not containing system calls. " +
+			"This usually means the system does not provide Redis CPU time to run for long periods. " +
+			"You should try to: 1) Lower the system load. 2) Check the GOMAXPROCS setting of the process.\n")
+	}
+
+	if _, ok := advices["expire-cycle"]; ok {
+		str.WriteString("- Deleting the expired keys locks the databases while they are scanned. " +
+			"Many keys are set to expire at the same time, try to spread their expiries.\n")
+	}
+
+	if _, ok := advices["eviction-cycle"]; ok {
+		str.WriteString("- Evicting the keys locks the databases while they are sampled. " +
+			"Try to lower maxmemory-samples or to raise maxmemory.\n")
+	}
+
+	return str.String()
+}
+
+// LatencyHistogram returns the number of calls of the command and the cumulative
+// number of calls whose latency is within each power of two microseconds.
+func (s *Stats) LatencyHistogram(name string) (uint64, [][2]uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cs, exists := s.commands[name]
+
+	if !exists || cs.Calls == 0 {
+		return 0, nil, false
+	}
+
+	return cs.Calls, cs.latency.cumulativePowersOfTwo(), true
+}
+
+// cumulativePowersOfTwo groups the latencies in buckets of powers of two
+// microseconds, each with the number of latencies up to it.
+func (h *histogram) cumulativePowersOfTwo() [][2]uint64 {
+	counts := make(map[uint64]uint64, 0)
+
+	for b, count := range h.counts {
+		usec := (histogramUpperBound(b) + 999) / 1000
+		bucket := uint64(1)
+
+		for bucket < usec {
+			bucket <<= 1
+		}
+
+		counts[bucket] += count
+	}
+
+	buckets := make([]uint64, 0, len(counts))
+
+	for b := range counts {
+		buckets = append(buckets, b)
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i] < buckets[j]
+	})
+
+	res := make([][2]uint64, 0, len(buckets))
+
+	var total uint64
+
+	for _, b := range buckets {
+		total += counts[b]
+		res = append(res, [2]uint64{b, total})
+	}
+
+	return res
+}
@@ -0,0 +1,588 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// The names of the chunks of the scripts and of the libraries of functions,
+// as they appear in their errors.
+const (
+	luaScriptChunk   = "user_script"
+	luaFunctionChunk = "user_function"
+)
+
+// The error raised on the writes to the libraries and the global variables.
+const luaReadonlyErr = "Attempt to modify a readonly table"
+
+var (
+	errLuaReply = errors.New("ERR Protocol error in the reply of the command")
+	// The location prefixed to the errors raised by the scripts
+	luaErrorLineRe = regexp.MustCompile(`^(` + luaScriptChunk + `|` + luaFunctionChunk + `):(\d+):`)
+)
+
+// luaState is an interpreter that runs the scripts.
+type luaState struct {
+	L       *lua.LState
+	globals *lua.LTable // The global variables, which _G only gives access to
+	redis   *Redis
+	run     *scriptRun // The script being run, nil if none
+	loading *library   // The library being loaded by FUNCTION LOAD, nil if none
+}
+
+// newLuaState creates an interpreter with the libraries available to the scripts.
+func (r *Redis) newLuaState() *luaState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	ls := &luaState{L: L, redis: r}
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	// The scripts can neither access the files nor load modules
+	for _, name := range []string{"dofile", "loadfile", "module", "require", "_printregs"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	lib := L.NewTable()
+	L.SetFuncs(lib, map[string]lua.LGFunction{
+		"call": func(L *lua.LState) int {
+			return ls.call(L, true)
+		},
+		"pcall": func(L *lua.LState) int {
+			return ls.call(L, false)
+		},
+		"error_reply": func(L *lua.LState) int {
+			L.Push(luaErrorTable(L, L.CheckString(1)))
+			return 1
+		},
+		"status_reply": func(L *lua.LState) int {
+			t := L.NewTable()
+			t.RawSetString("ok", lua.LString(L.CheckString(1)))
+			L.Push(t)
+			return 1
+		},
+		"sha1hex": func(L *lua.LState) int {
+			L.Push(lua.LString(ScriptSha(L.CheckString(1))))
+			return 1
+		},
+		"log": func(L *lua.LState) int {
+			L.CheckInt(1)
+			msgs := make([]string, 0, L.GetTop()-1)
+
+			for i := 2; i <= L.GetTop(); i++ {
+				msgs = append(msgs, L.Get(i).String())
+			}
+
+			r.logger.Println(strings.Join(msgs, " "))
+			return 0
+		},
+		"setresp": func(L *lua.LState) int {
+			resp := L.CheckInt(1)
+
+			if ls.run == nil {
+				L.RaiseError("redis.setresp can only be called inside a script invocation")
+			}
+
+			if resp != 2 && resp != 3 {
+				L.RaiseError("RESP version must be 2 or 3.")
+			}
+
+			ls.run.resp = resp
+			return 0
+		},
+		// The commands are not replicated, these only exist for compatibility
+		"set_repl": func(L *lua.LState) int {
+			L.CheckInt(1)
+			return 0
+		},
+		"replicate_commands": func(L *lua.LState) int {
+			L.Push(lua.LTrue)
+			return 1
+		},
+		"register_function": func(L *lua.LState) int {
+			return ls.registerFunction(L)
+		},
+	})
+
+	for name, value := range map[string]int{
+		"LOG_DEBUG":    0,
+		"LOG_VERBOSE":  1,
+		"LOG_NOTICE":   2,
+		"LOG_WARNING":  3,
+		"REPL_NONE":    0,
+		"REPL_AOF":     1,
+		"REPL_SLAVE":   2,
+		"REPL_REPLICA": 2,
+		"REPL_ALL":     3,
+	} {
+		lib.RawSetString(name, lua.LNumber(value))
+	}
+
+	lib.RawSetString("REDIS_VERSION", lua.LString(Version))
+	lib.RawSetString("REDIS_VERSION_NUM", lua.LNumber(versionNum(Version)))
+	L.SetGlobal("redis", lib)
+
+	ls.protect()
+	return ls
+}
+
+// protect makes the libraries and the global variables read-only since the
+// interpreters are shared by the scripts of all the users, as in Redis 7.
+// The libraries are replaced with proxies and the global variables are moved
+// behind _G, whose metatable can not be changed.
+func (ls *luaState) protect() {
+	L := ls.L
+	readonly := make(map[*lua.LTable]struct{}, 0)
+
+	for _, name := range []string{"redis", lua.StringLibName, lua.MathLibName, lua.TabLibName} {
+		proxy := luaReadonlyTable(L, L.GetGlobal(name).(*lua.LTable))
+		readonly[proxy] = struct{}{}
+		L.SetGlobal(name, proxy)
+	}
+
+	// The methods of the strings are the real string library
+	if mt, ok := L.GetMetatable(lua.LString("")).(*lua.LTable); ok {
+		mt.RawSetString("__metatable", lua.LFalse)
+	}
+
+	// rawset does not go through the metatables
+	rawset := L.GetGlobal("rawset").(*lua.LFunction)
+	L.SetGlobal("rawset", L.NewFunction(func(L *lua.LState) int {
+		if _, ok := readonly[L.CheckTable(1)]; ok {
+			L.RaiseError(luaReadonlyErr)
+		}
+
+		return rawset.GFunction(L)
+	}))
+
+	// Nor can the global variables be replaced for the whole interpreter
+	setfenv := L.GetGlobal("setfenv").(*lua.LFunction)
+	L.SetGlobal("setfenv", L.NewFunction(func(L *lua.LState) int {
+		if n, ok := L.Get(1).(lua.LNumber); ok && n <= 0 {
+			L.RaiseError(luaReadonlyErr)
+		}
+
+		return setfenv.GFunction(L)
+	}))
+
+	ls.globals = L.NewTable()
+	names := make([]lua.LValue, 0)
+
+	L.G.Global.ForEach(func(k lua.LValue, v lua.LValue) {
+		ls.globals.RawSet(k, v)
+		names = append(names, k)
+	})
+
+	for _, k := range names {
+		L.G.Global.RawSet(k, lua.LNil)
+	}
+
+	missing := L.NewTable()
+	missing.RawSetString("__index", L.NewFunction(func(L *lua.LState) int {
+		L.RaiseError("Script attempted to access nonexistent global variable '%s'", L.CheckAny(2).String())
+		return 0
+	}))
+	L.SetMetatable(ls.globals, missing)
+
+	// The scripts can not use global variables, which would leak to the other scripts
+	mt := L.NewTable()
+	mt.RawSetString("__index", ls.globals)
+	mt.RawSetString("__newindex", L.NewFunction(func(L *lua.LState) int {
+		name := L.CheckAny(2)
+
+		if ls.globals.RawGet(name) != lua.LNil {
+			L.RaiseError(luaReadonlyErr)
+		}
+
+		L.RaiseError("Script attempted to create global variable '%s'", name.String())
+		return 0
+	}))
+	mt.RawSetString("__metatable", lua.LFalse)
+	L.SetMetatable(L.G.Global, mt)
+	readonly[L.G.Global] = struct{}{}
+}
+
+// luaReadonlyTable returns a proxy of the table that can not be modified.
+func luaReadonlyTable(L *lua.LState, t *lua.LTable) *lua.LTable {
+	mt := L.NewTable()
+	mt.RawSetString("__index", t)
+	mt.RawSetString("__newindex", L.NewFunction(func(L *lua.LState) int {
+		L.RaiseError(luaReadonlyErr)
+		return 0
+	}))
+	mt.RawSetString("__metatable", lua.LFalse)
+
+	proxy := L.NewTable()
+	L.SetMetatable(proxy, mt)
+	return proxy
+}
+
+// versionNum returns the version as a number, e.g. 0x070000 for 7.0.0.
+func versionNum(version string) int {
+	n := 0
+
+	for _, part := range strings.SplitN(version, ".", 3) {
+		v, _ := strconv.Atoi(part)
+		n = n<<8 | v
+	}
+
+	return n
+}
+
+// setArguments sets the global tables KEYS and ARGV of the script.
+func (ls *luaState) setArguments(keys []string, args []string) {
+	ls.globals.RawSetString("KEYS", luaStringTable(ls.L, keys))
+	ls.globals.RawSetString("ARGV", luaStringTable(ls.L, args))
+}
+
+func luaStringTable(L *lua.LState, values []string) *lua.LTable {
+	t := L.CreateTable(len(values), 0)
+
+	for _, v := range values {
+		t.Append(lua.LString(v))
+	}
+
+	return t
+}
+
+// call is redis.call if raise is set, redis.pcall otherwise.
+// redis.call raises the errors of the commands while redis.pcall returns them.
+func (ls *luaState) call(L *lua.LState, raise bool) int {
+	argc := L.GetTop()
+
+	// The libraries can only register their functions while they are loaded
+	if ls.run == nil {
+		L.RaiseError("redis.call and redis.pcall can only be called inside a script invocation")
+	}
+
+	if argc == 0 {
+		return ls.callError(L, "ERR Please specify at least one argument for this redis lib call", raise)
+	}
+
+	args := make([][]byte, 0, argc)
+
+	for i := 1; i <= argc; i++ {
+		switch v := L.Get(i).(type) {
+		case lua.LString, lua.LNumber:
+			args = append(args, []byte(v.String()))
+		default:
+			return ls.callError(L, "ERR Lua redis lib command arguments must be strings or integers", raise)
+		}
+	}
+
+	reply := ls.redis.scriptCommand(ls.run, args)
+	value, _, err := luaValueFromReply(L, reply)
+
+	if err != nil {
+		return ls.callError(L, err.Error(), raise)
+	}
+
+	if t, ok := value.(*lua.LTable); ok && raise {
+		if _, ok := t.RawGetString("err").(lua.LString); ok {
+			ls.raise(L, t)
+		}
+	}
+
+	L.Push(value)
+	return 1
+}
+
+func (ls *luaState) callError(L *lua.LState, msg string, raise bool) int {
+	t := luaErrorTable(L, msg)
+
+	if raise {
+		ls.raise(L, t)
+	}
+
+	L.Push(t)
+	return 1
+}
+
+// raise raises the error table with the location of the caller.
+func (ls *luaState) raise(L *lua.LState, t *lua.LTable) {
+	if m := luaErrorLineRe.FindStringSubmatch(L.Where(1)); m != nil {
+		t.RawSetString("source", lua.LString("@"+m[1]))
+		t.RawSetString("line", lua.LString(m[2]))
+	}
+
+	L.Error(t, 1)
+}
+
+func luaErrorTable(L *lua.LState, msg string) *lua.LTable {
+	t := L.NewTable()
+	t.RawSetString("err", lua.LString(msg))
+	return t
+}
+
+// scriptErrorMessage returns the error reply of a script that has failed.
+// The name is the SHA1 digest of the script or the name of the function.
+func scriptErrorMessage(err error, name string) string {
+	apiErr, ok := err.(*lua.ApiError)
+
+	if !ok {
+		return "ERR " + err.Error()
+	}
+
+	switch obj := apiErr.Object.(type) {
+	case *lua.LTable:
+		msg, ok := obj.RawGetString("err").(lua.LString)
+
+		if !ok {
+			return "ERR Error running script " + name
+		}
+
+		source, hasSource := obj.RawGetString("source").(lua.LString)
+		line, hasLine := obj.RawGetString("line").(lua.LString)
+
+		if hasSource && hasLine {
+			return fmt.Sprintf("%s script: %s, on %s:%s.", msg, name, source, line)
+		}
+
+		return string(msg)
+	default:
+		msg := obj.String()
+
+		if m := luaErrorLineRe.FindStringSubmatch(msg); m != nil {
+			return fmt.Sprintf("ERR %s script: %s, on @%s:%s.", msg, name, m[1], m[2])
+		}
+
+		return "ERR " + msg
+	}
+}
+
+// luaValueFromReply converts the reply of a command to a Lua value.
+// It returns the rest of the data that follows the reply.
+func luaValueFromReply(L *lua.LState, data []byte) (lua.LValue, []byte, error) {
+	end := bytes.Index(data, []byte("\r\n"))
+
+	if len(data) == 0 || end < 0 {
+		return nil, nil, errLuaReply
+	}
+
+	line, rest := string(data[1:end]), data[end+2:]
+
+	switch data[0] {
+	case '+':
+		t := L.NewTable()
+		t.RawSetString("ok", lua.LString(line))
+		return t, rest, nil
+	case '-':
+		return luaErrorTable(L, line), rest, nil
+	case ':':
+		n, err := strconv.ParseInt(line, 10, 64)
+
+		if err != nil {
+			return nil, nil, errLuaReply
+		}
+
+		return lua.LNumber(n), rest, nil
+	case ',':
+		f, err := strconv.ParseFloat(line, 64)
+
+		if err != nil {
+			return nil, nil, errLuaReply
+		}
+
+		t := L.NewTable()
+		t.RawSetString("double", lua.LNumber(f))
+		return t, rest, nil
+	case '#':
+		return lua.LBool(line == "t"), rest, nil
+	case '_':
+		return lua.LNil, rest, nil
+	case '$':
+		n, err := strconv.Atoi(line)
+
+		if err != nil {
+			return nil, nil, errLuaReply
+		}
+
+		// The null bulk string of RESP2
+		if n < 0 {
+			return lua.LFalse, rest, nil
+		}
+
+		if len(rest) < n+2 {
+			return nil, nil, errLuaReply
+		}
+
+		return lua.LString(rest[:n]), rest[n+2:], nil
+	case '*', '>', '~', '%':
+		n, err := strconv.Atoi(line)
+
+		if err != nil {
+			return nil, nil, errLuaReply
+		}
+
+		// The null array of RESP2
+		if n < 0 {
+			return lua.LFalse, rest, nil
+		}
+
+		if data[0] == '%' {
+			n *= 2
+		}
+
+		values := make([]lua.LValue, 0, n)
+
+		for i := 0; i < n; i++ {
+			var value lua.LValue
+			value, rest, err = luaValueFromReply(L, rest)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		t := L.CreateTable(len(values), 0)
+
+		switch data[0] {
+		case '~':
+			set := L.NewTable()
+
+			for _, v := range values {
+				set.RawSet(v, lua.LTrue)
+			}
+
+			t.RawSetString("set", set)
+		case '%':
+			m := L.NewTable()
+
+			for i := 0; i < len(values); i += 2 {
+				m.RawSet(values[i], values[i+1])
+			}
+
+			t.RawSetString("map", m)
+		default:
+			for i, v := range values {
+				t.RawSetInt(i+1, v)
+			}
+		}
+
+		return t, rest, nil
+	}
+
+	return nil, nil, errLuaReply
+}
+
+// writeLuaReply replies to the client with the value returned by a script.
+func writeLuaReply(c *Client, run *scriptRun, value lua.LValue) {
+	switch v := value.(type) {
+	case lua.LString:
+		c.Conn().WriteBulkString(string(v))
+	case lua.LNumber:
+		c.Conn().WriteInt64(int64(v))
+	case lua.LBool:
+		if run.resp == 3 && c.R3 {
+			c.Conn().WriteBool(bool(v))
+		} else if v {
+			c.Conn().WriteInt(1)
+		} else {
+			writeLuaNull(c)
+		}
+	case *lua.LTable:
+		writeLuaTable(c, run, v)
+	default:
+		writeLuaNull(c)
+	}
+}
+
+func writeLuaNull(c *Client) {
+	if c.R3 {
+		c.Conn().WriteNull()
+	} else {
+		c.Conn().WriteNullBulk()
+	}
+}
+
+// writeLuaTable replies with a table, which is either an error, a status,
+// a RESP3 type or an array.
+func writeLuaTable(c *Client, run *scriptRun, t *lua.LTable) {
+	if msg, ok := t.RawGetString("err").(lua.LString); ok {
+		c.Conn().WriteError(string(msg))
+		return
+	}
+
+	if msg, ok := t.RawGetString("ok").(lua.LString); ok {
+		c.Conn().WriteString(string(msg))
+		return
+	}
+
+	if f, ok := t.RawGetString("double").(lua.LNumber); ok {
+		if c.R3 {
+			c.Conn().WriteFloat64(float64(f))
+		} else {
+			c.Conn().WriteBulkString(strconv.FormatFloat(float64(f), 'g', 17, 64))
+		}
+		return
+	}
+
+	if m, ok := t.RawGetString("map").(*lua.LTable); ok {
+		keys := make([]lua.LValue, 0)
+		m.ForEach(func(k lua.LValue, _ lua.LValue) {
+			keys = append(keys, k)
+		})
+
+		if c.R3 {
+			c.Conn().WriteMap(len(keys) * 2)
+		} else {
+			c.Conn().WriteArray(len(keys) * 2)
+		}
+
+		for _, k := range keys {
+			writeLuaReply(c, run, k)
+			writeLuaReply(c, run, m.RawGet(k))
+		}
+		return
+	}
+
+	if set, ok := t.RawGetString("set").(*lua.LTable); ok {
+		keys := make([]lua.LValue, 0)
+		set.ForEach(func(k lua.LValue, _ lua.LValue) {
+			keys = append(keys, k)
+		})
+
+		if c.R3 {
+			c.Conn().WriteSet(len(keys))
+		} else {
+			c.Conn().WriteArray(len(keys))
+		}
+
+		for _, k := range keys {
+			writeLuaReply(c, run, k)
+		}
+		return
+	}
+
+	// The array stops at the first nil, as the length operator of Lua does
+	n := 0
+
+	for t.RawGetInt(n+1) != lua.LNil {
+		n++
+	}
+
+	c.Conn().WriteArray(n)
+
+	for i := 1; i <= n; i++ {
+		writeLuaReply(c, run, t.RawGetInt(i))
+	}
+}
@@ -0,0 +1,225 @@
+package pkg
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+)
+
+const (
+	// Bytes of the entry of a key in the storage: the string header of the
+	// key and the interface of the item.
+	storageEntrySize = 32
+	// Bytes of the entry of a key in the ttls: the string header of the key
+	// and its time.Time.
+	ttlEntrySize = 40
+	// Below this memory MEMORY DOCTOR has nothing to say.
+	doctorMinMemory = 5 << 20
+)
+
+// readMemStats reads the statistics of the runtime and records the peak of
+// the allocated memory.
+func (r *Redis) readMemStats() *runtime.MemStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	for {
+		peak := r.stats.peakAllocated.Load()
+
+		if m.HeapAlloc <= peak || r.stats.peakAllocated.CompareAndSwap(peak, m.HeapAlloc) {
+			break
+		}
+	}
+
+	return &m
+}
+
+// MemoryUsage returns the approximate number of bytes used by a key and its
+// value, estimated from that many samples of the elements of aggregate items,
+// or all of them if samples is 0. It does not touch the key.
+func (db *Db) MemoryUsage(key string, samples int) (int64, bool) {
+	item, exists := db.Storage[key]
+
+	if !exists || db.DeleteExpired(key) > 0 {
+		return 0, false
+	}
+
+	return int64(keyOverhead+len(key)) + db.redis.ItemMemoryUsage(item, samples), true
+}
+
+// DbOverhead is the memory used by the hashtables of a database.
+type DbOverhead struct {
+	Id      uint64
+	Main    int64 // Entries of the keys
+	Expires int64 // Entries of the expiry times
+	Keys    int
+}
+
+// MemoryStats is the breakdown of the memory reported by MEMORY STATS.
+type MemoryStats struct {
+	PeakAllocated    uint64
+	TotalAllocated   uint64
+	StartupAllocated uint64
+	ClientsNormal    int64 // Query and output buffers of the clients
+	ClientsReplicas  int64
+	LuaCaches        int64 // The bodies of the cached scripts
+	FunctionsCaches  int64 // The code of the libraries of functions
+	Dbs              []DbOverhead
+	OverheadTotal    int64
+	KeysCount        int
+	Dataset          int64 // Memory of the keys besides the overhead of the hashtables
+	Resident         uint64
+	normalClients    int
+}
+
+// BytesPerKey returns the average memory used by a key.
+func (s *MemoryStats) BytesPerKey() int64 {
+	if s.KeysCount == 0 {
+		return 0
+	}
+
+	return (int64(s.TotalAllocated) - int64(s.StartupAllocated)) / int64(s.KeysCount)
+}
+
+// DatasetPercentage returns the percentage of the memory used by the dataset.
+func (s *MemoryStats) DatasetPercentage() float64 {
+	net := int64(s.TotalAllocated) - int64(s.StartupAllocated)
+
+	if net <= 0 {
+		return 0
+	}
+
+	return float64(s.Dataset) * 100 / float64(net)
+}
+
+// PeakPercentage returns the percentage of the peak memory that is used.
+func (s *MemoryStats) PeakPercentage() float64 {
+	if s.PeakAllocated == 0 {
+		return 0
+	}
+
+	return float64(s.TotalAllocated) * 100 / float64(s.PeakAllocated)
+}
+
+// Fragmentation returns the ratio of the resident memory to the allocated one.
+func (s *MemoryStats) Fragmentation() float64 {
+	if s.TotalAllocated == 0 {
+		return 0
+	}
+
+	return float64(s.Resident) / float64(s.TotalAllocated)
+}
+
+// GetMemoryStats returns the breakdown of the memory used by the server.
+func (r *Redis) GetMemoryStats() *MemoryStats {
+	m := r.readMemStats()
+	s := &MemoryStats{
+		PeakAllocated:    r.stats.peakAllocated.Load(),
+		TotalAllocated:   m.HeapAlloc,
+		StartupAllocated: r.stats.startupAllocated,
+		Resident:         m.HeapSys - m.HeapReleased + m.StackSys,
+	}
+
+	r.cliLock.Lock()
+
+	for _, c := range r.clients {
+		s.ClientsNormal += c.qbuf.Load() + c.pending.Load()
+		s.normalClients++
+	}
+
+	r.cliLock.Unlock()
+
+	dbs := make([]*Db, 0, len(r.dbs))
+
+	for _, db := range r.RedisDbs() {
+		dbs = append(dbs, db)
+	}
+
+	sort.Slice(dbs, func(i, j int) bool {
+		return dbs[i].id < dbs[j].id
+	})
+
+	var used int64
+
+	// The databases are not locked since the caller holds the lock of its own,
+	// every key has an entry in both hashtables
+	for _, db := range dbs {
+		keys := db.keys.Load()
+		overhead := DbOverhead{
+			Id:      db.id,
+			Main:    keys * storageEntrySize,
+			Expires: keys * ttlEntrySize,
+			Keys:    int(keys),
+		}
+
+		used += db.used.Load()
+
+		if overhead.Keys == 0 {
+			continue
+		}
+
+		s.Dbs = append(s.Dbs, overhead)
+		s.KeysCount += overhead.Keys
+		s.OverheadTotal += overhead.Main + overhead.Expires
+	}
+
+	s.Dataset = used - s.OverheadTotal
+
+	if s.Dataset < 0 {
+		s.Dataset = 0
+	}
+
+	_, s.LuaCaches = r.ScriptCacheStats()
+	_, _, s.FunctionsCaches = r.FunctionCacheStats()
+	s.OverheadTotal += int64(s.StartupAllocated) + s.ClientsNormal + s.ClientsReplicas + s.LuaCaches + s.FunctionsCaches
+
+	return s
+}
+
+// MemoryDoctor returns the report of MEMORY DOCTOR about the memory issues.
+func (r *Redis) MemoryDoctor() string {
+	s := r.GetMemoryStats()
+
+	if s.TotalAllocated < doctorMinMemory {
+		return "Hi Sam, this instance is empty or is using very little memory, my issues " +
+			"detector can't be used in these conditions. Please, leave for your mission " +
+			"on Earth and fill it with some data. The new Sam and I will be back to our " +
+			"programming as soon as I finished rebooting."
+	}
+
+	report := ""
+
+	if float64(s.PeakAllocated) > float64(s.TotalAllocated)*1.5 {
+		report += " * Peak memory: In the past this instance used more than 150% the memory " +
+			"that is currently using. The runtime is normally not able to release memory " +
+			"right after a peak, so you can expect to see a big fragmentation ratio, however " +
+			"this is actually harmless and is only due to the memory peak. If the memory " +
+			"peak was only occasional and you want to try to reclaim memory, please try " +
+			"the MEMORY PURGE command.\n\n"
+	}
+
+	if s.Fragmentation() > 1.4 {
+		report += fmt.Sprintf(" * High fragmentation: This instance has a memory "+
+			"fragmentation greater than 1.4 (this means that the memory held by the "+
+			"process is much larger than the sum of the logical allocations it "+
+			"performed). This problem is usually due either to a large peak memory "+
+			"(check if there is a peak memory entry above in the report) or may result "+
+			"from a workload that causes the allocator to fragment memory a lot. Note: "+
+			"The currently used allocator is \"%s\".\n\n", "go")
+	}
+
+	if s.normalClients > 0 && s.ClientsNormal/int64(s.normalClients) > 200*1024 {
+		report += fmt.Sprintf(" * Big client buffers: The clients input and output buffers "+
+			"are using on average a large amount of memory (%s per client). Use CLIENT "+
+			"LIST in order to investigate the issue. This is usually caused by slow "+
+			"clients or by big pipelines.\n\n", humanBytes(uint64(s.ClientsNormal/int64(s.normalClients))))
+	}
+
+	if report == "" {
+		return "Hi Sam, I can't find any memory issue in your instance. I can only account " +
+			"for what occurs on this base."
+	}
+
+	return "Sam, I detected a few issues in this Redis instance memory implants:\n\n" +
+		report + "I'm here to keep you safe, Sam. I want to help you.\n"
+}
@@ -0,0 +1,370 @@
+package pkg
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hbina/radish/internal/util"
+)
+
+const (
+	// Maximum number of connections kept open by MIGRATE
+	MIGRATE_SOCKET_CACHE_ITEMS = 64
+	// Idle time after which the connections of MIGRATE are closed
+	MIGRATE_SOCKET_CACHE_TTL = 10 * time.Second
+	// Timeout of MIGRATE when it is not positive
+	MIGRATE_DEFAULT_TIMEOUT = 1000 * time.Millisecond
+)
+
+var (
+	ErrMigrateNoKey   = errors.New("NOKEY")
+	errMigrateConnect = errors.New("IOERR error or timeout connecting to the client")
+	errMigrateWrite   = errors.New("IOERR error or timeout writing to target instance")
+	errMigrateRead    = errors.New("IOERR error or timeout reading to target instance")
+)
+
+// migrateSocket is a connection to another instance used by MIGRATE.
+type migrateSocket struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	lastDbId int64     // The database selected on the connection, -1 if unknown
+	lastUse  time.Time // When the connection was last used
+	inUse    bool      // Whether a MIGRATE is using the connection
+}
+
+// MigrateCache holds the connections of MIGRATE by the address of the instance.
+type MigrateCache struct {
+	mu      *sync.Mutex
+	sockets map[string]*migrateSocket
+}
+
+func newMigrateCache() *MigrateCache {
+	return &MigrateCache{
+		mu:      new(sync.Mutex),
+		sockets: make(map[string]*migrateSocket, 0),
+	}
+}
+
+// MigrateOptions are the arguments of MIGRATE.
+type MigrateOptions struct {
+	Host    string
+	Port    string
+	DbId    int64
+	Timeout time.Duration
+	Copy    bool     // Do not delete the keys from the source
+	Replace bool     // Replace the existing keys of the target
+	Auth    []string // The arguments of AUTH sent to the target, if any
+	Keys    []string
+}
+
+// Migrate moves the keys of the database to another instance by restoring
+// their DUMP payloads there. The keys that the target has restored are
+// deleted unless the option Copy is set.
+// ErrMigrateNoKey is returned if none of the keys exist.
+func (r *Redis) Migrate(db *Db, opts MigrateOptions) error {
+	if opts.Timeout <= 0 {
+		opts.Timeout = MIGRATE_DEFAULT_TIMEOUT
+	}
+
+	keys := make([]string, 0, len(opts.Keys))
+	items := make([]string, 0, len(opts.Keys))
+	ttls := make([]time.Time, 0, len(opts.Keys))
+
+	for _, key := range opts.Keys {
+		item, ttl := db.Get(key)
+
+		if item == nil {
+			continue
+		}
+
+		payload, err := r.DumpPayload(key, item)
+
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, key)
+		items = append(items, string(payload))
+		ttls = append(ttls, ttl)
+	}
+
+	if len(keys) == 0 {
+		return ErrMigrateNoKey
+	}
+
+	addr := net.JoinHostPort(opts.Host, opts.Port)
+	mayRetry := true
+
+	for {
+		cs, err := r.migrateSocket(addr, opts.Timeout)
+
+		if err != nil {
+			return errMigrateConnect
+		}
+
+		var cmds strings.Builder
+
+		if len(opts.Auth) > 0 {
+			cmds.WriteString(util.ConvertCommandArgToResp(append([]string{"AUTH"}, opts.Auth...)))
+		}
+
+		selectDb := cs.lastDbId != opts.DbId
+
+		if selectDb {
+			cmds.WriteString(util.ConvertCommandArgToResp([]string{"SELECT", strconv.FormatInt(opts.DbId, 10)}))
+		}
+
+		now := r.Now()
+
+		for i, key := range keys {
+			ttl := int64(0)
+
+			if !ttls[i].IsZero() {
+				ttl = ttls[i].Sub(now).Milliseconds()
+
+				// The key expires before it is restored, the target will delete it
+				if ttl < 1 {
+					ttl = 1
+				}
+			}
+
+			args := []string{"RESTORE", key, strconv.FormatInt(ttl, 10), items[i]}
+
+			if opts.Replace {
+				args = append(args, "REPLACE")
+			}
+
+			cmds.WriteString(util.ConvertCommandArgToResp(args))
+		}
+
+		cs.conn.SetDeadline(time.Now().Add(opts.Timeout))
+		_, err = cs.conn.Write([]byte(cmds.String()))
+
+		if err != nil {
+			r.closeMigrateSocket(addr, cs)
+
+			if mayRetry && !isTimeout(err) {
+				mayRetry = false
+				continue
+			}
+
+			return errMigrateWrite
+		}
+
+		// The replies are read in the order of the commands
+		first := 0
+
+		if len(opts.Auth) > 0 {
+			first++
+		}
+
+		if selectDb {
+			first++
+		}
+
+		var targetErr string
+		// Whether AUTH or SELECT has failed, in which case no key is restored
+		failed := false
+
+		for i := 0; i < first+len(keys); i++ {
+			var reply string
+			reply, err = readMigrateReply(cs.reader)
+
+			if err != nil {
+				break
+			}
+
+			if reply[0] == '-' {
+				if i < first {
+					failed = true
+				}
+
+				if selectDb && i == first-1 {
+					cs.lastDbId = -1
+				}
+
+				if targetErr == "" {
+					targetErr = reply[1:]
+				}
+			} else if i >= first && !failed && !opts.Copy {
+				db.Delete(keys[i-first])
+				mayRetry = false
+			}
+		}
+
+		if err != nil {
+			r.closeMigrateSocket(addr, cs)
+
+			if mayRetry && !isTimeout(err) {
+				mayRetry = false
+				continue
+			}
+
+			return errMigrateRead
+		}
+
+		if targetErr != "" {
+			r.releaseMigrateSocket(addr, cs)
+			return errors.New("ERR Target instance replied with error: " + targetErr)
+		}
+
+		cs.lastDbId = opts.DbId
+		r.releaseMigrateSocket(addr, cs)
+		return nil
+	}
+}
+
+// readMigrateReply reads a status or an error reply of the target.
+func readMigrateReply(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+
+	if err != nil {
+		return "", err
+	}
+
+	line = strings.TrimSuffix(line, "\r\n")
+
+	if len(line) == 0 {
+		return "", io.ErrUnexpectedEOF
+	}
+
+	return line, nil
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// migrateSocket returns a cached connection to the address, or connects to it.
+// The connection is reserved until it is released or closed.
+func (r *Redis) migrateSocket(addr string, timeout time.Duration) (*migrateSocket, error) {
+	mc := r.migrate
+	mc.mu.Lock()
+
+	if cs, exists := mc.sockets[addr]; exists && !cs.inUse {
+		cs.inUse = true
+		mc.mu.Unlock()
+		return cs, nil
+	}
+
+	mc.mu.Unlock()
+
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &migrateSocket{
+		conn:     conn,
+		reader:   bufio.NewReader(conn),
+		lastDbId: -1,
+		inUse:    true,
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	// The connection is only cached if no other MIGRATE is using one
+	// and there is room for it
+	if _, exists := mc.sockets[addr]; !exists {
+		// Make room by closing a connection that is not in use
+		if len(mc.sockets) >= MIGRATE_SOCKET_CACHE_ITEMS {
+			for a, other := range mc.sockets {
+				if !other.inUse {
+					other.conn.Close()
+					delete(mc.sockets, a)
+					break
+				}
+			}
+		}
+
+		if len(mc.sockets) < MIGRATE_SOCKET_CACHE_ITEMS {
+			mc.sockets[addr] = cs
+		}
+	}
+
+	return cs, nil
+}
+
+// releaseMigrateSocket gives back a connection once a MIGRATE is done with it.
+func (r *Redis) releaseMigrateSocket(addr string, cs *migrateSocket) {
+	mc := r.migrate
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.sockets[addr] != cs {
+		cs.conn.Close()
+		return
+	}
+
+	cs.inUse = false
+	cs.lastUse = r.Now()
+}
+
+// closeMigrateSocket closes a connection after an error.
+func (r *Redis) closeMigrateSocket(addr string, cs *migrateSocket) {
+	mc := r.migrate
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.sockets[addr] == cs {
+		delete(mc.sockets, addr)
+	}
+
+	cs.conn.Close()
+}
+
+// closeIdleMigrateSockets closes the connections that have not been used
+// for MIGRATE_SOCKET_CACHE_TTL, or all of them if all is set.
+func (r *Redis) closeIdleMigrateSockets(all bool) {
+	mc := r.migrate
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	now := r.Now()
+
+	for addr, cs := range mc.sockets {
+		if cs.inUse || (!all && now.Sub(cs.lastUse) <= MIGRATE_SOCKET_CACHE_TTL) {
+			continue
+		}
+
+		cs.conn.Close()
+		delete(mc.sockets, addr)
+	}
+}
+
+// MigrateCachedSockets returns the number of connections of MIGRATE.
+func (r *Redis) MigrateCachedSockets() int {
+	r.migrate.mu.Lock()
+	defer r.migrate.mu.Unlock()
+
+	return len(r.migrate.sockets)
+}
+
+// StartMigrateCacheJob closes the idle connections of MIGRATE until
+// the redis is stopped, at which point all of them are closed.
+func (r *Redis) StartMigrateCacheJob() {
+	f := func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.done:
+				r.closeIdleMigrateSockets(true)
+				return
+			case <-ticker.C:
+				r.closeIdleMigrateSockets(false)
+			}
+		}
+	}
+	go f()
+}
@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hbina/radish/internal/types"
+)
+
+// Module is a set of commands and types added by an embedder.
+type Module struct {
+	Name    string
+	Version int
+}
+
+// CustomType is a value type added by a module.
+// Items of the type must return types.ValueTypeModule from Type
+// and the name of the type from TypeFancy.
+type CustomType struct {
+	Name      string
+	Module    *Module
+	Marshal   func(item types.Item) ([]byte, error)
+	Unmarshal func(data []byte) (types.Item, error)
+	MemUsage  func(item types.Item) int // Optional, the approximate bytes used by the item
+}
+
+var builtinTypes = map[string]struct{}{
+	types.ValueTypeFancyList:   {},
+	types.ValueTypeFancyString: {},
+	types.ValueTypeFancySet:    {},
+	types.ValueTypeFancyZSet:   {},
+	"none":                     {},
+}
+
+// RegisterModule adds the commands and the types of the module.
+// Nothing is registered if any of them conflicts with the existing ones.
+func (r *Redis) RegisterModule(m *Module, cmds []*Command, customTypes []*CustomType) error {
+	for _, other := range r.modules {
+		if other.Name == m.Name {
+			return fmt.Errorf("module '%s' is already registered", m.Name)
+		}
+	}
+
+	names := make(map[string]struct{}, 0)
+
+	for _, cmd := range cmds {
+		_, exists := names[cmd.Name]
+		_, isCmd := r.cmds[cmd.Name]
+		_, isBcmd := r.bcmds[cmd.Name]
+
+		if exists || isCmd || isBcmd {
+			return fmt.Errorf("command '%s' of module '%s' is already registered", cmd.Name, m.Name)
+		}
+
+		names[cmd.Name] = struct{}{}
+	}
+
+	typeNames := make(map[string]struct{}, 0)
+
+	for _, t := range customTypes {
+		_, exists := typeNames[t.Name]
+		_, isBuiltin := builtinTypes[t.Name]
+		_, isCustom := r.ctypes[t.Name]
+
+		if exists || isBuiltin || isCustom {
+			return fmt.Errorf("type '%s' of module '%s' is already registered", t.Name, m.Name)
+		}
+
+		typeNames[t.Name] = struct{}{}
+	}
+
+	for _, cmd := range cmds {
+		cmd.Module = m.Name
+	}
+
+	r.RegisterCommands(cmds)
+
+	for _, t := range customTypes {
+		t.Module = m
+		r.ctypes[t.Name] = t
+	}
+
+	r.modules = append(r.modules, m)
+	return nil
+}
+
+// Modules returns the registered modules ordered by their name.
+func (r *Redis) Modules() []*Module {
+	res := make([]*Module, len(r.modules))
+	copy(res, r.modules)
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
+
+// CustomType returns the type registered by a module with the given name.
+func (r *Redis) CustomType(name string) (*CustomType, bool) {
+	t, exists := r.ctypes[name]
+	return t, exists
+}
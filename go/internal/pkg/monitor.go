@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hbina/radish/internal/util"
+)
+
+// AddMonitor puts the client in monitor mode, it will be sent every command
+// processed by the redis until it disconnects.
+func (r *Redis) AddMonitor(c *Client) {
+	r.cliLock.Lock()
+	defer r.cliLock.Unlock()
+
+	c.monitor = true
+	c.startPusher()
+	r.monitors[c] = struct{}{}
+}
+
+// Monitor returns whether the client is in monitor mode.
+func (c *Client) Monitor() bool {
+	return c.monitor
+}
+
+// feedMonitors sends the command to the clients in monitor mode.
+// The admin commands are not sent, as in Redis.
+func (r *Redis) feedMonitors(c *Client, info *CommandInfo, args [][]byte) {
+	if info.Flag&(CMD_SKIP_MONITOR|CMD_ADMIN) != 0 {
+		return
+	}
+
+	r.cliLock.Lock()
+	defer r.cliLock.Unlock()
+
+	if len(r.monitors) == 0 {
+		return
+	}
+
+	now := r.Now()
+	addr := c.Addr()
+
+	if c.isUnixSocket() {
+		addr = "unix:" + c.conn.LocalAddr().String()
+	}
+
+	var str strings.Builder
+	fmt.Fprintf(&str, "+%d.%06d [%d %s]", now.Unix(), now.Nanosecond()/1000, c.dbId, addr)
+
+	for i, arg := range args {
+		str.WriteByte(' ')
+
+		if redactedArg(info, args, i) {
+			str.WriteString(util.QuoteString("(redacted)"))
+		} else {
+			str.WriteString(util.QuoteString(string(arg)))
+		}
+	}
+
+	str.WriteString("\r\n")
+	msg := []byte(str.String())
+
+	for m := range r.monitors {
+		m.push(msg)
+	}
+}
@@ -0,0 +1,172 @@
+package pkg
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/hbina/radish/internal/types"
+)
+
+// Classes of the keyspace events, as set by notify-keyspace-events.
+const (
+	NOTIFY_KEYSPACE uint64 = 1 << iota // K
+	NOTIFY_KEYEVENT                    // E
+	NOTIFY_GENERIC                     // g
+	NOTIFY_STRING                      // $
+	NOTIFY_LIST                        // l
+	NOTIFY_SET                         // s
+	NOTIFY_HASH                        // h
+	NOTIFY_ZSET                        // z
+	NOTIFY_EXPIRED                     // x
+	NOTIFY_EVICTED                     // e
+	NOTIFY_STREAM                      // t
+	NOTIFY_KEY_MISS                    // m
+	NOTIFY_MODULE                      // d
+	NOTIFY_NEW                         // n
+
+	// A, all the classes except m and n
+	NOTIFY_ALL = NOTIFY_GENERIC | NOTIFY_STRING | NOTIFY_LIST | NOTIFY_SET | NOTIFY_HASH |
+		NOTIFY_ZSET | NOTIFY_EXPIRED | NOTIFY_EVICTED | NOTIFY_STREAM | NOTIFY_MODULE
+)
+
+// The characters of notify-keyspace-events.
+var notifyClasses = []struct {
+	char  byte
+	class uint64
+}{
+	{'g', NOTIFY_GENERIC},
+	{'$', NOTIFY_STRING},
+	{'l', NOTIFY_LIST},
+	{'s', NOTIFY_SET},
+	{'h', NOTIFY_HASH},
+	{'z', NOTIFY_ZSET},
+	{'x', NOTIFY_EXPIRED},
+	{'e', NOTIFY_EVICTED},
+	{'t', NOTIFY_STREAM},
+	{'m', NOTIFY_KEY_MISS},
+	{'d', NOTIFY_MODULE},
+	{'n', NOTIFY_NEW},
+	{'K', NOTIFY_KEYSPACE},
+	{'E', NOTIFY_KEYEVENT},
+}
+
+// KeyspaceEventsFromString parses the value of notify-keyspace-events.
+func KeyspaceEventsFromString(value string) (uint64, bool) {
+	var flags uint64
+
+	for i := 0; i < len(value); i++ {
+		if value[i] == 'A' {
+			flags |= NOTIFY_ALL
+			continue
+		}
+
+		found := false
+
+		for _, c := range notifyClasses {
+			if c.char == value[i] {
+				flags |= c.class
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return 0, false
+		}
+	}
+
+	return flags, true
+}
+
+// setNotifyKeyspaceEvents is the hook of notify-keyspace-events.
+func (r *Redis) setNotifyKeyspaceEvents(value string) error {
+	flags, ok := KeyspaceEventsFromString(value)
+
+	if !ok {
+		return errors.New("Invalid event class character. Use 'Ag$lshzxeKEtmdn'.")
+	}
+
+	atomic.StoreUint64(&r.notifyEvents, flags)
+	return nil
+}
+
+// NotifyKeyspaceEvent publishes the event on the keyspace and keyevent channels
+// if its class is enabled by notify-keyspace-events.
+func (r *Redis) NotifyKeyspaceEvent(class uint64, event string, key string, dbId uint64) {
+	flags := atomic.LoadUint64(&r.notifyEvents)
+
+	if flags&class == 0 {
+		return
+	}
+
+	db := strconv.FormatUint(dbId, 10)
+
+	if flags&NOTIFY_KEYSPACE != 0 {
+		r.Publish("__keyspace@"+db+"__:"+key, event)
+	}
+
+	if flags&NOTIFY_KEYEVENT != 0 {
+		r.Publish("__keyevent@"+db+"__:"+event, key)
+	}
+}
+
+// Events of the commands that are not named after the command, as in Redis.
+var notifyEventNames = map[string]string{
+	"incr":      "incrby",
+	"decr":      "decrby",
+	"setnx":     "set",
+	"setex":     "set",
+	"psetex":    "set",
+	"getset":    "set",
+	"mset":      "set",
+	"msetnx":    "set",
+	"lpushx":    "lpush",
+	"rpushx":    "rpush",
+	"blpop":     "lpop",
+	"brpop":     "rpop",
+	"bzpopmin":  "zpopmin",
+	"bzpopmax":  "zpopmax",
+	"zincrby":   "zincr",
+	"pexpire":   "expire",
+	"expireat":  "expire",
+	"pexpireat": "expire",
+	"bitop":     "set",
+	"bitfield":  "setbit",
+}
+
+// commandEvent returns the event of the keys modified by the command that
+// the caller is running.
+func commandEvent(caller *Client, def string) string {
+	if caller == nil {
+		return def
+	}
+
+	name, _ := caller.lastCmd.Load().(string)
+
+	if name == "" {
+		return def
+	}
+
+	if event, ok := notifyEventNames[name]; ok {
+		return event
+	}
+
+	return name
+}
+
+// itemNotifyClass returns the class of the events that modify an item.
+func itemNotifyClass(i types.Item) uint64 {
+	switch i.Type() {
+	case types.ValueTypeString:
+		return NOTIFY_STRING
+	case types.ValueTypeList:
+		return NOTIFY_LIST
+	case types.ValueTypeSet:
+		return NOTIFY_SET
+	case types.ValueTypeZSet:
+		return NOTIFY_ZSET
+	}
+
+	return NOTIFY_MODULE
+}
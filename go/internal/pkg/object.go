@@ -0,0 +1,254 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/hbina/radish/internal/types"
+)
+
+// Encodings of the items as reported by OBJECT ENCODING.
+// They are the representations that Redis would use for the same items.
+const (
+	OBJ_ENCODING_RAW       = "raw"
+	OBJ_ENCODING_INT       = "int"
+	OBJ_ENCODING_EMBSTR    = "embstr"
+	OBJ_ENCODING_HASHTABLE = "hashtable"
+	OBJ_ENCODING_INTSET    = "intset"
+	OBJ_ENCODING_LISTPACK  = "listpack"
+	OBJ_ENCODING_QUICKLIST = "quicklist"
+	OBJ_ENCODING_SKIPLIST  = "skiplist"
+)
+
+const (
+	// Longest string that is embedded in its object by Redis.
+	embstrSizeLimit = 44
+	// Strings holding integers in [0, sharedIntegers) are shared by Redis.
+	sharedIntegers = 10000
+	// Reference count reported for the shared objects.
+	sharedRefcount = 2147483647
+	// Bytes of the entries of a listpack besides their content.
+	listpackEntryOverhead = 2
+)
+
+// Limits holds the thresholds below which the items use a compact encoding.
+type Limits struct {
+	listMaxListpackSize    atomic.Int64
+	setMaxIntsetEntries    atomic.Int64
+	setMaxListpackEntries  atomic.Int64
+	setMaxListpackValue    atomic.Int64
+	zsetMaxListpackEntries atomic.Int64
+	zsetMaxListpackValue   atomic.Int64
+}
+
+func newLimits() *Limits {
+	l := &Limits{}
+	l.listMaxListpackSize.Store(-2)
+	l.setMaxIntsetEntries.Store(512)
+	l.setMaxListpackEntries.Store(128)
+	l.setMaxListpackValue.Store(64)
+	l.zsetMaxListpackEntries.Store(128)
+	l.zsetMaxListpackValue.Store(64)
+
+	return l
+}
+
+// limitConfigs returns the limit set by each configuration, the names of
+// the ziplist era are aliases of the listpack ones.
+func (l *Limits) limitConfigs() map[string]*atomic.Int64 {
+	return map[string]*atomic.Int64{
+		"list-max-listpack-size":    &l.listMaxListpackSize,
+		"list-max-ziplist-size":     &l.listMaxListpackSize,
+		"set-max-intset-entries":    &l.setMaxIntsetEntries,
+		"set-max-listpack-entries":  &l.setMaxListpackEntries,
+		"set-max-listpack-value":    &l.setMaxListpackValue,
+		"zset-max-listpack-entries": &l.zsetMaxListpackEntries,
+		"zset-max-ziplist-entries":  &l.zsetMaxListpackEntries,
+		"zset-max-listpack-value":   &l.zsetMaxListpackValue,
+		"zset-max-ziplist-value":    &l.zsetMaxListpackValue,
+	}
+}
+
+// setLimit is the hook of the configurations of the limits.
+func setLimit(limit *atomic.Int64, value string, min int64) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || n < min {
+		return fmt.Errorf("argument must be between %d and 9223372036854775807 inclusive", min)
+	}
+
+	limit.Store(n)
+	return nil
+}
+
+// listFitsListpack returns whether the list fits in a single listpack. A
+// positive list-max-listpack-size limits the entries, a negative one limits
+// the bytes to 4kb, 8kb, 16kb, 32kb or 64kb.
+func (l *Limits) listFitsListpack(list *types.List, fraction int) bool {
+	size := l.listMaxListpackSize.Load()
+
+	if size >= 0 {
+		return int64(list.Len()*fraction) <= size
+	}
+
+	maxBytes := 4096 << (-size - 1)
+	bytes := 0
+
+	// Every entry takes a few bytes so that long lists stop the loop early
+	list.ForEachF(func(a string) bool {
+		bytes += (len(a) + listpackEntryOverhead) * fraction
+		return bytes <= maxBytes
+	})
+
+	return bytes <= maxBytes
+}
+
+// itemEncoding returns the encoding of an item whose previous encoding was
+// prev, or "" if the key is new. The command is the one that modified it.
+// Like in Redis, sets and sorted sets are not converted back to a compact
+// encoding once they have outgrown it, while lists shrinking to half of
+// the limit are.
+func (r *Redis) itemEncoding(i types.Item, prev string, command string) string {
+	l := r.limits
+
+	switch item := i.(type) {
+	case *types.String:
+		// The strings modified in place are never embedded
+		if command == "append" || command == "setrange" || command == "setbit" {
+			return OBJ_ENCODING_RAW
+		}
+
+		if item.IsInt() {
+			return OBJ_ENCODING_INT
+		}
+
+		if item.Len() <= embstrSizeLimit {
+			return OBJ_ENCODING_EMBSTR
+		}
+
+		return OBJ_ENCODING_RAW
+	case *types.List:
+		if prev == OBJ_ENCODING_QUICKLIST {
+			if l.listFitsListpack(item, 2) {
+				return OBJ_ENCODING_LISTPACK
+			}
+
+			return OBJ_ENCODING_QUICKLIST
+		}
+
+		if l.listFitsListpack(item, 1) {
+			return OBJ_ENCODING_LISTPACK
+		}
+
+		return OBJ_ENCODING_QUICKLIST
+	case *types.Set:
+		if prev == OBJ_ENCODING_HASHTABLE {
+			return prev
+		}
+
+		intset := prev != OBJ_ENCODING_LISTPACK && int64(item.Len()) <= l.setMaxIntsetEntries.Load()
+		listpack := int64(item.Len()) <= l.setMaxListpackEntries.Load()
+		maxValue := int(l.setMaxListpackValue.Load())
+
+		item.ForEachF(func(a string) bool {
+			if _, ok := types.ParseCanonicalInt(a); !ok {
+				intset = false
+			}
+
+			if len(a) > maxValue {
+				listpack = false
+			}
+
+			return intset || listpack
+		})
+
+		if intset {
+			return OBJ_ENCODING_INTSET
+		}
+
+		if listpack {
+			return OBJ_ENCODING_LISTPACK
+		}
+
+		return OBJ_ENCODING_HASHTABLE
+	case *types.ZSet:
+		if prev == OBJ_ENCODING_SKIPLIST {
+			return prev
+		}
+
+		if int64(item.Len()) > l.zsetMaxListpackEntries.Load() {
+			return OBJ_ENCODING_SKIPLIST
+		}
+
+		maxValue := int(l.zsetMaxListpackValue.Load())
+
+		for member := range item.Value().(*types.SortedSet).Dict {
+			if len(member) > maxValue {
+				return OBJ_ENCODING_SKIPLIST
+			}
+		}
+
+		return OBJ_ENCODING_LISTPACK
+	}
+
+	return OBJ_ENCODING_RAW
+}
+
+// ObjectInfo is the information about a key returned by OBJECT.
+type ObjectInfo struct {
+	Encoding string
+	Refcount int64
+	Idle     int64 // Seconds since the last access
+	Freq     uint8 // Logarithmic access counter of LFU
+}
+
+// Object returns the information about a key without touching it.
+func (db *Db) Object(key string) (ObjectInfo, bool) {
+	item, exists := db.Storage[key]
+
+	if !exists || db.DeleteExpired(key) > 0 {
+		return ObjectInfo{}, false
+	}
+
+	info := ObjectInfo{Refcount: 1}
+	meta, ok := db.meta[key]
+
+	if !ok {
+		return info, true
+	}
+
+	now := db.redis.Now()
+	info.Encoding = meta.Encoding
+	info.Idle = (now.UnixMilli() - meta.Access) / 1000
+	info.Freq = db.redis.lfuDecrAndReturn(meta, now)
+
+	if s, ok := item.(*types.String); ok && meta.Encoding == OBJ_ENCODING_INT {
+		if n, _ := s.Int(); n >= 0 && n < sharedIntegers {
+			info.Refcount = sharedRefcount
+		}
+	}
+
+	return info, true
+}
+
+// SetAccess sets the LFU counter of a key if freq is not negative and an LFU
+// policy is selected, or else its idle time if idle is not negative.
+func (db *Db) SetAccess(key string, freq int64, idle int64) {
+	meta, ok := db.meta[key]
+
+	if !ok {
+		return
+	}
+
+	now := db.redis.Now()
+
+	if db.redis.LfuPolicy() {
+		if freq >= 0 {
+			meta.Freq = uint8(freq)
+			meta.Decay = now.Unix() / 60
+		}
+	} else if idle >= 0 {
+		meta.Access = now.UnixMilli() - idle*1000
+	}
+}
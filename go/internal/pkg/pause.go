@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"sync"
+	"time"
+)
+
+// Pause holds the state of CLIENT PAUSE.
+type Pause struct {
+	mu      *sync.Mutex
+	until   time.Time
+	all     bool          // Whether all the commands are paused or only the writes
+	timer   Timer         // Unpauses once the pause has elapsed
+	gen     uint64        // Incremented by every pause so that stale timers are ignored
+	changed chan struct{} // Closed when the clients are unpaused
+}
+
+func newPause() *Pause {
+	return &Pause{
+		mu:      new(sync.Mutex),
+		changed: make(chan struct{}),
+	}
+}
+
+// PauseClients pauses the commands of the clients until the timeout elapses.
+// Only the commands that may change the data are paused unless all is set.
+// An ongoing pause is only extended, it is never shortened nor weakened.
+func (r *Redis) PauseClients(timeout time.Duration, all bool) {
+	p := r.pause
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	until := r.Now().Add(timeout)
+	paused := p.timer != nil
+
+	if paused && p.all && !all {
+		all = true
+	}
+
+	if paused && p.until.After(until) {
+		until = p.until
+	}
+
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+
+	p.until = until
+	p.all = all
+	p.gen++
+	gen := p.gen
+	p.timer = r.clock.AfterFunc(until.Sub(r.Now()), func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		if p.gen == gen {
+			p.unpause()
+		}
+	})
+}
+
+// UnpauseClients resumes the paused clients.
+func (r *Redis) UnpauseClients() {
+	p := r.pause
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.unpause()
+}
+
+func (p *Pause) unpause() {
+	if p.timer == nil {
+		return
+	}
+
+	p.timer.Stop()
+	p.timer = nil
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// waitPause blocks the client until its command is no longer paused.
+// It returns false if the client has been closed in the meantime.
+func (r *Redis) waitPause(c *Client, info *CommandInfo) bool {
+	for {
+		p := r.pause
+		p.mu.Lock()
+		paused := p.timer != nil && (p.all || info != nil && info.Flag&(CMD_WRITE|CMD_MAY_REPLICATE) != 0)
+		changed := p.changed
+		p.mu.Unlock()
+
+		if !paused {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-c.closed:
+			return false
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hbina/radish/internal/util"
+)
+
+// PubSub holds the subscriptions of the clients to channels and patterns.
+type PubSub struct {
+	mu       *sync.Mutex
+	channels map[string]map[*Client]struct{}
+	patterns map[string]map[*Client]struct{}
+}
+
+func newPubSub() *PubSub {
+	return &PubSub{
+		mu:       new(sync.Mutex),
+		channels: make(map[string]map[*Client]struct{}, 0),
+		patterns: make(map[string]map[*Client]struct{}, 0),
+	}
+}
+
+// Subscribe subscribes the client to the channels, or to the patterns if
+// pattern is set. It replies with a confirmation for each of them.
+func (r *Redis) Subscribe(c *Client, names []string, pattern bool) {
+	ps := r.pubsub
+
+	r.cliLock.Lock()
+	c.startPusher()
+	r.cliLock.Unlock()
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	subs, clientSubs, kind := ps.channels, c.channels, "subscribe"
+
+	if pattern {
+		subs, clientSubs, kind = ps.patterns, c.patterns, "psubscribe"
+	}
+
+	for _, name := range names {
+		if _, ok := clientSubs[name]; !ok {
+			clientSubs[name] = struct{}{}
+
+			if _, ok := subs[name]; !ok {
+				subs[name] = make(map[*Client]struct{}, 0)
+			}
+
+			subs[name][c] = struct{}{}
+		}
+
+		c.writePubSubReply(kind, &name, len(c.channels)+len(c.patterns))
+	}
+}
+
+// Unsubscribe unsubscribes the client from the channels, or from the patterns
+// if pattern is set. No names means all of them.
+func (r *Redis) Unsubscribe(c *Client, names []string, pattern bool) {
+	ps := r.pubsub
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	subs, clientSubs, kind := ps.channels, c.channels, "unsubscribe"
+
+	if pattern {
+		subs, clientSubs, kind = ps.patterns, c.patterns, "punsubscribe"
+	}
+
+	if len(names) == 0 {
+		for name := range clientSubs {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		if len(names) == 0 {
+			c.writePubSubReply(kind, nil, len(c.channels)+len(c.patterns))
+			return
+		}
+	}
+
+	for _, name := range names {
+		ps.remove(subs, clientSubs, c, name)
+		c.writePubSubReply(kind, &name, len(c.channels)+len(c.patterns))
+	}
+}
+
+// remove removes the subscription of the client. Must be called with the lock held.
+func (ps *PubSub) remove(subs map[string]map[*Client]struct{}, clientSubs map[string]struct{}, c *Client, name string) {
+	delete(clientSubs, name)
+	delete(subs[name], c)
+
+	if len(subs[name]) == 0 {
+		delete(subs, name)
+	}
+}
+
+// unsubscribeAll removes the subscriptions of a disconnected client.
+func (r *Redis) unsubscribeAll(c *Client) {
+	ps := r.pubsub
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for name := range c.channels {
+		ps.remove(ps.channels, c.channels, c, name)
+	}
+
+	for name := range c.patterns {
+		ps.remove(ps.patterns, c.patterns, c, name)
+	}
+}
+
+// writePubSubReply replies to a (un)subscription with the number of subscriptions left.
+func (c *Client) writePubSubReply(kind string, name *string, count int) {
+	if c.R3 {
+		c.conn.WritePush(3)
+	} else {
+		c.conn.WriteArray(3)
+	}
+
+	c.conn.WriteBulkString(kind)
+
+	if name != nil {
+		c.conn.WriteBulkString(*name)
+	} else {
+		c.conn.WriteNullBulk()
+	}
+
+	c.conn.WriteInt(count)
+}
+
+// SubscriptionCount returns the number of channels and patterns the client is subscribed to.
+func (c *Client) SubscriptionCount() int {
+	channels, patterns := c.subscriptionCounts()
+	return channels + patterns
+}
+
+// subscriptionCounts returns the number of channels and patterns the client is subscribed to.
+func (c *Client) subscriptionCounts() (int, int) {
+	ps := c.redis.pubsub
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return len(c.channels), len(c.patterns)
+}
+
+// isSubscribed returns whether the client is subscribed to the channel.
+func (c *Client) isSubscribed(channel string) bool {
+	ps := c.redis.pubsub
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	_, ok := c.channels[channel]
+	return ok
+}
+
+// Publish sends the message to the clients subscribed to the channel
+// and returns the number of clients that received it.
+func (r *Redis) Publish(channel string, message string) int {
+	ps := r.pubsub
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	receivers := 0
+
+	for c := range ps.channels[channel] {
+		c.push(pubSubMessage(c.R3, "message", nil, channel, message))
+		receivers++
+	}
+
+	for pattern, clients := range ps.patterns {
+		if !util.StringMatch(pattern, channel, false) {
+			continue
+		}
+
+		for c := range clients {
+			c.push(pubSubMessage(c.R3, "pmessage", &pattern, channel, message))
+			receivers++
+		}
+	}
+
+	return receivers
+}
+
+// pubSubMessage formats a message pushed to a subscriber.
+func pubSubMessage(resp3 bool, kind string, pattern *string, channel string, message string) []byte {
+	var str strings.Builder
+	n := 3
+
+	if pattern != nil {
+		n = 4
+	}
+
+	if resp3 {
+		fmt.Fprintf(&str, ">%d\r\n", n)
+	} else {
+		fmt.Fprintf(&str, "*%d\r\n", n)
+	}
+
+	fmt.Fprintf(&str, "$%d\r\n%s\r\n", len(kind), kind)
+
+	if pattern != nil {
+		fmt.Fprintf(&str, "$%d\r\n%s\r\n", len(*pattern), *pattern)
+	}
+
+	fmt.Fprintf(&str, "$%d\r\n%s\r\n", len(channel), channel)
+	fmt.Fprintf(&str, "$%d\r\n%s\r\n", len(message), message)
+
+	return []byte(str.String())
+}
+
+// pubSubStats returns the number of channels and patterns with subscribers.
+func (r *Redis) pubSubStats() (int, int) {
+	ps := r.pubsub
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return len(ps.channels), len(ps.patterns)
+}
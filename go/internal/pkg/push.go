@@ -0,0 +1,51 @@
+package pkg
+
+// Number of messages that can be waiting to be pushed to a client.
+// The client is disconnected if it falls further behind.
+const pushQueueLen = 4096
+
+// startPusher creates the queue of the messages pushed to the client,
+// e.g. by MONITOR, and the goroutine that writes them.
+func (c *Client) startPusher() {
+	if c.outbox != nil {
+		return
+	}
+
+	c.outbox = make(chan []byte, pushQueueLen)
+
+	go func() {
+		for {
+			select {
+			case <-c.closed:
+				return
+			case msg := <-c.outbox:
+				c.writeMu.Lock()
+				err := c.conn.WriteAll(msg)
+				c.writeMu.Unlock()
+				c.pending.Add(-int64(len(msg)))
+
+				if err != nil {
+					c.conn.HandleWriteError(err)
+					c.Close()
+					return
+				}
+			}
+		}
+	}()
+}
+
+// push queues the message without waiting for it to be written.
+// A client that does not read its messages fast enough is disconnected.
+func (c *Client) push(msg []byte) {
+	c.pending.Add(int64(len(msg)))
+
+	select {
+	case c.outbox <- msg:
+	case <-c.closed:
+		c.pending.Add(-int64(len(msg)))
+	default:
+		c.pending.Add(-int64(len(msg)))
+		c.redis.logger.Printf("Closing client that reached the max push queue length: %s\n", c.info())
+		c.Close()
+	}
+}
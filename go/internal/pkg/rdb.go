@@ -0,0 +1,464 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/hbina/radish/internal/util"
+)
+
+// Encodings of the lengths in the RDB format, given by the 2 most significant bits.
+const (
+	RDB_6BITLEN  = 0
+	RDB_14BITLEN = 1
+	RDB_32BITLEN = 0x80
+	RDB_64BITLEN = 0x81
+	RDB_ENCVAL   = 3
+)
+
+// Special encodings of the strings, when the length is RDB_ENCVAL.
+const (
+	RDB_ENC_INT8  = 0
+	RDB_ENC_INT16 = 1
+	RDB_ENC_INT32 = 2
+	RDB_ENC_LZF   = 3
+)
+
+var errRdbBadData = errors.New("bad data format")
+
+// rdbWriter serializes values in the RDB format.
+type rdbWriter struct {
+	buf []byte
+}
+
+func (w *rdbWriter) writeByte(b byte) {
+	w.buf = append(w.buf, b)
+}
+
+func (w *rdbWriter) writeLen(n uint64) {
+	switch {
+	case n < 1<<6:
+		w.buf = append(w.buf, byte(n)|RDB_6BITLEN<<6)
+	case n < 1<<14:
+		w.buf = append(w.buf, byte(n>>8)|RDB_14BITLEN<<6, byte(n))
+	case n <= math.MaxUint32:
+		w.buf = append(w.buf, RDB_32BITLEN)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	default:
+		w.buf = append(w.buf, RDB_64BITLEN)
+		w.buf = binary.BigEndian.AppendUint64(w.buf, n)
+	}
+}
+
+// writeString writes a string, as an integer if it is a small one in its
+// canonical form.
+func (w *rdbWriter) writeString(s string) {
+	if len(s) <= 11 {
+		if n, err := strconv.ParseInt(s, 10, 32); err == nil && strconv.FormatInt(n, 10) == s {
+			switch {
+			case n >= math.MinInt8 && n <= math.MaxInt8:
+				w.buf = append(w.buf, RDB_ENCVAL<<6|RDB_ENC_INT8, byte(n))
+			case n >= math.MinInt16 && n <= math.MaxInt16:
+				w.buf = append(w.buf, RDB_ENCVAL<<6|RDB_ENC_INT16)
+				w.buf = binary.LittleEndian.AppendUint16(w.buf, uint16(n))
+			default:
+				w.buf = append(w.buf, RDB_ENCVAL<<6|RDB_ENC_INT32)
+				w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(n))
+			}
+			return
+		}
+	}
+
+	w.writeLen(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *rdbWriter) writeBinaryDouble(f float64) {
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(f))
+}
+
+// rdbReader deserializes values in the RDB format.
+type rdbReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *rdbReader) readByte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errRdbBadData
+	}
+
+	r.pos++
+	return r.buf[r.pos-1], nil
+}
+
+func (r *rdbReader) readBytes(n uint64) ([]byte, error) {
+	if n > uint64(len(r.buf)-r.pos) {
+		return nil, errRdbBadData
+	}
+
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// readLen reads a length, or the special encoding of a string if encoded is true.
+func (r *rdbReader) readLen() (n uint64, encoded bool, err error) {
+	b, err := r.readByte()
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch {
+	case b>>6 == RDB_6BITLEN:
+		return uint64(b & 0x3f), false, nil
+	case b>>6 == RDB_14BITLEN:
+		next, err := r.readByte()
+		return uint64(b&0x3f)<<8 | uint64(next), false, err
+	case b>>6 == RDB_ENCVAL:
+		return uint64(b & 0x3f), true, nil
+	case b == RDB_32BITLEN:
+		data, err := r.readBytes(4)
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		return uint64(binary.BigEndian.Uint32(data)), false, nil
+	case b == RDB_64BITLEN:
+		data, err := r.readBytes(8)
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		return binary.BigEndian.Uint64(data), false, nil
+	}
+
+	return 0, false, errRdbBadData
+}
+
+// readCount reads the length of a collection, which cannot be encoded.
+func (r *rdbReader) readCount() (uint64, error) {
+	n, encoded, err := r.readLen()
+
+	if err != nil || encoded {
+		return 0, errRdbBadData
+	}
+
+	// Every element takes at least one byte
+	if n > uint64(len(r.buf)-r.pos) {
+		return 0, errRdbBadData
+	}
+
+	return n, nil
+}
+
+func (r *rdbReader) readString() (string, error) {
+	n, encoded, err := r.readLen()
+
+	if err != nil {
+		return "", err
+	}
+
+	if !encoded {
+		data, err := r.readBytes(n)
+		return string(data), err
+	}
+
+	switch n {
+	case RDB_ENC_INT8:
+		b, err := r.readByte()
+		return strconv.FormatInt(int64(int8(b)), 10), err
+	case RDB_ENC_INT16:
+		data, err := r.readBytes(2)
+
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(int64(int16(binary.LittleEndian.Uint16(data))), 10), nil
+	case RDB_ENC_INT32:
+		data, err := r.readBytes(4)
+
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(int64(int32(binary.LittleEndian.Uint32(data))), 10), nil
+	case RDB_ENC_LZF:
+		clen, err := r.readCount()
+
+		if err != nil {
+			return "", err
+		}
+
+		length, _, err := r.readLen()
+
+		if err != nil || length > 1<<32 {
+			return "", errRdbBadData
+		}
+
+		compressed, err := r.readBytes(clen)
+
+		if err != nil {
+			return "", err
+		}
+
+		data, err := util.LzfDecompress(compressed, int(length))
+
+		if err != nil {
+			return "", errRdbBadData
+		}
+
+		return string(data), nil
+	}
+
+	return "", errRdbBadData
+}
+
+// readDouble reads a double of RDB_TYPE_ZSET, which is stored as a string.
+func (r *rdbReader) readDouble() (float64, error) {
+	n, err := r.readByte()
+
+	if err != nil {
+		return 0, err
+	}
+
+	switch n {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+
+	data, err := r.readBytes(uint64(n))
+
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := strconv.ParseFloat(string(data), 64)
+
+	if err != nil {
+		return 0, errRdbBadData
+	}
+
+	return f, nil
+}
+
+func (r *rdbReader) readBinaryDouble() (float64, error) {
+	data, err := r.readBytes(8)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Float64frombits(binary.LittleEndian.Uint64(data)), nil
+}
+
+// listpackEntries returns the entries of a listpack.
+func listpackEntries(lp []byte) ([]string, error) {
+	if len(lp) < 7 || binary.LittleEndian.Uint32(lp) != uint32(len(lp)) {
+		return nil, errRdbBadData
+	}
+
+	entries := make([]string, 0, binary.LittleEndian.Uint16(lp[4:]))
+	pos := 6
+
+	for {
+		if pos >= len(lp) {
+			return nil, errRdbBadData
+		}
+
+		b := lp[pos]
+
+		if b == 0xff {
+			break
+		}
+
+		var entry string
+		var header, length int // Bytes of the encoding and of the string that follows it
+
+		switch {
+		case b&0x80 == 0: // 7 bit unsigned integer
+			entry, header = strconv.Itoa(int(b)), 1
+		case b&0xc0 == 0x80: // 6 bit length string
+			header, length = 1, int(b&0x3f)
+		case b&0xe0 == 0xc0: // 13 bit signed integer
+			if pos+2 > len(lp) {
+				return nil, errRdbBadData
+			}
+
+			n := int64(b&0x1f)<<8 | int64(lp[pos+1])
+			entry, header = strconv.FormatInt(n<<51>>51, 10), 2
+		case b&0xf0 == 0xe0: // 12 bit length string
+			if pos+2 > len(lp) {
+				return nil, errRdbBadData
+			}
+
+			header, length = 2, int(b&0x0f)<<8|int(lp[pos+1])
+		case b == 0xf0: // 32 bit length string
+			if pos+5 > len(lp) {
+				return nil, errRdbBadData
+			}
+
+			header, length = 5, int(binary.LittleEndian.Uint32(lp[pos+1:]))
+		case b >= 0xf1 && b <= 0xf4: // 16, 24, 32 and 64 bit signed integers
+			width := map[byte]int{0xf1: 2, 0xf2: 3, 0xf3: 4, 0xf4: 8}[b]
+
+			if pos+1+width > len(lp) {
+				return nil, errRdbBadData
+			}
+
+			entry, header = strconv.FormatInt(littleEndianInt(lp[pos+1:pos+1+width]), 10), 1+width
+		default:
+			return nil, errRdbBadData
+		}
+
+		if length < 0 || pos+header+length > len(lp) {
+			return nil, errRdbBadData
+		}
+
+		if length > 0 {
+			entry = string(lp[pos+header : pos+header+length])
+		}
+
+		entries = append(entries, entry)
+		pos += header + length + listpackBacklenSize(header+length)
+	}
+
+	return entries, nil
+}
+
+// listpackBacklenSize returns the bytes used to store the length of an
+// entry after it, so that the listpack can be traversed backwards.
+func listpackBacklenSize(size int) int {
+	switch {
+	case size <= 127:
+		return 1
+	case size < 16383:
+		return 2
+	case size < 2097151:
+		return 3
+	case size < 268435455:
+		return 4
+	}
+
+	return 5
+}
+
+// littleEndianInt decodes a signed integer of 2 to 8 bytes.
+func littleEndianInt(b []byte) int64 {
+	var n uint64
+
+	for i := len(b) - 1; i >= 0; i-- {
+		n = n<<8 | uint64(b[i])
+	}
+
+	shift := 64 - 8*len(b)
+	return int64(n<<shift) >> shift
+}
+
+// ziplistEntries returns the entries of a ziplist, the encoding that
+// preceded listpacks.
+func ziplistEntries(zl []byte) ([]string, error) {
+	if len(zl) < 11 || binary.LittleEndian.Uint32(zl) != uint32(len(zl)) {
+		return nil, errRdbBadData
+	}
+
+	entries := make([]string, 0, binary.LittleEndian.Uint16(zl[8:]))
+	pos := 10
+
+	for {
+		if pos >= len(zl) {
+			return nil, errRdbBadData
+		}
+
+		if zl[pos] == 0xff {
+			break
+		}
+
+		// The length of the previous entry
+		if zl[pos] == 0xfe {
+			pos += 5
+		} else {
+			pos++
+		}
+
+		if pos >= len(zl) {
+			return nil, errRdbBadData
+		}
+
+		b := zl[pos]
+		var header, length int
+		var entry string
+
+		switch {
+		case b>>6 == 0:
+			header, length = 1, int(b&0x3f)
+		case b>>6 == 1:
+			if pos+2 > len(zl) {
+				return nil, errRdbBadData
+			}
+
+			header, length = 2, int(b&0x3f)<<8|int(zl[pos+1])
+		case b == 0x80:
+			if pos+5 > len(zl) {
+				return nil, errRdbBadData
+			}
+
+			header, length = 5, int(binary.BigEndian.Uint32(zl[pos+1:]))
+		case b >= 0xf1 && b <= 0xfd: // Immediate integers between 0 and 12
+			entry, header = strconv.Itoa(int(b&0x0f)-1), 1
+		default:
+			width := map[byte]int{0xc0: 2, 0xd0: 4, 0xe0: 8, 0xf0: 3, 0xfe: 1}[b]
+
+			if width == 0 || pos+1+width > len(zl) {
+				return nil, errRdbBadData
+			}
+
+			entry, header = strconv.FormatInt(littleEndianInt(zl[pos+1:pos+1+width]), 10), 1+width
+		}
+
+		if b>>6 < 2 || b == 0x80 {
+			if length < 0 || pos+header+length > len(zl) {
+				return nil, errRdbBadData
+			}
+
+			entry = string(zl[pos+header : pos+header+length])
+		}
+
+		entries = append(entries, entry)
+		pos += header + length
+	}
+
+	return entries, nil
+}
+
+// intsetEntries returns the integers of an intset.
+func intsetEntries(is []byte) ([]string, error) {
+	if len(is) < 8 {
+		return nil, errRdbBadData
+	}
+
+	width := int(binary.LittleEndian.Uint32(is))
+	count := int(binary.LittleEndian.Uint32(is[4:]))
+
+	if (width != 2 && width != 4 && width != 8) || len(is) != 8+width*count {
+		return nil, errRdbBadData
+	}
+
+	entries := make([]string, 0, count)
+
+	for i := 0; i < count; i++ {
+		entries = append(entries, strconv.FormatInt(littleEndianInt(is[8+i*width:8+(i+1)*width]), 10))
+	}
+
+	return entries, nil
+}
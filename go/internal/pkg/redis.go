@@ -3,7 +3,9 @@ package pkg
 import (
 	"fmt"
 	"net"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hbina/radish/internal/util"
@@ -12,32 +14,242 @@ import (
 type Redis struct {
 	cmds    map[string]*Command         // List of supported commands
 	configs map[string]string           // Configurations (Currently unused)
+	cfgDefs map[string]string           // Configurations the server was started with
 	dbs     map[uint64]*Db              // List of database currently maintained
 	bcmds   map[string]*BlockingCommand // List of supported blocked commands
 	rlist   map[*Client]*BlockedCommand // List of commands to be retried for which clients
-	bcmdTtl chan *Client
+	cfgLock *sync.RWMutex               // Lock to the configurations
+	cfgHook map[string]ConfigHook       // Hooks called when a configuration is changed
+	acl     *Acl
+	logger  util.ILogger
+	clients map[uint64]*Client // List of connected clients by their id
+	nextId  uint64
+	cliLock *sync.Mutex   // Lock to the list of clients
+	done    chan struct{} // Closed when the background jobs should stop
+	stop    *sync.Once
+	clock   Clock
+	modules []*Module              // List of registered modules
+	ctypes  map[string]*CustomType // Types registered by the modules
+	stats   *Stats
+	slowlog *Slowlog
+	latency *LatencyMonitor
+	// Clients in monitor mode, protected by cliLock
+	monitors map[*Client]struct{}
+	pause    *Pause
+	tracking *Tracking
+	pubsub   *PubSub
+	eviction *Eviction
+	limits   *Limits
+	migrate  *MigrateCache
+	// Scripts run by EVAL, protected by its own lock
+	scripting *Scripting
+	// Libraries loaded by FUNCTION LOAD, protected by its own locks
+	functions *Functions
+	// Classes of the keyspace events that are published, from notify-keyspace-events
+	notifyEvents uint64
 }
 
+// ConfigHook is called with the new value when a configuration is changed.
+// The configuration is not changed if the hook returns an error.
+type ConfigHook func(r *Redis, value string) error
+
 func Default(
 	commands map[string]*Command,
 	blockingCommands map[string]*BlockingCommand,
 	configs map[string]string) *Redis {
 	r := &Redis{
-		cmds:    commands,
-		bcmds:   blockingCommands,
-		configs: configs,
-		dbs:     make(map[uint64]*Db, 0),
-		rlist:   make(map[*Client]*BlockedCommand, 0),
-		bcmdTtl: make(chan *Client, 1),
+		cmds:      commands,
+		bcmds:     blockingCommands,
+		configs:   configs,
+		cfgDefs:   make(map[string]string, len(configs)),
+		dbs:       make(map[uint64]*Db, 0),
+		rlist:     make(map[*Client]*BlockedCommand, 0),
+		cfgLock:   new(sync.RWMutex),
+		cfgHook:   make(map[string]ConfigHook, 0),
+		acl:       NewAcl(),
+		logger:    &util.StubLogger{},
+		clients:   make(map[uint64]*Client, 0),
+		cliLock:   new(sync.Mutex),
+		done:      make(chan struct{}),
+		stop:      new(sync.Once),
+		clock:     SystemClock{},
+		ctypes:    make(map[string]*CustomType, 0),
+		stats:     newStats(),
+		slowlog:   newSlowlog(),
+		latency:   newLatencyMonitor(),
+		monitors:  make(map[*Client]struct{}, 0),
+		pause:     newPause(),
+		tracking:  newTracking(),
+		pubsub:    newPubSub(),
+		eviction:  newEviction(),
+		limits:    newLimits(),
+		migrate:   newMigrateCache(),
+		scripting: newScripting(),
+		functions: newFunctions(),
+	}
+
+	for key, value := range configs {
+		r.cfgDefs[key] = value
+	}
+
+	r.RegisterConfigHook("requirepass", func(r *Redis, value string) error {
+		return r.setDefaultUserPassword(value)
+	})
+
+	if v := r.GetConfigValue("requirepass"); v != nil {
+		r.setDefaultUserPassword(*v)
+	}
+
+	r.RegisterConfigHook("slowlog-log-slower-than", func(r *Redis, value string) error {
+		return r.setSlowlogThreshold(value)
+	})
+
+	if v := r.GetConfigValue("slowlog-log-slower-than"); v != nil {
+		r.setSlowlogThreshold(*v)
+	}
+
+	r.RegisterConfigHook("slowlog-max-len", func(r *Redis, value string) error {
+		return r.setSlowlogMaxLen(value)
+	})
+
+	if v := r.GetConfigValue("slowlog-max-len"); v != nil {
+		r.setSlowlogMaxLen(*v)
+	}
+
+	r.RegisterConfigHook("latency-monitor-threshold", func(r *Redis, value string) error {
+		return r.setLatencyThreshold(value)
+	})
+
+	if v := r.GetConfigValue("latency-monitor-threshold"); v != nil {
+		r.setLatencyThreshold(*v)
+	}
+
+	r.RegisterConfigHook("tracking-table-max-keys", func(r *Redis, value string) error {
+		return r.setTrackingMaxKeys(value)
+	})
+
+	if v := r.GetConfigValue("tracking-table-max-keys"); v != nil {
+		r.setTrackingMaxKeys(*v)
+	}
+
+	r.RegisterConfigHook("notify-keyspace-events", func(r *Redis, value string) error {
+		return r.setNotifyKeyspaceEvents(value)
+	})
+
+	if v := r.GetConfigValue("notify-keyspace-events"); v != nil {
+		r.setNotifyKeyspaceEvents(*v)
+	}
+
+	r.RegisterConfigHook("maxmemory", func(r *Redis, value string) error {
+		return r.setMaxmemory(value)
+	})
+
+	if v := r.GetConfigValue("maxmemory"); v != nil {
+		r.setMaxmemory(*v)
 	}
+
+	r.RegisterConfigHook("maxmemory-policy", func(r *Redis, value string) error {
+		return r.setMaxmemoryPolicy(value)
+	})
+
+	if v := r.GetConfigValue("maxmemory-policy"); v != nil {
+		r.setMaxmemoryPolicy(*v)
+	}
+
+	r.RegisterConfigHook("maxmemory-samples", func(r *Redis, value string) error {
+		return r.setMaxmemorySamples(value)
+	})
+
+	if v := r.GetConfigValue("maxmemory-samples"); v != nil {
+		r.setMaxmemorySamples(*v)
+	}
+
+	r.RegisterConfigHook("lfu-log-factor", func(r *Redis, value string) error {
+		return r.setLfuLogFactor(value)
+	})
+
+	if v := r.GetConfigValue("lfu-log-factor"); v != nil {
+		r.setLfuLogFactor(*v)
+	}
+
+	r.RegisterConfigHook("lfu-decay-time", func(r *Redis, value string) error {
+		return r.setLfuDecayTime(value)
+	})
+
+	if v := r.GetConfigValue("lfu-decay-time"); v != nil {
+		r.setLfuDecayTime(*v)
+	}
+
+	r.RegisterConfigHook("lua-time-limit", func(r *Redis, value string) error {
+		return r.setLuaTimeLimit(value)
+	})
+
+	if v := r.GetConfigValue("lua-time-limit"); v != nil {
+		r.setLuaTimeLimit(*v)
+	}
+
+	for key, limit := range r.limits.limitConfigs() {
+		limit := limit
+		min := int64(0)
+
+		if key == "list-max-listpack-size" || key == "list-max-ziplist-size" {
+			min = -5
+		}
+
+		r.RegisterConfigHook(key, func(r *Redis, value string) error {
+			return setLimit(limit, value, min)
+		})
+
+		if v := r.GetConfigValue(key); v != nil {
+			setLimit(limit, *v, min)
+		}
+	}
+
 	return r
 }
 
+// Logger returns the logger of this instance.
+func (r *Redis) Logger() util.ILogger {
+	return r.logger
+}
+
+// SetLogger sets the logger used by this instance and its clients.
+func (r *Redis) SetLogger(logger util.ILogger) {
+	r.logger = logger
+}
+
+// Stop stops the background jobs.
+func (r *Redis) Stop() {
+	r.stop.Do(func() {
+		close(r.done)
+	})
+}
+
+// Clients returns the connected clients ordered by their id.
+func (r *Redis) Clients() []*Client {
+	r.cliLock.Lock()
+	defer r.cliLock.Unlock()
+
+	res := make([]*Client, 0, len(r.clients))
+
+	for _, c := range r.clients {
+		res = append(res, c)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].id < res[j].id
+	})
+
+	return res
+}
+
 // Flush all keys synchronously
 func (r *Redis) SyncFlushAll() {
 	for _, v := range r.dbs {
 		v.Clear()
 	}
+
+	r.trackingInvalidateAll()
 }
 
 // Flush the selected db
@@ -47,6 +259,8 @@ func (r *Redis) SyncFlushDb(dbId uint64) {
 	if exists {
 		d.Clear()
 	}
+
+	r.trackingInvalidateAll()
 }
 
 // GetDb gets the redis database by its id or creates and returns it if not exists.
@@ -63,11 +277,14 @@ func (r *Redis) GetDb(dbId uint64) *Db {
 	// rely on it to fail to stop?
 
 	// now really create db of that id
-	r.dbs[dbId] = NewRedisDb(dbId)
+	r.dbs[dbId] = NewRedisDb(dbId, r)
 	return r.dbs[dbId]
 }
 
 func (r *Redis) GetConfigValue(key string) *string {
+	r.cfgLock.RLock()
+	defer r.cfgLock.RUnlock()
+
 	v, e := r.configs[key]
 	if e {
 		return &v
@@ -75,21 +292,125 @@ func (r *Redis) GetConfigValue(key string) *string {
 	return nil
 }
 
-func (r *Redis) SetConfigValue(key string, value string) {
-	r.configs[key] = value
+// SetConfigValue changes the configuration and runs its hook, if any.
+func (r *Redis) SetConfigValue(key string, value string) error {
+	return r.SetConfigValues([]string{key}, []string{value})
+}
+
+// SetConfigValues changes the configurations atomically.
+// The hooks are called after all the values are set so that they can
+// observe each other. If any hook fails, the old values are restored and
+// the hooks that have succeeded are called again with them, or with the
+// values the server was started with for the keys that had none.
+func (r *Redis) SetConfigValues(keys []string, values []string) error {
+	r.cfgLock.Lock()
+	olds := make([]*string, len(keys))
+	hooks := make([]ConfigHook, len(keys))
+
+	for i, key := range keys {
+		if v, e := r.configs[key]; e {
+			olds[i] = &v
+		}
+		hooks[i] = r.cfgHook[key]
+		r.configs[key] = values[i]
+	}
+
+	r.cfgLock.Unlock()
+
+	for i, hook := range hooks {
+		if hook == nil {
+			continue
+		}
+
+		err := hook(r, values[i])
+
+		if err == nil {
+			continue
+		}
+
+		r.cfgLock.Lock()
+		restored := make([]string, len(keys))
+
+		for j, key := range keys {
+			if olds[j] != nil {
+				r.configs[key] = *olds[j]
+				restored[j] = *olds[j]
+			} else {
+				delete(r.configs, key)
+				restored[j] = r.cfgDefs[key]
+			}
+		}
+
+		r.cfgLock.Unlock()
+
+		// Let the hooks that have succeeded observe the old values
+		for j := 0; j < i; j++ {
+			if hooks[j] != nil {
+				hooks[j](r, restored[j])
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// RegisterConfigHook registers a hook that will be called whenever the configuration is changed.
+func (r *Redis) RegisterConfigHook(key string, hook ConfigHook) {
+	r.cfgLock.Lock()
+	defer r.cfgLock.Unlock()
+
+	r.cfgHook[key] = hook
 }
 
 // NewClient creates new client and adds it to the redis.
 func (r *Redis) NewClient(conn net.Conn) *Client {
+	r.cliLock.Lock()
+	defer r.cliLock.Unlock()
+
+	r.nextId++
+	r.stats.totalConnections.Add(1)
+
 	c := &Client{
-		conn:  util.NewConn(conn),
-		redis: r,
-		dbId:  0,
-		R3:    false,
+		id:        r.nextId,
+		conn:      util.NewConn(&countingConn{Conn: conn, stats: r.stats}, r.logger),
+		redis:     r,
+		dbId:      0,
+		R3:        false,
+		user:      r.DefaultUser(),
+		writeMu:   new(sync.Mutex),
+		closed:    make(chan struct{}),
+		closeOnce: new(sync.Once),
+		created:   r.Now(),
+		channels:  make(map[string]struct{}, 0),
+		patterns:  make(map[string]struct{}, 0),
+		// Clients are automatically authenticated if there is no password
+		authenticated: !r.RequiresPass(),
 	}
+
+	c.conn.SetErrorHook(func(msg string) {
+		c.failed = true
+		r.stats.recordError(msg)
+	})
+
+	c.lastActive.Store(c.created.UnixNano())
+	r.clients[c.id] = c
 	return c
 }
 
+// removeClient removes the client from the redis once it is disconnected.
+func (r *Redis) removeClient(c *Client) {
+	r.DisableTracking(c)
+	r.unsubscribeAll(c)
+
+	r.cliLock.Lock()
+	defer r.cliLock.Unlock()
+
+	delete(r.clients, c.id)
+	delete(r.monitors, c)
+}
+
 func (r *Redis) HandleRequest(c *Client, args [][]byte) {
 	if len(args) == 0 {
 		c.Conn().WriteError(util.ZeroArgumentErr)
@@ -102,17 +423,110 @@ func (r *Redis) HandleRequest(c *Client, args [][]byte) {
 	cmd := r.cmds[cmdName]
 	bcmd := r.bcmds[cmdName]
 
+	// Disconnect clients whose user have been deleted
+	if c.user.deleted {
+		c.Close()
+		return
+	}
+
+	var info *CommandInfo
+
+	if cmd != nil {
+		info = &cmd.CommandInfo
+	} else if bcmd != nil {
+		info = &bcmd.CommandInfo
+	}
+
+	if !r.waitPause(c, info) {
+		return
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.lastActive.Store(r.Now().UnixNano())
+
+	if info != nil {
+		c.lastCmd.Store(info.Name)
+	}
+
+	// Replies are discarded with CLIENT REPLY OFF or once after CLIENT REPLY SKIP
+	c.conn.SetMuted(c.replyOff || c.replySkip)
+	c.replySkip = false
+
+	if r.AuthRequired(c, info) {
+		r.rejectCommand(c, info, util.NoAuthErr)
+		return
+	}
+
+	if info != nil && !info.CheckArity(args) {
+		r.rejectCommand(c, info, fmt.Sprintf(util.WrongNumOfArgsErr, info.Name))
+		return
+	}
+
+	if info != nil {
+		if errMsg, ok := r.AclCheck(c, info, args); !ok {
+			r.rejectCommand(c, info, errMsg)
+			return
+		}
+	}
+
+	// Only the commands managing the subscriptions are allowed in the RESP2 pubsub context
+	if !c.R3 && info != nil && c.SubscriptionCount() > 0 {
+		switch info.Name {
+		case "subscribe", "psubscribe", "unsubscribe", "punsubscribe", "ping", "quit", "reset":
+		default:
+			r.rejectCommand(c, info, fmt.Sprintf("ERR Can't execute '%s': only (P|S)SUBSCRIBE / "+
+				"(P|S)UNSUBSCRIBE / PING / QUIT / RESET are allowed in this context", info.Name))
+			return
+		}
+	}
+
+	if c.monitor && info != nil && info.Flag&(CMD_READONLY|CMD_WRITE|CMD_MAY_REPLICATE) != 0 {
+		r.rejectCommand(c, info, "ERR Replica can't interact with the keyspace")
+		return
+	}
+
+	// Only a few commands can run while a script runs for too long, they
+	// do not wait for the database that the script holds
+	if info != nil && r.ScriptBusy() {
+		if cmd == nil || !allowedWhileBusy(info, args) {
+			r.rejectCommand(c, info, util.BusyErr)
+			return
+		}
+
+		c.failed = false
+		start := time.Now()
+		(cmd.Handler)(c, args)
+		r.stats.recordCall(info, time.Since(start), c.failed)
+		return
+	}
+
+	// The keys are evicted before the database of the client is locked
+	if info != nil && !r.PerformEvictions() && info.Flag&CMD_DENYOOM != 0 {
+		r.rejectCommand(c, info, util.OOMErr)
+		return
+	}
+
 	c.Db().Lock()
 
+	// Only the lookups of the read-only commands count as keyspace hits and misses
+	c.Db().countLookups = info != nil && info.Flag&CMD_READONLY != 0
+	c.Db().caller = c
+	c.failed = false
+	start := time.Now()
+
 	if cmd != nil {
 		(cmd.Handler)(c, args)
 		r.HandleBlockedRequests(true)
 	} else if bcmd != nil {
 		err := (bcmd.Handler)(c, args)
 		if err != nil {
+			r.cliLock.Lock()
 			r.rlist[err.c] = err
-			go time.AfterFunc(err.duration, func() {
-				r.bcmdTtl <- err.c
+			r.cliLock.Unlock()
+			r.clock.AfterFunc(err.duration, func() {
+				r.timeoutBlockedCommand(err.c)
 			})
 		} else {
 			r.HandleBlockedRequests(false)
@@ -121,20 +535,60 @@ func (r *Redis) HandleRequest(c *Client, args [][]byte) {
 		c.Conn().WriteError(fmt.Sprintf("ERR unknown command '%s' with args '%s'", string(args[0]), args[1:]))
 	}
 
+	if info != nil {
+		duration := time.Since(start)
+		r.stats.recordCall(info, duration, c.failed)
+		r.slowlogPush(c, info, args, duration)
+
+		if info.Flag&CMD_FAST != 0 {
+			r.LatencyAddSampleIfNeeded(LATENCY_EVENT_FAST_COMMAND, duration)
+		} else {
+			r.LatencyAddSampleIfNeeded(LATENCY_EVENT_COMMAND, duration)
+		}
+
+		r.trackingAfterCommand(c, info, args)
+		r.feedMonitors(c, info, args)
+	}
+
+	// SELECT has switched the locked database
+	c.Db().countLookups = false
+	c.Db().caller = nil
 	c.Db().Unlock()
 }
 
+// rejectCommand replies with the error of a command that is not allowed to run.
+func (r *Redis) rejectCommand(c *Client, info *CommandInfo, errMsg string) {
+	c.Conn().WriteError(errMsg)
+
+	if info != nil {
+		r.stats.recordRejected(info)
+	}
+}
+
 // SAFETY: Some of the checks here have been ommitted because
 // we already checked for them when we first received the command
 func (r *Redis) HandleBlockedRequests(new bool) {
+	// The handlers run without the lock since they may publish notifications
+	r.cliLock.Lock()
+	blocked := make([]*BlockedCommand, 0, len(r.rlist))
+
 	for _, bcmd := range r.rlist {
-		if !bcmd.ttl.IsZero() && time.Now().After(bcmd.ttl) {
+		blocked = append(blocked, bcmd)
+	}
+
+	r.cliLock.Unlock()
+
+	for _, bcmd := range blocked {
+		if !bcmd.ttl.IsZero() && r.Now().After(bcmd.ttl) {
+			r.cliLock.Lock()
 			delete(r.rlist, bcmd.c)
+			r.cliLock.Unlock()
 		} else {
 			cmdName := strings.ToLower(string(bcmd.args[0]))
 			cmd := r.bcmds[cmdName]
 			err := (cmd.Handler)(bcmd.c, bcmd.args)
 
+			r.cliLock.Lock()
 			if err != nil {
 				if !new { // If not a new blocked command, use the old TTL
 					err.ttl = bcmd.ttl
@@ -143,17 +597,28 @@ func (r *Redis) HandleBlockedRequests(new bool) {
 			} else {
 				delete(r.rlist, bcmd.c)
 			}
+			r.cliLock.Unlock()
 		}
 	}
 }
 
 func (r *Redis) HandleClient(client *Client) {
+	defer r.removeClient(client)
+	defer client.Close()
+
+	if r.IsProtected(client) {
+		client.Conn().WriteError(util.ProtectedModeErr)
+		return
+	}
+
 	buffer := make([]byte, 0, 1024)
 	tmp := make([]byte, 1024)
 	count, err := client.Read(tmp)
 
+	// The connection can fail before the first read, e.g. a failed TLS handshake
 	if err != nil {
-		util.Logger.Fatal(err)
+		r.logger.Println(err)
+		return
 	}
 
 	for {
@@ -163,12 +628,20 @@ func (r *Redis) HandleClient(client *Client) {
 		resp, leftover := util.ConvertBytesToRespType(buffer)
 
 		for resp != nil {
-			util.Logger.Println(util.EscapeString(string(buffer)))
+			r.logger.Println(util.EscapeString(string(buffer)))
 			buffer = leftover
+			client.qbuf.Store(int64(len(buffer)))
 			r.HandleRequest(client, util.ConvertRespToArgs(resp))
+
+			if client.closeAfter {
+				return
+			}
+
 			resp, leftover = util.ConvertBytesToRespType(buffer)
 		}
 
+		client.qbuf.Store(int64(len(buffer)))
+
 		count, err = client.Read(tmp)
 
 		if err != nil || count == 0 {
@@ -177,6 +650,30 @@ func (r *Redis) HandleClient(client *Client) {
 	}
 }
 
+// LookupCommand returns the metadata of the command or nil if it does not exist.
+func (r *Redis) LookupCommand(name string) *CommandInfo {
+	return r.lookupCommandInfo(strings.ToLower(name))
+}
+
+// CommandInfos returns the metadata of all the commands ordered by their name.
+func (r *Redis) CommandInfos() []*CommandInfo {
+	res := make([]*CommandInfo, 0, len(r.cmds)+len(r.bcmds))
+
+	for _, cmd := range r.cmds {
+		res = append(res, &cmd.CommandInfo)
+	}
+
+	for _, bcmd := range r.bcmds {
+		res = append(res, &bcmd.CommandInfo)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
+
 func (r *Redis) RegisterCommands(cmds []*Command) {
 	for _, cmd := range cmds {
 		r.cmds[cmd.Name] = cmd
@@ -192,29 +689,50 @@ func (r *Redis) RegisterBlockingCommands(cmds []*BlockingCommand) {
 func (r *Redis) StartKeyExpiryJob(tick time.Duration) {
 	f := func() {
 		ticker := time.NewTicker(tick)
-		for range ticker.C {
-			for _, db := range r.RedisDbs() {
-				db.Lock()
-				db.DeleteExpiredKeys()
-				db.Unlock()
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+				r.deleteExpiredKeys()
 			}
 		}
 	}
 	go f()
 }
 
-func (r *Redis) StartBcmdTimeoutJob() {
-	f := func() {
-		for c := range r.bcmdTtl {
-			c.Db().Lock()
-			if c.R3 {
-				c.Conn().WriteNull()
-			} else {
-				c.Conn().WriteNullArray()
-			}
-			delete(r.rlist, c)
-			c.Db().Unlock()
-		}
+// deleteExpiredKeys deletes the expired keys of all the databases.
+func (r *Redis) deleteExpiredKeys() {
+	start := time.Now()
+
+	for _, db := range r.RedisDbs() {
+		db.Lock()
+		db.DeleteExpiredKeys()
+		db.Unlock()
+	}
+
+	r.LatencyAddSampleIfNeeded(LATENCY_EVENT_EXPIRE_CYCLE, time.Since(start))
+}
+
+// timeoutBlockedCommand replies with a null to the client if it is still blocked.
+func (r *Redis) timeoutBlockedCommand(c *Client) {
+	c.Db().Lock()
+	defer c.Db().Unlock()
+
+	r.cliLock.Lock()
+	_, blocked := r.rlist[c]
+	delete(r.rlist, c)
+	r.cliLock.Unlock()
+
+	if !blocked {
+		return
+	}
+
+	if c.R3 {
+		c.Conn().WriteNull()
+	} else {
+		c.Conn().WriteNullArray()
 	}
-	go f()
 }
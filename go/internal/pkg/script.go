@@ -0,0 +1,534 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hbina/radish/internal/util"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+var (
+	errScriptNotBusy    = errors.New("NOTBUSY No scripts in execution right now.")
+	errScriptUnkillable = errors.New("UNKILLABLE Sorry the script already executed write commands against the dataset. " +
+		"You can either wait the script termination or kill the server in a hard way using the SHUTDOWN NOSAVE command.")
+	errScriptKilled = errors.New("ERR Script killed by user with SCRIPT KILL...")
+)
+
+// Scripting holds the scripts cached by EVAL and SCRIPT LOAD and the
+// scripts that are running.
+type Scripting struct {
+	mu      *sync.Mutex
+	scripts map[string]*script // By their SHA1 digest
+	running map[*scriptRun]struct{}
+	idle    chan struct{} // Closed once the running scripts end
+	states  []*luaState   // Interpreters that are not in use
+	// Milliseconds after which the running scripts make the server busy, from lua-time-limit
+	timeLimit atomic.Int64
+}
+
+func newScripting() *Scripting {
+	return &Scripting{
+		mu:      new(sync.Mutex),
+		scripts: make(map[string]*script, 0),
+		running: make(map[*scriptRun]struct{}, 0),
+	}
+}
+
+// script is a compiled script.
+type script struct {
+	body  string
+	proto *lua.FunctionProto
+}
+
+// scriptRun is a script or a function being run by a client.
+type scriptRun struct {
+	caller   *Client
+	client   *Client     // Runs the commands of the script
+	replies  *scriptConn // The replies to the commands of the script
+	name     string      // The SHA1 digest of the script or the name of the function
+	function bool        // Whether it is a function run by FCALL
+	command  []string    // The command that runs the script
+	start    time.Time
+	readOnly bool // Whether the script can not write, e.g. when run by EVAL_RO
+	allowOom bool // Whether the script can run the DENYOOM commands when out of memory
+	resp     int  // The protocol of the replies of the commands, set by redis.setresp
+	cancel   context.CancelFunc
+	// Protected by the lock of the scripting
+	wrote  bool // Whether the script has run a write command
+	killed bool
+}
+
+// setLuaTimeLimit is the hook of lua-time-limit.
+func (r *Redis) setLuaTimeLimit(value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil {
+		return errors.New("argument couldn't be parsed into an integer")
+	}
+
+	r.scripting.timeLimit.Store(n)
+	return nil
+}
+
+// ScriptSha returns the SHA1 digest of the body of a script.
+func ScriptSha(body string) string {
+	sum := sha1.Sum([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// ScriptLoad compiles the script and caches it.
+// It returns the SHA1 digest of the script.
+func (r *Redis) ScriptLoad(body string) (string, error) {
+	sha := ScriptSha(body)
+	s := r.scripting
+
+	s.mu.Lock()
+	_, exists := s.scripts[sha]
+	s.mu.Unlock()
+
+	if exists {
+		return sha, nil
+	}
+
+	chunk, err := parse.Parse(strings.NewReader(body), luaScriptChunk)
+
+	if err != nil {
+		return "", compileError("script (new function)", err)
+	}
+
+	proto, err := lua.Compile(chunk, luaScriptChunk)
+
+	if err != nil {
+		return "", compileError("script (new function)", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scripts[sha] = &script{body: body, proto: proto}
+	return sha, nil
+}
+
+// compileError returns the error of a script that does not compile.
+// The message of the parser spans several lines but the error reply can not.
+func compileError(what string, err error) error {
+	msg := strings.Join(strings.Fields(err.Error()), " ")
+	return fmt.Errorf("ERR Error compiling %s: %s", what, msg)
+}
+
+// ScriptExists returns whether the script of the SHA1 digest is cached.
+func (r *Redis) ScriptExists(sha string) bool {
+	s := r.scripting
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.scripts[strings.ToLower(sha)]
+	return exists
+}
+
+// ScriptFlush removes all the cached scripts.
+func (r *Redis) ScriptFlush() {
+	s := r.scripting
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scripts = make(map[string]*script, 0)
+
+	for _, ls := range s.states {
+		ls.L.Close()
+	}
+
+	s.states = nil
+}
+
+// ScriptKill stops the running scripts, or the running functions if
+// functions is set. None of them is stopped if one has already run a
+// write command.
+func (r *Redis) ScriptKill(functions bool) error {
+	s := r.scripting
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := make([]*scriptRun, 0, len(s.running))
+
+	for run := range s.running {
+		if run.function == functions {
+			runs = append(runs, run)
+		}
+	}
+
+	if len(runs) == 0 {
+		return errScriptNotBusy
+	}
+
+	for _, run := range runs {
+		if run.wrote {
+			return errScriptUnkillable
+		}
+	}
+
+	for _, run := range runs {
+		run.killed = true
+		run.cancel()
+	}
+
+	return nil
+}
+
+// ScriptBusy waits for the running scripts to end, like the commands of
+// Redis are queued behind a script. It returns true instead once a script
+// has been running for longer than lua-time-limit.
+func (r *Redis) ScriptBusy() bool {
+	s := r.scripting
+
+	for {
+		limit := time.Duration(s.timeLimit.Load()) * time.Millisecond
+
+		s.mu.Lock()
+
+		if len(s.running) == 0 {
+			s.mu.Unlock()
+			return false
+		}
+
+		idle := s.idle
+		wait := time.Duration(math.MaxInt64)
+
+		for run := range s.running {
+			if left := limit - time.Since(run.start); limit > 0 && left < wait {
+				wait = left
+			}
+		}
+
+		s.mu.Unlock()
+
+		if wait <= 0 {
+			return true
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-idle:
+		case <-timer.C:
+		}
+
+		timer.Stop()
+	}
+}
+
+// ScriptCacheStats returns the number of cached scripts and the size of their bodies.
+func (r *Redis) ScriptCacheStats() (int, int64) {
+	s := r.scripting
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := int64(0)
+
+	for _, script := range s.scripts {
+		size += int64(len(script.body))
+	}
+
+	return len(s.scripts), size
+}
+
+// allowedWhileBusy returns whether the command can run while a script is busy.
+func allowedWhileBusy(info *CommandInfo, args [][]byte) bool {
+	if info.Flag&CMD_ALLOW_BUSY == 0 {
+		return false
+	}
+
+	// Only some of the subcommands of SCRIPT and FUNCTION are allowed
+	if info.Name == "script" || info.Name == "function" {
+		subcommand := ""
+
+		if len(args) > 1 {
+			subcommand = strings.ToLower(string(args[1]))
+		}
+
+		return subcommand == "kill" || (info.Name == "function" && subcommand == "stats")
+	}
+
+	return true
+}
+
+// EvalSha runs the cached script with the keys and the arguments and
+// replies to the client with its result.
+// The database of the client must be locked, it stays locked while the
+// script runs so that the script is atomic.
+func (r *Redis) EvalSha(c *Client, sha string, keys []string, args []string, readOnly bool) {
+	s := r.scripting
+	sha = strings.ToLower(sha)
+
+	s.mu.Lock()
+	script, exists := s.scripts[sha]
+	s.mu.Unlock()
+
+	if !exists {
+		c.Conn().WriteError(util.NoScriptErr)
+		return
+	}
+
+	ls := r.acquireLuaState()
+	defer r.releaseLuaState(ls)
+
+	ls.setArguments(keys, args)
+	run := r.newScriptRun(c, sha, readOnly)
+	r.runScript(c, ls, run, ls.L.NewFunctionFromProto(script.proto))
+}
+
+func (r *Redis) newScriptRun(c *Client, name string, readOnly bool) *scriptRun {
+	replies := new(scriptConn)
+
+	return &scriptRun{
+		caller:   c,
+		client:   r.newScriptClient(c, replies),
+		replies:  replies,
+		name:     name,
+		readOnly: readOnly,
+		resp:     2,
+	}
+}
+
+// runScript calls the function of the script with the arguments and
+// replies to the client with its result.
+func (r *Redis) runScript(c *Client, ls *luaState, run *scriptRun, fn *lua.LFunction, args ...lua.LValue) {
+	s := r.scripting
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	run.start = time.Now()
+	run.cancel = cancel
+	ls.run = run
+	ls.L.SetContext(ctx)
+
+	s.mu.Lock()
+	if len(s.running) == 0 {
+		s.idle = make(chan struct{})
+	}
+	s.running[run] = struct{}{}
+	s.mu.Unlock()
+
+	ls.L.Push(fn)
+
+	for _, arg := range args {
+		ls.L.Push(arg)
+	}
+
+	err := ls.L.PCall(len(args), 1, nil)
+
+	s.mu.Lock()
+	delete(s.running, run)
+	if len(s.running) == 0 {
+		close(s.idle)
+	}
+	killed := run.killed
+	s.mu.Unlock()
+
+	ls.L.RemoveContext()
+	ls.run = nil
+
+	if killed {
+		ls.L.SetTop(0)
+		c.Conn().WriteError(errScriptKilled.Error())
+		return
+	}
+
+	if err != nil {
+		ls.L.SetTop(0)
+		c.Conn().WriteError(scriptErrorMessage(err, run.name))
+		return
+	}
+
+	writeLuaReply(c, run, ls.L.Get(-1))
+	ls.L.SetTop(0)
+}
+
+// acquireLuaState returns an interpreter that is not in use.
+func (r *Redis) acquireLuaState() *luaState {
+	s := r.scripting
+	s.mu.Lock()
+
+	if n := len(s.states); n > 0 {
+		ls := s.states[n-1]
+		s.states = s.states[:n-1]
+		s.mu.Unlock()
+		return ls
+	}
+
+	s.mu.Unlock()
+
+	return r.newLuaState()
+}
+
+// releaseLuaState keeps the interpreter for the next scripts.
+func (r *Redis) releaseLuaState(ls *luaState) {
+	s := r.scripting
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states = append(s.states, ls)
+}
+
+// scriptCommand runs a command of the script and returns its reply.
+func (r *Redis) scriptCommand(run *scriptRun, args [][]byte) []byte {
+	name := strings.ToLower(string(args[0]))
+	cmd := r.cmds[name]
+	bcmd := r.bcmds[name]
+
+	var info *CommandInfo
+
+	if cmd != nil {
+		info = &cmd.CommandInfo
+	} else if bcmd != nil {
+		info = &bcmd.CommandInfo
+	}
+
+	if info == nil {
+		return scriptErrorReply("ERR Unknown Redis command called from script")
+	}
+
+	// The script only holds the lock of the database of its caller
+	if info.Flag&CMD_NOSCRIPT != 0 || info.Name == "select" {
+		return scriptErrorReply("ERR This Redis command is not allowed from script")
+	}
+
+	if !info.CheckArity(args) {
+		return scriptErrorReply("ERR Wrong number of args calling Redis command from script")
+	}
+
+	if errMsg, ok := r.AclCheck(run.caller, info, args); !ok {
+		return scriptErrorReply(errMsg)
+	}
+
+	write := info.Flag&CMD_WRITE != 0
+
+	if write && run.readOnly {
+		return scriptErrorReply("ERR Write commands are not allowed from read-only scripts.")
+	}
+
+	if info.Flag&CMD_DENYOOM != 0 && !run.allowOom && r.OutOfMemory() {
+		return scriptErrorReply(util.OOMErr)
+	}
+
+	// The script can not be killed once it has written
+	r.scripting.mu.Lock()
+	killed := run.killed
+	run.wrote = run.wrote || write
+	r.scripting.mu.Unlock()
+
+	if killed {
+		return scriptErrorReply(errScriptKilled.Error())
+	}
+
+	c := run.client
+	c.R3 = run.resp == 3
+	c.failed = false
+	c.lastCmd.Store(info.Name)
+
+	db := c.Db()
+	caller, countLookups := db.caller, db.countLookups
+	db.caller = c
+	db.countLookups = info.Flag&CMD_READONLY != 0
+
+	run.replies.Reset()
+	start := time.Now()
+
+	if cmd != nil {
+		(cmd.Handler)(c, args)
+	} else if (bcmd.Handler)(c, args) != nil {
+		// The blocking commands time out immediately in scripts
+		run.replies.Reset()
+
+		if c.R3 {
+			c.Conn().WriteNull()
+		} else {
+			c.Conn().WriteNullArray()
+		}
+	}
+
+	r.stats.recordCall(info, time.Since(start), c.failed)
+	db.caller, db.countLookups = caller, countLookups
+
+	return run.replies.Bytes()
+}
+
+func scriptErrorReply(msg string) []byte {
+	return []byte("-" + msg + "\r\n")
+}
+
+// newScriptClient creates the client that runs the commands of a script
+// on behalf of its caller. The replies are written to the conn.
+func (r *Redis) newScriptClient(caller *Client, conn net.Conn) *Client {
+	c := &Client{
+		id:            caller.id,
+		conn:          util.NewConn(conn, r.logger),
+		redis:         r,
+		dbId:          caller.dbId,
+		user:          caller.user,
+		authenticated: true,
+		writeMu:       new(sync.Mutex),
+		closed:        make(chan struct{}),
+		closeOnce:     new(sync.Once),
+		created:       r.Now(),
+		channels:      make(map[string]struct{}, 0),
+		patterns:      make(map[string]struct{}, 0),
+	}
+
+	c.conn.SetErrorHook(func(msg string) {
+		c.failed = true
+		r.stats.recordError(msg)
+	})
+
+	return c
+}
+
+// scriptConn keeps the replies to the commands of a script in memory.
+type scriptConn struct {
+	bytes.Buffer
+}
+
+var _ net.Conn = (*scriptConn)(nil)
+
+func (sc *scriptConn) Read(b []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (sc *scriptConn) Close() error {
+	return nil
+}
+
+func (sc *scriptConn) LocalAddr() net.Addr {
+	return nil
+}
+
+func (sc *scriptConn) RemoteAddr() net.Addr {
+	return nil
+}
+
+func (sc *scriptConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (sc *scriptConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (sc *scriptConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
@@ -0,0 +1,261 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limits of the arguments kept in a slowlog entry, as in Redis.
+const (
+	SlowlogEntryMaxArgc = 32
+	SlowlogEntryMaxLen  = 128
+)
+
+// SlowlogEntry is a command that took longer than slowlog-log-slower-than.
+type SlowlogEntry struct {
+	Id         int64
+	Time       time.Time
+	Duration   time.Duration
+	Args       []string
+	ClientAddr string
+	ClientName string
+}
+
+// Slowlog is a ring buffer of the slowest commands.
+// The buffer grows as the entries arrive until it reaches maxLen.
+type Slowlog struct {
+	threshold atomic.Int64 // In microseconds, negative to disable the log
+	mu        *sync.Mutex
+	entries   []SlowlogEntry
+	start     int // Index of the oldest entry
+	maxLen    int
+	nextId    int64
+}
+
+func newSlowlog() *Slowlog {
+	s := &Slowlog{
+		mu:     new(sync.Mutex),
+		maxLen: 128,
+	}
+	s.threshold.Store(10000)
+
+	return s
+}
+
+// setSlowlogThreshold is the hook of slowlog-log-slower-than.
+func (r *Redis) setSlowlogThreshold(value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil {
+		return errors.New("argument couldn't be parsed into an integer")
+	}
+
+	r.slowlog.threshold.Store(n)
+	return nil
+}
+
+// setSlowlogMaxLen is the hook of slowlog-max-len.
+func (r *Redis) setSlowlogMaxLen(value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || n < 0 || n > math.MaxInt32 {
+		return errors.New("argument must be between 0 and 2147483647 inclusive")
+	}
+
+	r.slowlog.resize(int(n))
+	return nil
+}
+
+// resize changes the maximum length of the slowlog, keeping the newest entries.
+func (s *Slowlog) resize(maxLen int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := len(s.entries)
+
+	if size > maxLen {
+		size = maxLen
+	}
+
+	// The entries are put back in order so that the buffer can grow again
+	entries := make([]SlowlogEntry, size)
+
+	for i := 0; i < size; i++ {
+		entries[size-1-i] = s.entries[(s.start+len(s.entries)-1-i)%len(s.entries)]
+	}
+
+	s.entries = entries
+	s.start = 0
+	s.maxLen = maxLen
+}
+
+// push adds an entry, replacing the oldest one if the slowlog is full.
+func (s *Slowlog) push(e SlowlogEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.Id = s.nextId
+	s.nextId++
+
+	if s.maxLen == 0 {
+		return
+	}
+
+	// The oldest entry is always the first one until the buffer is full
+	if len(s.entries) < s.maxLen {
+		s.entries = append(s.entries, e)
+	} else {
+		s.entries[s.start] = e
+		s.start = (s.start + 1) % len(s.entries)
+	}
+}
+
+// SlowlogGet returns up to count entries of the slowlog, newest first.
+// A negative count returns all of them.
+func (r *Redis) SlowlogGet(count int) []SlowlogEntry {
+	s := r.slowlog
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if count < 0 || count > len(s.entries) {
+		count = len(s.entries)
+	}
+
+	res := make([]SlowlogEntry, 0, count)
+
+	for i := 0; i < count; i++ {
+		res = append(res, s.entries[(s.start+len(s.entries)-1-i)%len(s.entries)])
+	}
+
+	return res
+}
+
+// SlowlogLen returns the number of entries in the slowlog.
+func (r *Redis) SlowlogLen() int {
+	r.slowlog.mu.Lock()
+	defer r.slowlog.mu.Unlock()
+
+	return len(r.slowlog.entries)
+}
+
+// SlowlogReset removes all the entries of the slowlog.
+func (r *Redis) SlowlogReset() {
+	s := r.slowlog
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = nil
+	s.start = 0
+}
+
+// slowlogPush records the command if it ran longer than the threshold.
+func (r *Redis) slowlogPush(c *Client, info *CommandInfo, args [][]byte, duration time.Duration) {
+	threshold := r.slowlog.threshold.Load()
+
+	if threshold < 0 || info.Flag&CMD_SKIP_SLOWLOG != 0 || duration.Microseconds() < threshold {
+		return
+	}
+
+	name := ""
+
+	if c.Name != nil {
+		name = *c.Name
+	}
+
+	r.slowlog.push(SlowlogEntry{
+		Time:       r.Now(),
+		Duration:   duration,
+		Args:       slowlogArgs(info, args),
+		ClientAddr: c.conn.RemoteAddr().String(),
+		ClientName: name,
+	})
+}
+
+// slowlogArgs truncates the arguments and hides the passwords.
+func slowlogArgs(info *CommandInfo, args [][]byte) []string {
+	argc := len(args)
+
+	if argc > SlowlogEntryMaxArgc {
+		argc = SlowlogEntryMaxArgc
+	}
+
+	res := make([]string, 0, argc)
+
+	for i := 0; i < argc; i++ {
+		if i == argc-1 && argc != len(args) {
+			res = append(res, fmt.Sprintf("... (%d more arguments)", len(args)-argc+1))
+			break
+		}
+
+		if redactedArg(info, args, i) {
+			res = append(res, "(redacted)")
+			continue
+		}
+
+		arg := args[i]
+
+		if len(arg) > SlowlogEntryMaxLen {
+			res = append(res, fmt.Sprintf("%s... (%d more bytes)", arg[:SlowlogEntryMaxLen], len(arg)-SlowlogEntryMaxLen))
+		} else {
+			res = append(res, string(arg))
+		}
+	}
+
+	return res
+}
+
+// The configurations whose values are not logged by CONFIG SET.
+var sensitiveConfigs = map[string]struct{}{
+	"requirepass": {},
+	"masterauth":  {},
+}
+
+// redactedArg returns whether the argument at i is a secret that must not be logged.
+func redactedArg(info *CommandInfo, args [][]byte, i int) bool {
+	switch info.Name {
+	case "auth":
+		return i > 0
+	case "hello":
+		// HELLO [protover [AUTH username password] [SETNAME clientname]]
+		for j := 2; j+2 < len(args); j++ {
+			if strings.ToLower(string(args[j])) == "auth" {
+				return i == j+1 || i == j+2
+			}
+		}
+	case "migrate":
+		// MIGRATE ... [AUTH password | AUTH2 username password] [KEYS key [key ...]]
+		for j := 6; j < len(args); j++ {
+			switch strings.ToLower(string(args[j])) {
+			case "auth":
+				return i == j+1
+			case "auth2":
+				return i == j+1 || i == j+2
+			case "keys":
+				return false
+			}
+		}
+	case "acl":
+		// ACL SETUSER username [rule [rule ...]], the rules that add or
+		// remove passwords and their hashes
+		if i > 2 && strings.ToLower(string(args[1])) == "setuser" && len(args[i]) > 0 {
+			switch args[i][0] {
+			case '>', '<', '#', '!':
+				return true
+			}
+		}
+	case "config":
+		// CONFIG SET parameter value [parameter value ...]
+		if i > 2 && i%2 == 1 && strings.ToLower(string(args[1])) == "set" {
+			_, ok := sensitiveConfigs[strings.ToLower(string(args[i-1]))]
+			return ok
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,404 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/bits"
+	"net"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Number of samples used to compute the instantaneous metrics,
+// they are taken every statsSamplePeriod.
+const (
+	statsSamples      = 16
+	statsSamplePeriod = 100 * time.Millisecond
+)
+
+// Stats holds the counters reported by INFO.
+type Stats struct {
+	runId            string // Random identifier of this run of the server
+	startTime        time.Time
+	totalConnections atomic.Uint64
+	totalCommands    atomic.Uint64
+	netInputBytes    atomic.Uint64
+	netOutputBytes   atomic.Uint64
+	expiredKeys      atomic.Uint64
+	evictedKeys      atomic.Uint64
+	keyspaceHits     atomic.Uint64
+	keyspaceMisses   atomic.Uint64
+	totalErrors      atomic.Uint64
+	dirty            atomic.Uint64 // Changes since the start
+	peakAllocated    atomic.Uint64 // Peak of the heap, as far as it has been observed
+	startupAllocated uint64        // Heap allocated when the server was created
+
+	mu       *sync.Mutex
+	commands map[string]*CommandStats
+	errors   map[string]uint64 // Number of errors by their code
+
+	samples []*metricSamples // Samples of the instantaneous metrics
+}
+
+// CommandStats holds the statistics of a command.
+type CommandStats struct {
+	Calls         uint64
+	Usec          uint64
+	RejectedCalls uint64
+	FailedCalls   uint64
+	latency       *histogram
+}
+
+// metricSamples computes the average per second of a counter.
+type metricSamples struct {
+	counter   *atomic.Uint64
+	last      uint64
+	lastTime  time.Time
+	samples   [statsSamples]float64
+	idx       int
+	perSecond float64
+}
+
+func newStats() *Stats {
+	id := make([]byte, 20)
+	rand.Read(id)
+
+	s := &Stats{
+		runId:     hex.EncodeToString(id),
+		startTime: time.Now(),
+		mu:        new(sync.Mutex),
+		commands:  make(map[string]*CommandStats, 0),
+		errors:    make(map[string]uint64, 0),
+	}
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	s.startupAllocated = m.HeapAlloc
+	s.peakAllocated.Store(m.HeapAlloc)
+
+	s.samples = []*metricSamples{
+		{counter: &s.totalCommands, lastTime: s.startTime},
+		{counter: &s.netInputBytes, lastTime: s.startTime},
+		{counter: &s.netOutputBytes, lastTime: s.startTime},
+	}
+
+	return s
+}
+
+// Stats returns the statistics of the redis.
+func (r *Redis) Stats() *Stats {
+	return r.stats
+}
+
+func (s *Stats) RunId() string                    { return s.runId }
+func (s *Stats) StartTime() time.Time             { return s.startTime }
+func (s *Stats) TotalConnections() uint64         { return s.totalConnections.Load() }
+func (s *Stats) TotalCommands() uint64            { return s.totalCommands.Load() }
+func (s *Stats) NetInputBytes() uint64            { return s.netInputBytes.Load() }
+func (s *Stats) NetOutputBytes() uint64           { return s.netOutputBytes.Load() }
+func (s *Stats) ExpiredKeys() uint64              { return s.expiredKeys.Load() }
+func (s *Stats) EvictedKeys() uint64              { return s.evictedKeys.Load() }
+func (s *Stats) KeyspaceHits() uint64             { return s.keyspaceHits.Load() }
+func (s *Stats) KeyspaceMisses() uint64           { return s.keyspaceMisses.Load() }
+func (s *Stats) TotalErrors() uint64              { return s.totalErrors.Load() }
+func (s *Stats) Dirty() uint64                    { return s.dirty.Load() }
+func (s *Stats) InstantaneousOps() float64        { return s.instantaneous(0) }
+func (s *Stats) InstantaneousInputKbps() float64  { return s.instantaneous(1) / 1024 }
+func (s *Stats) InstantaneousOutputKbps() float64 { return s.instantaneous(2) / 1024 }
+
+func (s *Stats) instantaneous(idx int) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.samples[idx].perSecond
+}
+
+// sample records the current value of the instantaneous metrics.
+func (s *Stats) sample(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range s.samples {
+		current := m.counter.Load()
+		elapsed := now.Sub(m.lastTime).Seconds()
+
+		// The counter goes backwards when the statistics are reset
+		if elapsed > 0 && current >= m.last {
+			m.samples[m.idx] = float64(current-m.last) / elapsed
+			m.idx = (m.idx + 1) % statsSamples
+		}
+
+		m.last = current
+		m.lastTime = now
+
+		sum := 0.0
+
+		for _, v := range m.samples {
+			sum += v
+		}
+
+		m.perSecond = sum / statsSamples
+	}
+}
+
+// Commands returns the statistics of the commands that have been called,
+// ordered by their name.
+func (s *Stats) Commands() ([]string, []CommandStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.commands))
+
+	for name := range s.commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	res := make([]CommandStats, 0, len(names))
+
+	for _, name := range names {
+		cs := *s.commands[name]
+		cs.latency = nil
+		res = append(res, cs)
+	}
+
+	return names, res
+}
+
+// Errors returns the number of errors by their code, ordered by their code.
+func (s *Stats) Errors() ([]string, []uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	codes := make([]string, 0, len(s.errors))
+
+	for code := range s.errors {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	res := make([]uint64, 0, len(codes))
+
+	for _, code := range codes {
+		res = append(res, s.errors[code])
+	}
+
+	return codes, res
+}
+
+// Percentiles returns the latencies of the command at the given percentiles in microseconds.
+func (s *Stats) Percentiles(name string, percentiles []float64) []float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]float64, len(percentiles))
+	cs, exists := s.commands[name]
+
+	if !exists {
+		return res
+	}
+
+	for i, p := range percentiles {
+		res[i] = cs.latency.percentile(p)
+	}
+
+	return res
+}
+
+// Reset resets the statistics, as CONFIG RESETSTAT does.
+func (s *Stats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.totalConnections.Store(0)
+	s.totalCommands.Store(0)
+	s.netInputBytes.Store(0)
+	s.netOutputBytes.Store(0)
+	s.expiredKeys.Store(0)
+	s.evictedKeys.Store(0)
+	s.keyspaceHits.Store(0)
+	s.keyspaceMisses.Store(0)
+	s.totalErrors.Store(0)
+	s.commands = make(map[string]*CommandStats, 0)
+	s.errors = make(map[string]uint64, 0)
+}
+
+func (s *Stats) commandStats(name string) *CommandStats {
+	cs, exists := s.commands[name]
+
+	if !exists {
+		cs = &CommandStats{latency: newHistogram()}
+		s.commands[name] = cs
+	}
+
+	return cs
+}
+
+// recordCall records a command that has been executed.
+func (s *Stats) recordCall(info *CommandInfo, duration time.Duration, failed bool) {
+	s.totalCommands.Add(1)
+
+	if info.Flag&CMD_WRITE != 0 && !failed {
+		s.dirty.Add(1)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cs := s.commandStats(info.Name)
+	cs.Calls++
+	cs.Usec += uint64(duration.Microseconds())
+	cs.latency.record(duration)
+
+	if failed {
+		cs.FailedCalls++
+	}
+}
+
+// recordRejected records a command that has been rejected before it was executed.
+func (s *Stats) recordRejected(info *CommandInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commandStats(info.Name).RejectedCalls++
+}
+
+// recordError records an error reply by its code, which is its first word.
+func (s *Stats) recordError(msg string) {
+	code := msg
+
+	if i := strings.IndexByte(msg, ' '); i >= 0 {
+		code = msg[:i]
+	}
+
+	s.totalErrors.Add(1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errors[code]++
+}
+
+// StartStatsJob samples the instantaneous metrics until the redis is stopped.
+func (r *Redis) StartStatsJob() {
+	f := func() {
+		ticker := time.NewTicker(statsSamplePeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.done:
+				return
+			case now := <-ticker.C:
+				r.stats.sample(now)
+			}
+		}
+	}
+	go f()
+}
+
+// countingConn counts the bytes that are read from and written to the connection.
+type countingConn struct {
+	net.Conn
+	stats *Stats
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.stats.netInputBytes.Add(uint64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.stats.netOutputBytes.Add(uint64(n))
+	return n, err
+}
+
+// histogram records latencies in buckets of roughly 6% precision.
+// Every power of two is divided into 16 linear sub-buckets.
+type histogram struct {
+	counts map[int]uint64
+	total  uint64
+}
+
+const histogramSubBuckets = 4 // log2 of the number of sub-buckets
+
+func newHistogram() *histogram {
+	return &histogram{counts: make(map[int]uint64, 0)}
+}
+
+func histogramBucket(ns uint64) int {
+	exp := bits.Len64(ns)
+
+	if exp <= histogramSubBuckets {
+		return int(ns)
+	}
+
+	sub := (ns >> (exp - histogramSubBuckets - 1)) & (1<<histogramSubBuckets - 1)
+	return exp<<histogramSubBuckets | int(sub)
+}
+
+// histogramUpperBound returns the highest value in nanoseconds within the bucket.
+func histogramUpperBound(bucket int) uint64 {
+	if bucket < 1<<histogramSubBuckets {
+		return uint64(bucket)
+	}
+
+	exp := bucket >> histogramSubBuckets
+	sub := uint64(bucket & (1<<histogramSubBuckets - 1))
+	shift := exp - histogramSubBuckets - 1
+	low := (1<<histogramSubBuckets | sub) << shift
+
+	return low + (1 << shift) - 1
+}
+
+func (h *histogram) record(d time.Duration) {
+	ns := uint64(d.Nanoseconds())
+
+	if d < 0 {
+		ns = 0
+	}
+
+	h.counts[histogramBucket(ns)]++
+	h.total++
+}
+
+// percentile returns the latency in microseconds at the percentile p, between 0 and 100.
+func (h *histogram) percentile(p float64) float64 {
+	if h.total == 0 {
+		return 0
+	}
+
+	buckets := make([]int, 0, len(h.counts))
+
+	for b := range h.counts {
+		buckets = append(buckets, b)
+	}
+
+	sort.Ints(buckets)
+
+	target := uint64(float64(h.total)*p/100 + 0.5)
+
+	if target == 0 {
+		target = 1
+	}
+
+	var seen uint64
+
+	for _, b := range buckets {
+		seen += h.counts[b]
+
+		if seen >= target {
+			return float64(histogramUpperBound(b)) / 1000
+		}
+	}
+
+	return float64(histogramUpperBound(buckets[len(buckets)-1])) / 1000
+}
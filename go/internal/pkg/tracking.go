@@ -0,0 +1,476 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TrackingChannelName is the channel of the invalidation messages of RESP2 clients.
+const TrackingChannelName = "__redis__:invalidate"
+
+// Tracking holds the state of the client side caching.
+// The keys are tracked by name regardless of their database, as in Redis.
+type Tracking struct {
+	mu       *sync.Mutex
+	maxKeys  int                            // From tracking-table-max-keys, 0 for no limit
+	keys     map[string]map[uint64]struct{} // Clients that may have cached a key
+	prefixes map[string]map[uint64]struct{} // Clients in BCAST mode by prefix
+	clients  map[uint64]*ClientTracking     // Clients with tracking enabled
+}
+
+// ClientTracking holds the options of CLIENT TRACKING of a client.
+type ClientTracking struct {
+	Redirect uint64 // Id of the client that receives the invalidations, 0 for none
+	Bcast    bool
+	OptIn    bool
+	OptOut   bool
+	NoLoop   bool
+	Prefixes []string
+	// Set by CLIENT CACHING for the next command, it means "yes"
+	// in OPTIN mode and "no" in OPTOUT mode.
+	caching      bool
+	redirBroken  bool
+	cachingBound bool // Whether caching was set by the current command
+}
+
+func newTracking() *Tracking {
+	return &Tracking{
+		mu:       new(sync.Mutex),
+		keys:     make(map[string]map[uint64]struct{}, 0),
+		prefixes: make(map[string]map[uint64]struct{}, 0),
+		clients:  make(map[uint64]*ClientTracking, 0),
+	}
+}
+
+// setTrackingMaxKeys is the hook of tracking-table-max-keys.
+func (r *Redis) setTrackingMaxKeys(value string) error {
+	n, err := strconv.Atoi(value)
+
+	if err != nil || n < 0 {
+		return errors.New("argument must be a positive integer")
+	}
+
+	t := r.tracking
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.maxKeys = n
+	return nil
+}
+
+// EnableTracking turns on the tracking of the client with the given options.
+// If the client is already tracking, the options are merged as in Redis.
+func (r *Redis) EnableTracking(c *Client, opts ClientTracking) error {
+	if !opts.Bcast && len(opts.Prefixes) > 0 {
+		return errors.New("ERR PREFIX option requires BCAST mode to be enabled")
+	}
+
+	if opts.Bcast && (opts.OptIn || opts.OptOut) {
+		return errors.New("ERR OPTIN and OPTOUT are not compatible with BCAST")
+	}
+
+	if opts.OptIn && opts.OptOut {
+		return errors.New("ERR You can't use both OPTIN and OPTOUT")
+	}
+
+	var target *Client
+
+	if opts.Redirect != 0 {
+		r.cliLock.Lock()
+		target = r.clients[opts.Redirect]
+
+		if target != nil {
+			target.startPusher()
+		}
+
+		r.cliLock.Unlock()
+
+		if target == nil {
+			return errors.New("ERR The client ID you want redirect to does not exist")
+		}
+	}
+
+	t := r.tracking
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	old := t.clients[c.id]
+
+	if old != nil {
+		if old.Bcast != opts.Bcast {
+			return errors.New("ERR You can't switch BCAST mode on/off before disabling " +
+				"tracking for this client, and then re-enabling it with a different mode.")
+		}
+
+		if opts.OptIn && old.OptOut || opts.OptOut && old.OptIn {
+			return errors.New("ERR You can't switch OPTIN/OPTOUT mode before disabling " +
+				"tracking for this client, and then re-enabling it with a different mode.")
+		}
+	}
+
+	prefixes := opts.Prefixes
+
+	if opts.Bcast {
+		if len(prefixes) == 0 && old == nil {
+			prefixes = []string{""}
+		}
+
+		var existing []string
+
+		if old != nil {
+			existing = old.Prefixes
+		}
+
+		if err := checkPrefixCollisions(existing, prefixes); err != nil {
+			return err
+		}
+	}
+
+	r.cliLock.Lock()
+	c.startPusher()
+	r.cliLock.Unlock()
+
+	ct := old
+
+	if ct == nil {
+		ct = &ClientTracking{}
+		t.clients[c.id] = ct
+	}
+
+	ct.Redirect = opts.Redirect
+	ct.Bcast = opts.Bcast
+	ct.OptIn = opts.OptIn
+	ct.OptOut = opts.OptOut
+	ct.NoLoop = opts.NoLoop
+	ct.redirBroken = false
+
+	for _, prefix := range prefixes {
+		if _, ok := t.prefixes[prefix]; !ok {
+			t.prefixes[prefix] = make(map[uint64]struct{}, 0)
+		}
+
+		t.prefixes[prefix][c.id] = struct{}{}
+		ct.Prefixes = append(ct.Prefixes, prefix)
+	}
+
+	return nil
+}
+
+// checkPrefixCollisions checks that the prefixes do not overlap with each other
+// nor with the prefixes already registered by the client.
+func checkPrefixCollisions(existing []string, prefixes []string) error {
+	for i, p := range prefixes {
+		for _, e := range existing {
+			if strings.HasPrefix(p, e) || strings.HasPrefix(e, p) {
+				return fmt.Errorf("ERR Prefix '%s' overlaps with an existing prefix '%s'. "+
+					"Prefixes for a single client must not overlap.", p, e)
+			}
+		}
+
+		for j, other := range prefixes {
+			if i != j && (strings.HasPrefix(p, other) || strings.HasPrefix(other, p)) {
+				return fmt.Errorf("ERR Prefix '%s' overlaps with another provided prefix '%s'. "+
+					"Prefixes for a single client must not overlap.", p, other)
+			}
+		}
+	}
+
+	return nil
+}
+
+// DisableTracking turns off the tracking of the client.
+// The keys it has read are forgotten lazily, when they are invalidated.
+func (r *Redis) DisableTracking(c *Client) {
+	t := r.tracking
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ct := t.clients[c.id]
+
+	if ct == nil {
+		return
+	}
+
+	for _, prefix := range ct.Prefixes {
+		delete(t.prefixes[prefix], c.id)
+
+		if len(t.prefixes[prefix]) == 0 {
+			delete(t.prefixes, prefix)
+		}
+	}
+
+	delete(t.clients, c.id)
+}
+
+// GetTracking returns a copy of the tracking options of the client,
+// or nil if the tracking is off.
+func (r *Redis) GetTracking(c *Client) *ClientTracking {
+	t := r.tracking
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ct := t.clients[c.id]
+
+	if ct == nil {
+		return nil
+	}
+
+	cp := *ct
+	cp.Prefixes = append([]string{}, ct.Prefixes...)
+	sort.Strings(cp.Prefixes)
+
+	return &cp
+}
+
+// RedirectBroken returns whether the client redirecting its invalidations
+// has found that the target has been disconnected.
+func (ct *ClientTracking) RedirectBroken() bool {
+	return ct.redirBroken
+}
+
+// Caching returns whether CLIENT CACHING has been called for the next command.
+func (ct *ClientTracking) Caching() bool {
+	return ct.caching
+}
+
+// SetTrackingCaching implements CLIENT CACHING YES|NO.
+func (r *Redis) SetTrackingCaching(c *Client, yes bool) error {
+	t := r.tracking
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ct := t.clients[c.id]
+
+	if ct == nil || !ct.OptIn && !ct.OptOut {
+		return errors.New("ERR CLIENT CACHING can be called only when the client is in " +
+			"tracking mode with OPTIN or OPTOUT mode enabled")
+	}
+
+	if yes && !ct.OptIn {
+		return errors.New("ERR CLIENT CACHING YES is only valid when tracking is enabled in OPTIN mode.")
+	}
+
+	if !yes && !ct.OptOut {
+		return errors.New("ERR CLIENT CACHING NO is only valid when tracking is enabled in OPTOUT mode.")
+	}
+
+	ct.caching = true
+	ct.cachingBound = true
+	return nil
+}
+
+// trackingAfterCommand remembers the keys read by a client that is tracking,
+// so that it is sent an invalidation message once they are modified.
+func (r *Redis) trackingAfterCommand(c *Client, info *CommandInfo, args [][]byte) {
+	t := r.tracking
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ct := t.clients[c.id]
+
+	if ct == nil {
+		return
+	}
+
+	// CLIENT CACHING only applies to the command that follows it
+	caching := ct.caching
+
+	if ct.cachingBound {
+		ct.cachingBound = false
+	} else {
+		ct.caching = false
+	}
+
+	if ct.Bcast || info.Flag&CMD_READONLY == 0 {
+		return
+	}
+
+	if ct.OptIn && !caching || ct.OptOut && caching {
+		return
+	}
+
+	indexes, _ := info.KeyIndexes(args)
+
+	for _, idx := range indexes {
+		key := string(args[idx])
+
+		if _, ok := t.keys[key]; !ok {
+			t.keys[key] = make(map[uint64]struct{}, 0)
+		}
+
+		t.keys[key][c.id] = struct{}{}
+	}
+
+	r.trackingLimitKeys()
+}
+
+// trackingLimitKeys invalidates random keys until the tracking table fits in
+// tracking-table-max-keys. Must be called with the tracking lock held.
+func (r *Redis) trackingLimitKeys() {
+	t := r.tracking
+
+	if t.maxKeys == 0 {
+		return
+	}
+
+	for key := range t.keys {
+		if len(t.keys) <= t.maxKeys {
+			return
+		}
+
+		r.invalidateTrackedKey(nil, key)
+	}
+}
+
+// trackingInvalidateKey sends an invalidation message to the clients that may
+// have cached the key. The caller is the client that modified the key, or nil.
+func (r *Redis) trackingInvalidateKey(caller *Client, key string) {
+	t := r.tracking
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.clients) == 0 {
+		return
+	}
+
+	for prefix, ids := range t.prefixes {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		for id := range ids {
+			ct := t.clients[id]
+
+			if ct.NoLoop && caller != nil && caller.id == id {
+				continue
+			}
+
+			r.sendTrackingMessage(id, ct, []string{key})
+		}
+	}
+
+	r.invalidateTrackedKey(caller, key)
+}
+
+// invalidateTrackedKey notifies the clients that have read the key and
+// removes it from the tracking table. Must be called with the tracking lock held.
+func (r *Redis) invalidateTrackedKey(caller *Client, key string) {
+	t := r.tracking
+	ids, ok := t.keys[key]
+
+	if !ok {
+		return
+	}
+
+	delete(t.keys, key)
+
+	for id := range ids {
+		ct := t.clients[id]
+
+		// The client may have disabled the tracking since it read the key
+		if ct == nil || ct.Bcast {
+			continue
+		}
+
+		if ct.NoLoop && caller != nil && caller.id == id {
+			continue
+		}
+
+		r.sendTrackingMessage(id, ct, []string{key})
+	}
+}
+
+// trackingInvalidateAll is called when the databases are flushed. All the
+// clients are sent a null invalidation message and the tracked keys are forgotten.
+func (r *Redis) trackingInvalidateAll() {
+	t := r.tracking
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, ct := range t.clients {
+		r.sendTrackingMessage(id, ct, nil)
+	}
+
+	t.keys = make(map[string]map[uint64]struct{}, 0)
+}
+
+// sendTrackingMessage pushes an invalidation message for the keys, or for
+// everything if keys is nil, to the client or where it redirects them.
+// Must be called with the tracking lock held.
+func (r *Redis) sendTrackingMessage(id uint64, ct *ClientTracking, keys []string) {
+	r.cliLock.Lock()
+	defer r.cliLock.Unlock()
+
+	c := r.clients[id]
+
+	if c == nil {
+		return
+	}
+
+	target := c
+
+	if ct.Redirect != 0 {
+		target = r.clients[ct.Redirect]
+
+		if target == nil {
+			// Let the client know that its invalidations are lost
+			ct.redirBroken = true
+
+			if c.R3 {
+				msg := "tracking-redir-broken"
+				c.startPusher()
+				c.push([]byte(fmt.Sprintf(">2\r\n$%d\r\n%s\r\n:%d\r\n", len(msg), msg, ct.Redirect)))
+			}
+
+			return
+		}
+	}
+
+	var msg strings.Builder
+
+	if target.R3 {
+		msg.WriteString(">2\r\n$10\r\ninvalidate\r\n")
+	} else if ct.Redirect != 0 && target.isSubscribed(TrackingChannelName) {
+		// RESP2 clients can only receive the invalidations as pubsub messages
+		fmt.Fprintf(&msg, "*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n", len(TrackingChannelName), TrackingChannelName)
+	} else {
+		return
+	}
+
+	if keys == nil {
+		if target.R3 {
+			msg.WriteString("_\r\n")
+		} else {
+			msg.WriteString("$-1\r\n")
+		}
+	} else {
+		fmt.Fprintf(&msg, "*%d\r\n", len(keys))
+
+		for _, key := range keys {
+			fmt.Fprintf(&msg, "$%d\r\n%s\r\n", len(key), key)
+		}
+	}
+
+	target.startPusher()
+	target.push([]byte(msg.String()))
+}
+
+// trackingStats returns the number of clients with tracking enabled,
+// of tracked keys, of tracking entries and of BCAST prefixes.
+func (r *Redis) trackingStats() (int, int, int, int) {
+	t := r.tracking
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	items := 0
+
+	for _, ids := range t.keys {
+		items += len(ids)
+	}
+
+	return len(t.clients), len(t.keys), items, len(t.prefixes)
+}
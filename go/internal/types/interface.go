@@ -5,6 +5,7 @@ const (
 	ValueTypeString
 	ValueTypeSet
 	ValueTypeZSet
+	ValueTypeModule // Types registered by modules, they are told apart by TypeFancy
 )
 
 const (
@@ -13,8 +13,17 @@ func TestListForEachF(t *testing.T) {
 		list.LPush(fmt.Sprint(j))
 	}
 	c := 4
-	list.ForEachF(func(a string) {
+	list.ForEachF(func(a string) bool {
 		assert.Equal(t, a, fmt.Sprint(c))
 		c--
+		return true
 	})
+
+	// The iteration stops once the function returns false
+	n := 0
+	list.ForEachF(func(a string) bool {
+		n++
+		return a != "3"
+	})
+	assert.Equal(t, 2, n)
 }
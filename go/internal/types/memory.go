@@ -0,0 +1,93 @@
+package types
+
+// Approximate sizes in bytes of the Go structures backing the items.
+// They are used to account for the memory of the keys, the exact
+// numbers depend on the runtime.
+const (
+	stringHeaderSize  = 16 // Pointer and length of a string
+	sliceHeaderSize   = 24 // Pointer, length and capacity of a slice
+	intSize           = 8
+	interfaceSize     = 16 // Type and data pointers of an interface value
+	listSize          = 56 // container/list.List
+	listElementSize   = 48 // container/list.Element without its value
+	mapSize           = 48 // Header of a map
+	mapEntrySize      = 16 // Average overhead of a map entry besides its key and value
+	skiplistSize      = 40 // SortedSet without its dict
+	skiplistNodeSize  = 56 // SortedSetNode without its levels
+	skiplistLevelSize = 16
+	pointerSize       = 8
+)
+
+// Sized is implemented by the items that can estimate their memory usage.
+type Sized interface {
+	// MemoryUsage returns the approximate number of bytes used by the item.
+	// The size of the elements of aggregate items is estimated from a sample
+	// of that many elements, or all of them if samples is 0.
+	MemoryUsage(samples int) int
+}
+
+func (s *String) MemoryUsage(samples int) int {
+	if s.isInt {
+		return sliceHeaderSize + intSize
+	}
+
+	return sliceHeaderSize + cap(s.buf)
+}
+
+func (l *List) MemoryUsage(samples int) int {
+	size := listSize + l.Len()*(listElementSize+interfaceSize+stringHeaderSize)
+	sampled, bytes := 0, 0
+
+	for e := l.inner.Front(); e != nil && (samples == 0 || sampled < samples); e = e.Next() {
+		bytes += len(e.Value.(string))
+		sampled++
+	}
+
+	return size + estimate(bytes, sampled, l.Len())
+}
+
+func (s *Set) MemoryUsage(samples int) int {
+	size := mapSize + s.Len()*(mapEntrySize+stringHeaderSize)
+	sampled, bytes := 0, 0
+
+	for member := range s.inner {
+		if samples != 0 && sampled >= samples {
+			break
+		}
+
+		bytes += len(member)
+		sampled++
+	}
+
+	return size + estimate(bytes, sampled, s.Len())
+}
+
+func (s *ZSet) MemoryUsage(samples int) int {
+	ss := s.inner
+	// Every member is in the dict and in a node of the skiplist, which has
+	// 1/(1-p) levels on average. Both share the bytes of the member.
+	levels := int(float64(ss.Len()) / (1 - SKIPLIST_P))
+	size := skiplistSize + mapSize + ss.Len()*(mapEntrySize+stringHeaderSize+pointerSize) +
+		ss.Len()*(skiplistNodeSize+stringHeaderSize) + levels*skiplistLevelSize
+	sampled, bytes := 0, 0
+
+	for member := range ss.Dict {
+		if samples != 0 && sampled >= samples {
+			break
+		}
+
+		bytes += len(member)
+		sampled++
+	}
+
+	return size + estimate(bytes, sampled, ss.Len())
+}
+
+// estimate extrapolates the bytes of the sampled elements to all of them.
+func estimate(bytes int, sampled int, total int) int {
+	if sampled == 0 {
+		return 0
+	}
+
+	return int(float64(bytes) / float64(sampled) * float64(total))
+}
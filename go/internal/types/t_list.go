@@ -207,10 +207,13 @@ func (l *List) LTrim(start int, end int) bool {
 }
 
 // TODO: For now we only store strings so this should be enough.
-func (list *List) ForEachF(f func(a string)) {
+// The iteration stops once f returns false.
+func (list *List) ForEachF(f func(a string) bool) {
 	l := list.inner
 	for e := l.Front(); e != nil; e = e.Next() {
-		f(e.Value.(string))
+		if !f(e.Value.(string)) {
+			break
+		}
 	}
 }
 
@@ -271,8 +274,9 @@ func abs(x int) int {
 func (s *List) Marshal() ([]byte, error) {
 	arr := make([]string, 0, s.Len())
 
-	s.ForEachF(func(a string) {
+	s.ForEachF(func(a string) bool {
 		arr = append(arr, a)
+		return true
 	})
 
 	str, err := json.Marshal(arr)
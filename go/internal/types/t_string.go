@@ -1,21 +1,48 @@
 package types
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strconv"
+)
 
 var _ Item = (*String)(nil)
 
+// The length of the longest int64, -9223372036854775808.
+const maxIntLen = 20
+
+// String is a binary safe string. Like in Redis, the strings holding an
+// integer in its canonical form are stored as the integer so that the
+// counters are updated without parsing and formatting them. The others are
+// stored in a byte buffer that is modified in place, its capacity grows
+// geometrically so that appending to it takes amortised constant time.
 type String struct {
-	inner string
+	buf   []byte
+	n     int64
+	isInt bool
 }
 
 // impl Item for String
 
 func NewString(value string) *String {
-	return &String{inner: value}
+	if n, ok := ParseCanonicalInt(value); ok {
+		return NewIntString(n)
+	}
+
+	return &String{buf: []byte(value)}
+}
+
+// NewStringFromBytes returns a string that takes the ownership of the bytes.
+func NewStringFromBytes(value []byte) *String {
+	return &String{buf: value}
+}
+
+// NewIntString returns a string holding the integer.
+func NewIntString(n int64) *String {
+	return &String{n: n, isInt: true}
 }
 
 func (s *String) Value() interface{} {
-	return s.inner
+	return s.AsString()
 }
 
 func (l *String) Type() uint64 {
@@ -27,18 +54,27 @@ func (l *String) TypeFancy() string {
 }
 
 func (s *String) Len() int {
-	return len(s.inner)
+	if s.isInt {
+		var buf [maxIntLen]byte
+		return len(strconv.AppendInt(buf[:0], s.n, 10))
+	}
+
+	return len(s.buf)
 }
 
 // impl String
 
 func (s *String) Get(idx int) byte {
-	v := s.inner[idx]
-	return v
+	if s.isInt {
+		var buf [maxIntLen]byte
+		return strconv.AppendInt(buf[:0], s.n, 10)[idx]
+	}
+
+	return s.buf[idx]
 }
 
 func (s *String) Marshal() ([]byte, error) {
-	str, err := json.Marshal(s.inner)
+	str, err := json.Marshal(s.AsString())
 	return str, err
 }
 
@@ -53,24 +89,110 @@ func StringUnmarshal(data []byte) (*String, bool) {
 	return NewString(set), true
 }
 
+// IsInt returns whether the string is stored as an integer.
+func (s *String) IsInt() bool {
+	return s.isInt
+}
+
+// Int returns the integer held by the string, if it is one in its
+// canonical form.
+func (s *String) Int() (int64, bool) {
+	if s.isInt {
+		return s.n, true
+	}
+
+	return ParseCanonicalInt(string(s.buf))
+}
+
+// Bytes returns the content of the string without copying it unless it is
+// an integer. The bytes must not be modified.
+func (s *String) Bytes() []byte {
+	if s.isInt {
+		return strconv.AppendInt(make([]byte, 0, maxIntLen), s.n, 10)
+	}
+
+	return s.buf
+}
+
+// AsBytes returns a copy of the content of the string.
 func (s *String) AsBytes() []byte {
-	return []byte(s.inner)
+	if s.isInt {
+		return s.Bytes()
+	}
+
+	return append([]byte(nil), s.buf...)
 }
 
 func (s *String) AsString() string {
-	return s.inner
+	if s.isInt {
+		return strconv.FormatInt(s.n, 10)
+	}
+
+	return string(s.buf)
 }
 
 func (s *String) SubString(start, end int) string {
-	return s.inner[start:end]
+	return string(s.Bytes()[start:end])
+}
+
+// Append appends the value to the string and returns its new length.
+func (s *String) Append(value []byte) int {
+	s.toBuffer()
+	s.buf = append(s.buf, value...)
+	return len(s.buf)
+}
+
+// SetRange overwrites the string with the value from the offset, padding it
+// with zeros if it is shorter than the offset. It returns the new length.
+func (s *String) SetRange(offset int, value []byte) int {
+	s.Grow(offset + len(value))
+	copy(s.buf[offset:], value)
+	return len(s.buf)
+}
+
+// Grow pads the string with zeros up to n bytes.
+func (s *String) Grow(n int) {
+	s.toBuffer()
+
+	if n > len(s.buf) {
+		s.buf = append(s.buf, make([]byte, n-len(s.buf))...)
+	}
+}
+
+// toBuffer stores an integer as bytes before the string is modified in
+// place.
+func (s *String) toBuffer() {
+	if s.isInt {
+		s.buf = s.Bytes()
+		s.n, s.isInt = 0, false
+	}
 }
 
 func (s *String) Reverse() String {
-	n := len(s.inner)
+	str := s.AsString()
+	n := len(str)
 	runes := make([]rune, n)
-	for _, rune := range s.inner {
+	for _, rune := range str {
 		n--
 		runes[n] = rune
 	}
 	return *NewString(string(runes[n:]))
 }
+
+// ParseCanonicalInt parses a string holding an integer in its canonical
+// form, without a sign for positive numbers nor leading zeros, which is
+// the form in which it is formatted back.
+func ParseCanonicalInt(s string) (int64, bool) {
+	if len(s) == 0 || len(s) > maxIntLen || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	var buf [maxIntLen]byte
+
+	if err != nil || string(strconv.AppendInt(buf[:0], n, 10)) != s {
+		return 0, false
+	}
+
+	return n, true
+}
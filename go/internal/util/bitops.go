@@ -0,0 +1,315 @@
+package util
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// Operations of BITOP.
+const (
+	BITOP_AND = iota
+	BITOP_OR
+	BITOP_XOR
+	BITOP_NOT
+	BITOP_DIFF  // The bits of the first source that are in none of the others
+	BITOP_ANDOR // The bits of the first source that are in one of the others at least
+	BITOP_ONE   // The bits that are in exactly one of the sources
+)
+
+// The bits are numbered from the most significant bit of the first byte,
+// as SETBIT and GETBIT do.
+func bitAt(b []byte, i int) bool {
+	return b[i>>3]&(0x80>>(i&7)) != 0
+}
+
+// BitCount returns the number of bits set between the bits start and end,
+// both included. The bytes in between are counted a word at a time.
+func BitCount(b []byte, start int, end int) int {
+	n := 0
+
+	for ; start <= end && start&7 != 0; start++ {
+		if bitAt(b, start) {
+			n++
+		}
+	}
+
+	if first, last := start>>3, (end+1)>>3; first < last {
+		words := b[first:last]
+
+		for len(words) >= 8 {
+			n += bits.OnesCount64(binary.LittleEndian.Uint64(words))
+			words = words[8:]
+		}
+
+		for _, c := range words {
+			n += bits.OnesCount8(c)
+		}
+
+		start = last << 3
+	}
+
+	for ; start <= end; start++ {
+		if bitAt(b, start) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// BitPos returns the position of the first bit equal to bit between the
+// bits start and end, both included, or -1 if there is none. The bytes in
+// between are skipped a word at a time.
+func BitPos(b []byte, bit bool, start int, end int) int {
+	for ; start <= end && start&7 != 0; start++ {
+		if bitAt(b, start) == bit {
+			return start
+		}
+	}
+
+	if first, last := start>>3, (end+1)>>3; first < last {
+		// The words without the bit
+		skip := uint64(0)
+
+		if !bit {
+			skip = math.MaxUint64
+		}
+
+		i := first
+
+		for ; i+8 <= last; i += 8 {
+			if w := binary.BigEndian.Uint64(b[i:]); w != skip {
+				return i<<3 + bits.LeadingZeros64(w^skip)
+			}
+		}
+
+		for ; i < last; i++ {
+			if c := b[i]; c != byte(skip) {
+				return i<<3 + bits.LeadingZeros8(c^byte(skip))
+			}
+		}
+
+		start = last << 3
+	}
+
+	for ; start <= end; start++ {
+		if bitAt(b, start) == bit {
+			return start
+		}
+	}
+
+	return -1
+}
+
+// BitOp computes the operation over the sources a word at a time. The
+// result is as long as the longest source, the others are padded with zeros.
+func BitOp(op int, srcs [][]byte) []byte {
+	n := 0
+
+	for _, src := range srcs {
+		if len(src) > n {
+			n = len(src)
+		}
+	}
+
+	res := make([]byte, n)
+
+	for i := 0; i < n; i += 8 {
+		var w uint64
+
+		switch op {
+		case BITOP_AND:
+			w = math.MaxUint64
+
+			for _, src := range srcs {
+				w &= loadWord(src, i)
+			}
+		case BITOP_OR:
+			for _, src := range srcs {
+				w |= loadWord(src, i)
+			}
+		case BITOP_XOR:
+			for _, src := range srcs {
+				w ^= loadWord(src, i)
+			}
+		case BITOP_NOT:
+			w = ^loadWord(srcs[0], i)
+		case BITOP_DIFF, BITOP_ANDOR:
+			others := uint64(0)
+
+			for _, src := range srcs[1:] {
+				others |= loadWord(src, i)
+			}
+
+			if op == BITOP_DIFF {
+				w = loadWord(srcs[0], i) &^ others
+			} else {
+				w = loadWord(srcs[0], i) & others
+			}
+		case BITOP_ONE:
+			// The bits that are in two of the sources at least
+			twice := uint64(0)
+
+			for _, src := range srcs {
+				s := loadWord(src, i)
+				twice |= w & s
+				w |= s
+			}
+
+			w &^= twice
+		}
+
+		storeWord(res, i, w)
+	}
+
+	return res
+}
+
+// loadWord returns the 8 bytes at i, padded with zeros past the end.
+func loadWord(b []byte, i int) uint64 {
+	if i+8 <= len(b) {
+		return binary.LittleEndian.Uint64(b[i:])
+	}
+
+	var buf [8]byte
+
+	if i < len(b) {
+		copy(buf[:], b[i:])
+	}
+
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+// storeWord stores the 8 bytes at i, as many as fit.
+func storeWord(b []byte, i int, w uint64) {
+	if i+8 <= len(b) {
+		binary.LittleEndian.PutUint64(b[i:], w)
+		return
+	}
+
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], w)
+	copy(b[i:], buf[:])
+}
+
+// Behaviors of BITFIELD when a value does not fit in its field.
+const (
+	BITFIELD_OVERFLOW_WRAP = iota
+	BITFIELD_OVERFLOW_SAT
+	BITFIELD_OVERFLOW_FAIL
+)
+
+// GetUnsignedBitfield returns the unsigned integer of bits bits at the bit
+// offset. The bits past the end of the string are zeros.
+func GetUnsignedBitfield(b []byte, offset int, bits int) uint64 {
+	value := uint64(0)
+
+	for i := offset; i < offset+bits; i++ {
+		value <<= 1
+
+		if i>>3 < len(b) && bitAt(b, i) {
+			value |= 1
+		}
+	}
+
+	return value
+}
+
+// GetSignedBitfield returns the two's complement integer of bits bits at
+// the bit offset.
+func GetSignedBitfield(b []byte, offset int, bits int) int64 {
+	value := GetUnsignedBitfield(b, offset, bits)
+
+	// Extend the sign
+	if bits < 64 && value&(1<<(bits-1)) != 0 {
+		value |= math.MaxUint64 << bits
+	}
+
+	return int64(value)
+}
+
+// SetBitfield stores the bits least significant bits of the value at the
+// bit offset. The string must be long enough.
+func SetBitfield(b []byte, offset int, bits int, value uint64) {
+	for i := 0; i < bits; i++ {
+		pos := offset + i
+		mask := byte(0x80 >> (pos & 7))
+
+		if value&(1<<(bits-1-i)) != 0 {
+			b[pos>>3] |= mask
+		} else {
+			b[pos>>3] &^= mask
+		}
+	}
+}
+
+// CheckUnsignedBitfieldOverflow returns whether value+incr does not fit in
+// an unsigned field of bits bits and the value to store instead, according
+// to the overflow behavior.
+func CheckUnsignedBitfieldOverflow(value uint64, incr int64, bits int, overflow int) (bool, uint64) {
+	max := uint64(1)<<bits - 1
+
+	if bits == 64 {
+		max = math.MaxUint64
+	}
+
+	maxIncr := int64(max - value)
+	minIncr := -int64(value)
+
+	switch {
+	case value > max || (incr > 0 && incr > maxIncr):
+		if overflow == BITFIELD_OVERFLOW_SAT {
+			return true, max
+		}
+	case incr < 0 && incr < minIncr:
+		if overflow == BITFIELD_OVERFLOW_SAT {
+			return true, 0
+		}
+	default:
+		return false, 0
+	}
+
+	return true, (value + uint64(incr)) & max
+}
+
+// CheckSignedBitfieldOverflow returns whether value+incr does not fit in
+// a signed field of bits bits and the value to store instead, according
+// to the overflow behavior.
+func CheckSignedBitfieldOverflow(value int64, incr int64, bits int, overflow int) (bool, int64) {
+	max := int64(1)<<(bits-1) - 1
+
+	if bits == 64 {
+		max = math.MaxInt64
+	}
+
+	min := -max - 1
+	maxIncr := max - value
+	minIncr := min - value
+
+	switch {
+	case value > max || (bits != 64 && incr > maxIncr) || (value >= 0 && incr > 0 && incr > maxIncr):
+		if overflow == BITFIELD_OVERFLOW_SAT {
+			return true, max
+		}
+	case value < min || (bits != 64 && incr < minIncr) || (value < 0 && incr < 0 && incr < minIncr):
+		if overflow == BITFIELD_OVERFLOW_SAT {
+			return true, min
+		}
+	default:
+		return false, 0
+	}
+
+	// Wrap around, keeping the sign of the field
+	c := uint64(value) + uint64(incr)
+
+	if bits < 64 {
+		if c&(1<<(bits-1)) != 0 {
+			c |= math.MaxUint64 << bits
+		} else {
+			c &^= math.MaxUint64 << bits
+		}
+	}
+
+	return true, int64(c)
+}
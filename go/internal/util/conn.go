@@ -4,18 +4,33 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"time"
 )
 
 type Conn struct {
-	conn net.Conn
+	conn    net.Conn
+	logger  ILogger
+	onError func(msg string) // Called for every error reply
+	muted   bool             // Whether the replies are discarded
 }
 
-func NewConn(conn net.Conn) *Conn {
+func NewConn(conn net.Conn, logger ILogger) *Conn {
 	return &Conn{
-		conn: conn,
+		conn:   conn,
+		logger: logger,
 	}
 }
 
+// SetErrorHook sets the function called with the message of every error reply.
+func (c *Conn) SetErrorHook(hook func(msg string)) {
+	c.onError = hook
+}
+
+// SetMuted discards the replies until it is unset, as CLIENT REPLY OFF does.
+func (c *Conn) SetMuted(muted bool) {
+	c.muted = muted
+}
+
 func (c *Conn) Close() error {
 	return c.conn.Close()
 }
@@ -24,7 +39,24 @@ func (c *Conn) Read(buffer []byte) (int, error) {
 	return c.conn.Read(buffer)
 }
 
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// SetReadDeadline unblocks the pending and future reads after t.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
 func (c *Conn) WriteAll(in []byte) error {
+	if c.muted {
+		return nil
+	}
+
 	t := 0
 
 	for t != len(in) {
@@ -52,6 +84,10 @@ func (c *Conn) WriteString(value string) bool {
 }
 
 func (c *Conn) WriteError(value string) bool {
+	if c.onError != nil {
+		c.onError(value)
+	}
+
 	err := c.WriteAll([]byte(fmt.Sprintf("-%s\r\n", value)))
 
 	if err != nil {
@@ -117,6 +153,23 @@ func (c *Conn) WriteInt64(value int64) bool {
 	return true
 }
 
+func (c *Conn) WriteBool(value bool) bool {
+	reply := "#f\r\n"
+
+	if value {
+		reply = "#t\r\n"
+	}
+
+	err := c.WriteAll([]byte(reply))
+
+	if err != nil {
+		c.HandleWriteError(err)
+		return false
+	}
+
+	return true
+}
+
 func (c *Conn) WriteArray(value int) bool {
 	err := c.WriteAll([]byte(fmt.Sprintf("*%d\r\n", value)))
 
@@ -150,6 +203,17 @@ func (c *Conn) WriteSet(value int) bool {
 	return true
 }
 
+func (c *Conn) WritePush(value int) bool {
+	err := c.WriteAll([]byte(fmt.Sprintf(">%d\r\n", value)))
+
+	if err != nil {
+		c.HandleWriteError(err)
+		return false
+	}
+
+	return true
+}
+
 func (c *Conn) WriteNull() bool {
 	err := c.WriteAll([]byte("_\r\n"))
 
@@ -184,11 +248,11 @@ func (c *Conn) WriteNullArray() bool {
 }
 
 func (c *Conn) HandleWriteError(err error) {
-	Logger.Printf("Failed to write to connection: '%s'\n", err)
+	c.logger.Printf("Failed to write to connection: '%s'\n", err)
 
 	err = c.conn.Close()
 
 	if err != nil {
-		Logger.Printf("Unable to close connection: '%s'\n", err)
+		c.logger.Printf("Unable to close connection: '%s'\n", err)
 	}
 }
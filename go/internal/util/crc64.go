@@ -0,0 +1,18 @@
+package util
+
+import (
+	"hash/crc64"
+	"math/bits"
+)
+
+// The Jones polynomial used by Redis for the checksum of the RDB payloads.
+const crc64Jones = 0xad93d23594c935a9
+
+// The table is built from the reflected polynomial because the CRC is reflected.
+var crc64JonesTable = crc64.MakeTable(bits.Reverse64(crc64Jones))
+
+// Crc64 updates the CRC-64/Jones checksum of Redis with the bytes.
+// It has no initial value nor final xor, unlike hash/crc64.
+func Crc64(crc uint64, p []byte) uint64 {
+	return ^crc64.Update(^crc, crc64JonesTable, p)
+}
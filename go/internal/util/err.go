@@ -4,6 +4,7 @@ const (
 	FormatErr             = "ERR format error"
 	SyntaxErr             = "ERR syntax error"
 	InvalidIntErr         = "ERR value is not an integer or out of range"
+	IntOverflowErr        = "ERR increment or decrement would overflow"
 	InvalidFloatErr       = "ERR value is not a valid float"
 	InvalidLexErr         = "ERR min or max not valid string range item"
 	WrongTypeErr          = "WRONGTYPE Operation against a key holding the wrong kind of value"
@@ -13,4 +14,18 @@ const (
 	OptionNotSupportedErr = "ERR option '%s' is not currently supported"
 	NegativeIntErr        = "ERR %s must be greater than 0"
 	MustBePositiveErr     = "ERR %s must be positive"
+	NoAuthErr             = "NOAUTH Authentication required."
+	OOMErr                = "OOM command not allowed when used memory > 'maxmemory'."
+	BusyErr               = "BUSY Redis is busy running a script. You can only call SCRIPT KILL or SHUTDOWN NOSAVE."
+	NoScriptErr           = "NOSCRIPT No matching script. Please use EVAL."
+	WrongPassErr          = "WRONGPASS invalid username-password pair or user is disabled."
+	NoPasswordErr         = "ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?"
+	ProtectedModeErr      = "DENIED Redis is running in protected mode because protected mode is enabled and no password is set for the default user. " +
+		"In this mode connections are only accepted from the loopback interface. " +
+		"If you want to connect from external computers to Redis you may adopt one of the following solutions: " +
+		"1) Just disable protected mode sending the command 'CONFIG SET protected-mode no' from the loopback interface by connecting to Redis from the same host the server is running, however MAKE SURE Redis is not publicly accessible from internet if you do so. " +
+		"2) Alternatively you can just disable the protected mode by editing the Redis configuration file, and setting the protected mode option to 'no', and then restarting the server. " +
+		"3) If you started the server manually just for testing, restart it with the '--protected-mode no' option. " +
+		"4) Set up an authentication password for the default user. " +
+		"NOTE: You only need to do one of the above things in order for the server to start accepting connections from the outside."
 )
@@ -13,8 +13,6 @@ type ILogger interface {
 	Println(v ...any)
 }
 
-var Logger ILogger
-
 var _ ILogger = &StubLogger{}
 
 type StubLogger struct {
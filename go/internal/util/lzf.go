@@ -0,0 +1,64 @@
+package util
+
+import "errors"
+
+var ErrLzfCorrupted = errors.New("corrupted lzf data")
+
+// LzfDecompress decompresses the data compressed with LZF by Redis into
+// length bytes.
+func LzfDecompress(in []byte, length int) ([]byte, error) {
+	out := make([]byte, 0, length)
+
+	for ip := 0; ip < len(in); {
+		ctrl := int(in[ip])
+		ip++
+
+		// A literal run of ctrl+1 bytes
+		if ctrl < 1<<5 {
+			ctrl++
+
+			if ip+ctrl > len(in) || len(out)+ctrl > length {
+				return nil, ErrLzfCorrupted
+			}
+
+			out = append(out, in[ip:ip+ctrl]...)
+			ip += ctrl
+			continue
+		}
+
+		// A back reference of n+2 bytes
+		n := ctrl >> 5
+
+		if n == 7 {
+			if ip >= len(in) {
+				return nil, ErrLzfCorrupted
+			}
+
+			n += int(in[ip])
+			ip++
+		}
+
+		if ip >= len(in) {
+			return nil, ErrLzfCorrupted
+		}
+
+		ref := len(out) - (ctrl&0x1f)<<8 - int(in[ip]) - 1
+		ip++
+		n += 2
+
+		if ref < 0 || len(out)+n > length {
+			return nil, ErrLzfCorrupted
+		}
+
+		// The reference can overlap with the bytes being copied
+		for i := 0; i < n; i++ {
+			out = append(out, out[ref+i])
+		}
+	}
+
+	if len(out) != length {
+		return nil, ErrLzfCorrupted
+	}
+
+	return out, nil
+}
@@ -0,0 +1,137 @@
+package util
+
+// StringMatch returns whether str matches the glob-style pattern.
+// This is a port of stringmatchlen from the reference implementation so
+// it supports '*', '?', '[...]' character classes and '\' escapes.
+func StringMatch(pattern string, str string, nocase bool) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// Collapse consecutive stars
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 1 {
+				return true
+			}
+
+			for len(str) > 0 {
+				if StringMatch(pattern[1:], str, nocase) {
+					return true
+				}
+				str = str[1:]
+			}
+
+			return false
+		case '?':
+			if len(str) == 0 {
+				return false
+			}
+
+			str = str[1:]
+		case '[':
+			if len(str) == 0 {
+				return false
+			}
+
+			pattern = pattern[1:]
+			not := len(pattern) > 0 && pattern[0] == '^'
+
+			if not {
+				pattern = pattern[1:]
+			}
+
+			match := false
+
+			for {
+				if len(pattern) == 0 {
+					break
+				} else if pattern[0] == '\\' && len(pattern) >= 2 {
+					pattern = pattern[1:]
+
+					if equalByte(pattern[0], str[0], nocase) {
+						match = true
+					}
+				} else if pattern[0] == ']' {
+					break
+				} else if len(pattern) >= 3 && pattern[1] == '-' {
+					start := pattern[0]
+					end := pattern[2]
+					c := str[0]
+
+					if start > end {
+						start, end = end, start
+					}
+
+					if nocase {
+						start = toLower(start)
+						end = toLower(end)
+						c = toLower(c)
+					}
+
+					pattern = pattern[2:]
+
+					if c >= start && c <= end {
+						match = true
+					}
+				} else if equalByte(pattern[0], str[0], nocase) {
+					match = true
+				}
+
+				pattern = pattern[1:]
+			}
+
+			if not {
+				match = !match
+			}
+
+			if !match {
+				return false
+			}
+
+			str = str[1:]
+
+			// Unterminated class, treat it as the end of the pattern
+			if len(pattern) == 0 {
+				return len(str) == 0
+			}
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(str) == 0 || !equalByte(pattern[0], str[0], nocase) {
+				return false
+			}
+
+			str = str[1:]
+		}
+
+		pattern = pattern[1:]
+
+		if len(str) == 0 {
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			break
+		}
+	}
+
+	return len(pattern) == 0 && len(str) == 0
+}
+
+func equalByte(a byte, b byte, nocase bool) bool {
+	if nocase {
+		return toLower(a) == toLower(b)
+	}
+	return a == b
+}
+
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
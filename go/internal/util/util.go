@@ -1,13 +1,15 @@
 package util
 
 import (
+	"fmt"
 	"math"
 	"strconv"
 	"strings"
 	"time"
 )
 
-func ParseTtlFromUnitTime(arg string, multiplier int64) (time.Time, error) {
+// ParseTtlFromUnitTime parses the relative expiry arg in the given unit from now.
+func ParseTtlFromUnitTime(now time.Time, arg string, multiplier int64) (time.Time, error) {
 	unitTime, err := strconv.ParseInt(string(arg), 10, 64)
 	if err != nil {
 		return time.Time{}, err
@@ -15,7 +17,7 @@ func ParseTtlFromUnitTime(arg string, multiplier int64) (time.Time, error) {
 	if unitTime == 0 {
 		return time.Time{}, nil
 	}
-	return time.Now().Add(time.Duration(unitTime * multiplier)), nil
+	return now.Add(time.Duration(unitTime * multiplier)), nil
 }
 
 func ParseTtlFromTimestamp(arg string, multiplier time.Duration) (time.Time, error) {
@@ -37,6 +39,40 @@ func EscapeString(s string) string {
 	return s
 }
 
+// QuoteString quotes and escapes the string as redis-cli prints it,
+// the non printable bytes are written as \xHH.
+func QuoteString(s string) string {
+	var str strings.Builder
+	str.WriteByte('"')
+
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; b {
+		case '\\', '"':
+			str.WriteByte('\\')
+			str.WriteByte(b)
+		case '\n':
+			str.WriteString("\\n")
+		case '\r':
+			str.WriteString("\\r")
+		case '\t':
+			str.WriteString("\\t")
+		case '\a':
+			str.WriteString("\\a")
+		case '\b':
+			str.WriteString("\\b")
+		default:
+			if b >= 0x20 && b < 0x7f {
+				str.WriteByte(b)
+			} else {
+				fmt.Fprintf(&str, "\\x%02x", b)
+			}
+		}
+	}
+
+	str.WriteByte('"')
+	return str.String()
+}
+
 func CollectArgs(args [][]byte) string {
 	result := ""
 	for i, arg := range args {
@@ -148,3 +184,39 @@ func ParseLexRange(start string, stop string) (string, bool, string, bool, bool)
 
 	return start, startExclusive, stop, stopExclusive, false
 }
+
+// ParseMemory parses an amount of memory as in the configuration of Redis,
+// e.g. "1024", "1k" (1000 bytes), "1kb" (1024 bytes) or "100mb".
+func ParseMemory(value string) (uint64, bool) {
+	units := []struct {
+		suffix string
+		mul    uint64
+	}{
+		{"kb", 1024},
+		{"mb", 1024 * 1024},
+		{"gb", 1024 * 1024 * 1024},
+		{"k", 1000},
+		{"m", 1000 * 1000},
+		{"g", 1000 * 1000 * 1000},
+		{"b", 1},
+	}
+
+	value = strings.ToLower(value)
+	mul := uint64(1)
+
+	for _, u := range units {
+		if strings.HasSuffix(value, u.suffix) {
+			value = strings.TrimSuffix(value, u.suffix)
+			mul = u.mul
+			break
+		}
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+
+	if err != nil || n > math.MaxUint64/mul {
+		return 0, false
+	}
+
+	return n * mul, true
+}
@@ -0,0 +1,259 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/types"
+	"github.com/hbina/radish/internal/util"
+)
+
+// Item is the value of a key.
+// Items of the types registered by modules must return TypeModule from
+// Type and the name of their type from TypeFancy.
+type Item = types.Item
+
+// TypeModule is the type id shared by all the types registered by modules.
+const TypeModule = types.ValueTypeModule
+
+// Module is a set of commands and types that extends the server, the equivalent of Redis modules.
+type Module struct {
+	Name     string
+	Version  int
+	Commands []*Command
+	Types    []*Type
+}
+
+// Command is a command added by a module.
+type Command struct {
+	Name string
+	// Number of arguments including the command name.
+	// A negative arity means that it is the minimum number of arguments.
+	Arity int
+	// Flags as given to RedisModule_CreateCommand, e.g. "write", "readonly", "deny-oom" or "fast".
+	Flags []string
+	// Positions of the keys in the arguments, 0 if the command has no keys.
+	// A negative LastKey counts from the end.
+	FirstKey int
+	LastKey  int
+	KeyStep  int
+	// Handler is called with the database locked, so it is atomic.
+	Handler func(ctx *Context, args [][]byte)
+}
+
+// Type is a value type added by a module.
+// Marshal and Unmarshal are used by DUMP and RESTORE.
+// MemUsage is optional, it returns the approximate bytes used by the item
+// for the accounting of maxmemory.
+type Type struct {
+	Name      string
+	Marshal   func(item Item) ([]byte, error)
+	Unmarshal func(data []byte) (Item, error)
+	MemUsage  func(item Item) int
+}
+
+// WithModules registers the modules when the server is started.
+func WithModules(modules ...*Module) Option {
+	return func(s *Server) {
+		s.modules = append(s.modules, modules...)
+	}
+}
+
+// Flags of the module commands, named as in the Redis modules API.
+var moduleCommandFlags = map[string]uint64{
+	"write":             pkg.CMD_WRITE,
+	"readonly":          pkg.CMD_READONLY,
+	"admin":             pkg.CMD_ADMIN,
+	"deny-oom":          pkg.CMD_DENYOOM,
+	"deny-script":       pkg.CMD_NOSCRIPT,
+	"allow-loading":     pkg.CMD_LOADING,
+	"pubsub":            pkg.CMD_PUBSUB,
+	"allow-stale":       pkg.CMD_STALE,
+	"no-monitor":        pkg.CMD_SKIP_MONITOR,
+	"no-slowlog":        pkg.CMD_SKIP_SLOWLOG,
+	"fast":              pkg.CMD_FAST,
+	"no-auth":           pkg.CMD_NO_AUTH,
+	"may-replicate":     pkg.CMD_MAY_REPLICATE,
+	"no-mandatory-keys": pkg.CMD_NO_MANDATORY_KEYS,
+	"allow-busy":        pkg.CMD_ALLOW_BUSY,
+}
+
+func registerModule(instance *pkg.Redis, m *Module) error {
+	cmds := make([]*pkg.Command, 0, len(m.Commands))
+
+	for _, cmd := range m.Commands {
+		c, err := newModuleCommand(cmd)
+
+		if err != nil {
+			return fmt.Errorf("module '%s': %s", m.Name, err.Error())
+		}
+
+		cmds = append(cmds, c)
+	}
+
+	customTypes := make([]*pkg.CustomType, 0, len(m.Types))
+
+	for _, t := range m.Types {
+		customTypes = append(customTypes, &pkg.CustomType{
+			Name:      t.Name,
+			Marshal:   t.Marshal,
+			Unmarshal: t.Unmarshal,
+			MemUsage:  t.MemUsage,
+		})
+	}
+
+	return instance.RegisterModule(&pkg.Module{Name: m.Name, Version: m.Version}, cmds, customTypes)
+}
+
+func newModuleCommand(cmd *Command) (*pkg.Command, error) {
+	name := strings.ToLower(cmd.Name)
+
+	if name == "" || cmd.Handler == nil {
+		return nil, fmt.Errorf("command '%s' must have a name and a handler", cmd.Name)
+	}
+
+	flag := pkg.CMD_MODULE
+	keyFlags := pkg.KEY_READ
+
+	for _, f := range cmd.Flags {
+		cmdFlag, ok := moduleCommandFlags[strings.ToLower(f)]
+
+		if !ok {
+			return nil, fmt.Errorf("command '%s' has an unknown flag '%s'", cmd.Name, f)
+		}
+
+		flag |= cmdFlag
+
+		if cmdFlag == pkg.CMD_WRITE {
+			keyFlags = pkg.KEY_READ | pkg.KEY_WRITE
+		}
+	}
+
+	keys := make([]pkg.KeySpec, 0, 1)
+
+	if cmd.FirstKey > 0 {
+		step := cmd.KeyStep
+
+		if step <= 0 {
+			step = 1
+		}
+
+		keys = append(keys, pkg.NewKeySpec(keyFlags, cmd.FirstKey, cmd.LastKey, step))
+	}
+
+	handler := cmd.Handler
+
+	return pkg.NewCommand(name, func(c *pkg.Client, args [][]byte) {
+		handler(&Context{c: c}, args)
+	}, cmd.Arity, flag, 0, keys...), nil
+}
+
+// Context gives the handler of a module command access to the
+// database selected by the client and to the reply.
+type Context struct {
+	c *pkg.Client
+}
+
+// Now returns the current time of the server clock.
+func (ctx *Context) Now() time.Time {
+	return ctx.c.Redis().Now()
+}
+
+// DbId returns the id of the database selected by the client.
+func (ctx *Context) DbId() uint64 {
+	return ctx.c.DbId()
+}
+
+// Get returns the item of the key or nil if it does not exist.
+func (ctx *Context) Get(key string) Item {
+	item, _ := ctx.c.Db().Get(key)
+	return item
+}
+
+// Expiry returns the expiry of the key, zero if it has none.
+func (ctx *Context) Expiry(key string) time.Time {
+	_, ttl := ctx.c.Db().Get(key)
+	return ttl
+}
+
+// Set sets the item of the key and removes its expiry.
+func (ctx *Context) Set(key string, item Item) {
+	ctx.c.Db().Set(key, item, time.Time{})
+}
+
+// SetWithExpiry sets the item of the key which expires at ttl.
+func (ctx *Context) SetWithExpiry(key string, item Item, ttl time.Time) {
+	ctx.c.Db().Set(key, item, ttl)
+}
+
+// Delete deletes the key and returns whether it existed.
+func (ctx *Context) Delete(key string) bool {
+	return ctx.c.Db().Delete(key) > 0
+}
+
+// GetString returns the value of a string key.
+// ok is false if the key does not exist, an error is returned if it is not a string.
+func (ctx *Context) GetString(key string) (value string, ok bool, err error) {
+	item := ctx.Get(key)
+
+	if item == nil {
+		return "", false, nil
+	}
+
+	if item.Type() != types.ValueTypeString {
+		return "", false, errors.New(util.WrongTypeErr)
+	}
+
+	return item.(*types.String).AsString(), true, nil
+}
+
+// SetString sets the key to the string value and keeps its expiry.
+func (ctx *Context) SetString(key string, value string) {
+	ctx.c.Db().Set(key, types.NewString(value), ctx.Expiry(key))
+}
+
+// ReplyString replies with a simple string.
+func (ctx *Context) ReplyString(value string) {
+	ctx.c.Conn().WriteString(value)
+}
+
+// ReplyError replies with an error, the message should start with an error code like "ERR".
+func (ctx *Context) ReplyError(message string) {
+	ctx.c.Conn().WriteError(message)
+}
+
+// ReplyBulkString replies with a bulk string.
+func (ctx *Context) ReplyBulkString(value string) {
+	ctx.c.Conn().WriteBulkString(value)
+}
+
+// ReplyInt replies with an integer.
+func (ctx *Context) ReplyInt(value int64) {
+	ctx.c.Conn().WriteInt64(value)
+}
+
+// ReplyFloat replies with a double, or a bulk string with RESP2.
+func (ctx *Context) ReplyFloat(value float64) {
+	if ctx.c.R3 {
+		ctx.c.Conn().WriteFloat64(value)
+	} else {
+		ctx.c.Conn().WriteBulkString(fmt.Sprint(value))
+	}
+}
+
+// ReplyNull replies with a null.
+func (ctx *Context) ReplyNull() {
+	if ctx.c.R3 {
+		ctx.c.Conn().WriteNull()
+	} else {
+		ctx.c.Conn().WriteNullBulk()
+	}
+}
+
+// ReplyArray starts an array reply of length elements, which must be replied next.
+func (ctx *Context) ReplyArray(length int) {
+	ctx.c.Conn().WriteArray(length)
+}
@@ -1,53 +1,196 @@
 package redis
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"os"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/hbina/radish/internal/commands"
 	"github.com/hbina/radish/internal/pkg"
 	"github.com/hbina/radish/internal/util"
 )
 
-var started bool = false
-
+// Run starts the server on the given port and blocks forever.
 func Run(port int, shouldLog bool) {
+	configs := commands.GenerateConfigs()
+	configs["port"] = strconv.Itoa(port)
 
-	if started {
-		return
-	}
+	RunWithConfigs(configs, shouldLog)
+}
 
-	started = true
+// RunWithConfigs starts the server with the given configurations and blocks forever.
+// For every address in "bind", the plaintext listener is started if "port"
+// is not 0 and the TLS listener is started if "tls-port" is not 0.
+// The server also listens on "unixsocket" if it is set.
+func RunWithConfigs(configs map[string]string, shouldLog bool) {
+	var logger Logger = &util.StubLogger{}
 
 	if shouldLog {
-		util.Logger = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	} else {
-		util.Logger = &util.StubLogger{}
+		logger = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
 	}
 
-	listen, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	server := NewServer(WithConfigs(configs), WithLogger(logger))
+	err := server.Start()
 
-	if err != nil || listen == nil {
-		util.Logger.Fatal(err)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	instance := pkg.Default(
-		commands.GenerateCommands(),
-		commands.GenerateBlockingCommands(),
-		commands.GenerateConfigs())
-	instance.StartKeyExpiryJob(1 * time.Second)
-	instance.StartBcmdTimeoutJob()
+	<-server.Done()
+}
+
+// listen creates the listeners for every bind address and the unix socket.
+// Bind addresses prefixed with '-' are optional and are skipped if they are not available.
+// If address is set, the plaintext listener only listens on it.
+func listen(instance *pkg.Redis, address string) ([]net.Listener, error) {
+	port, err := configPort(instance, "port")
 
-	for {
-		conn, err := listen.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsPort, err := configPort(instance, "tls-port")
+
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0)
+
+	if address != "" {
+		listen, err := net.Listen("tcp", address)
 
 		if err != nil {
-			util.Logger.Fatal(err)
+			return nil, fmt.Errorf("Could not create server TCP listening socket %s: %s", address, err.Error())
 		}
 
-		go instance.HandleClient(instance.NewClient(conn))
+		listeners = append(listeners, listen)
+		port = 0
 	}
+
+	var tlsConfig *tls.Config
+
+	if tlsPort != 0 {
+		ctx, err := newTlsContext(instance)
+
+		if err != nil {
+			closeListeners(listeners)
+			return nil, err
+		}
+
+		tlsConfig = ctx.serverConfig()
+	}
+
+	binds := []string{"*"}
+
+	if v := instance.GetConfigValue("bind"); v != nil && strings.TrimSpace(*v) != "" {
+		binds = strings.Fields(*v)
+	}
+
+	for _, bind := range binds {
+		optional := strings.HasPrefix(bind, "-")
+		host := strings.TrimPrefix(bind, "-")
+
+		network := "tcp"
+
+		if host == "*" {
+			network = "tcp4"
+			host = "0.0.0.0"
+		} else if host == "::*" {
+			network = "tcp6"
+			host = "::"
+		}
+
+		if port != 0 {
+			listen, err := net.Listen(network, net.JoinHostPort(host, strconv.Itoa(port)))
+
+			if err != nil && !optional {
+				closeListeners(listeners)
+				return nil, fmt.Errorf("Could not create server TCP listening socket %s:%d: %s", host, port, err.Error())
+			} else if err == nil {
+				listeners = append(listeners, listen)
+			}
+		}
+
+		if tlsPort != 0 {
+			listen, err := tls.Listen(network, net.JoinHostPort(host, strconv.Itoa(tlsPort)), tlsConfig)
+
+			if err != nil && !optional {
+				closeListeners(listeners)
+				return nil, fmt.Errorf("Could not create server TLS listening socket %s:%d: %s", host, tlsPort, err.Error())
+			} else if err == nil {
+				listeners = append(listeners, listen)
+			}
+		}
+	}
+
+	if v := instance.GetConfigValue("unixsocket"); v != nil && *v != "" {
+		listen, err := listenUnix(instance, *v)
+
+		if err != nil {
+			closeListeners(listeners)
+			return nil, err
+		}
+
+		listeners = append(listeners, listen)
+	}
+
+	if len(listeners) == 0 {
+		return nil, errors.New("Configured to not listen anywhere, exiting.")
+	}
+
+	return listeners, nil
+}
+
+func listenUnix(instance *pkg.Redis, path string) (net.Listener, error) {
+	// Remove the socket left behind by a previous instance
+	os.Remove(path)
+
+	listen, err := net.Listen("unix", path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed opening Unix socket: %s", err.Error())
+	}
+
+	if v := instance.GetConfigValue("unixsocketperm"); v != nil && *v != "" && *v != "0" {
+		perm, err := strconv.ParseUint(*v, 8, 32)
+
+		if err == nil {
+			err = os.Chmod(path, os.FileMode(perm))
+		}
+
+		if err != nil {
+			listen.Close()
+			return nil, fmt.Errorf("Failed to set permissions '%s' of Unix socket: %s", *v, err.Error())
+		}
+	}
+
+	return listen, nil
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, l := range listeners {
+		l.Close()
+	}
+}
+
+func configPort(instance *pkg.Redis, key string) (int, error) {
+	v := instance.GetConfigValue(key)
+
+	if v == nil {
+		return 0, nil
+	}
+
+	port, err := strconv.Atoi(*v)
+
+	if err != nil || port < 0 || port > 65535 {
+		return 0, fmt.Errorf("Invalid %s '%s'", key, *v)
+	}
+
+	return port, nil
 }
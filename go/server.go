@@ -0,0 +1,316 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hbina/radish/internal/commands"
+	"github.com/hbina/radish/internal/pkg"
+	"github.com/hbina/radish/internal/util"
+)
+
+// Clock is the source of time of the server.
+type Clock = pkg.Clock
+
+// ManualClock is a clock that only moves with Server.FastForward.
+type ManualClock = pkg.ManualClock
+
+// NewManualClock creates a clock that starts at now.
+func NewManualClock(now time.Time) *ManualClock {
+	return pkg.NewManualClock(now)
+}
+
+// Logger is the logger used by the server.
+// *log.Logger satisfies this interface.
+type Logger = util.ILogger
+
+// Option configures a Server.
+type Option func(s *Server)
+
+// WithAddress makes the server listen on the given "host:port" address instead of
+// the "bind" and "port" configurations.
+// Use port 0 to listen on any available port, see Server.Addr.
+func WithAddress(address string) Option {
+	return func(s *Server) {
+		s.address = address
+	}
+}
+
+// WithConfigs overrides the default configurations.
+func WithConfigs(configs map[string]string) Option {
+	return func(s *Server) {
+		for k, v := range configs {
+			s.configs[k] = v
+		}
+	}
+}
+
+// WithLogger sets the logger of the server.
+// Nothing is logged by default.
+func WithLogger(logger Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithClock sets the clock used for the expiries and the blocking timeouts.
+// Use a ManualClock to control the time with Server.FastForward.
+func WithClock(clock Clock) Option {
+	return func(s *Server) {
+		s.clock = clock
+	}
+}
+
+// Server is a radish instance that can be embedded in another program.
+// Every Server has its own databases, configurations and listeners
+// so several of them can run in the same process.
+type Server struct {
+	address   string
+	configs   map[string]string
+	logger    Logger
+	clock     Clock
+	modules   []*Module
+	instance  *pkg.Redis
+	listeners []net.Listener
+	mu        sync.Mutex
+	started   bool
+	closed    bool
+	done      chan struct{}
+	clients   sync.WaitGroup
+	accepts   sync.WaitGroup
+}
+
+// NewServer creates a server with the default configurations.
+// The server does not listen until Start is called.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		configs: commands.GenerateConfigs(),
+		logger:  &util.StubLogger{},
+		clock:   pkg.SystemClock{},
+		done:    make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Start creates the listeners and starts accepting clients in the background.
+// It returns once the server is listening.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return errors.New("server already started")
+	}
+
+	instance := pkg.Default(
+		commands.GenerateCommands(),
+		commands.GenerateBlockingCommands(),
+		s.configs)
+	instance.SetLogger(s.logger)
+	instance.SetClock(s.clock)
+
+	for _, m := range s.modules {
+		err := registerModule(instance, m)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if v := instance.GetConfigValue("aclfile"); v != nil && *v != "" {
+		err := instance.AclLoad()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err := instance.LoadFunctions()
+
+	if err != nil {
+		return err
+	}
+
+	listeners, err := listen(instance, s.address)
+
+	if err != nil {
+		return err
+	}
+
+	// Report the port that was actually bound
+	if s.address != "" {
+		if addr, ok := listeners[0].Addr().(*net.TCPAddr); ok {
+			instance.SetConfigValue("port", strconv.Itoa(addr.Port))
+		}
+	}
+
+	s.instance = instance
+	s.listeners = listeners
+	s.started = true
+
+	instance.StartKeyExpiryJob(1 * time.Second)
+	instance.StartStatsJob()
+	instance.StartMigrateCacheJob()
+
+	for _, l := range listeners {
+		s.accepts.Add(1)
+		go s.acceptClients(l)
+	}
+
+	return nil
+}
+
+// Addr returns the address of the first listener, or nil if the server is not started.
+func (s *Server) Addr() net.Addr {
+	addrs := s.Addrs()
+
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	return addrs[0]
+}
+
+// Addrs returns the addresses of all the listeners.
+func (s *Server) Addrs() []net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]net.Addr, 0, len(s.listeners))
+
+	for _, l := range s.listeners {
+		res = append(res, l.Addr())
+	}
+
+	return res
+}
+
+// FastForward advances the clock of the server, which must be a ManualClock.
+// The keys that expire and the blocked commands that time out are
+// handled before it returns.
+func (s *Server) FastForward(d time.Duration) error {
+	s.mu.Lock()
+	instance := s.instance
+	s.mu.Unlock()
+
+	if instance == nil {
+		return errors.New("server not started")
+	}
+
+	return instance.FastForward(d)
+}
+
+// Close stops the listeners and closes all the clients immediately.
+func (s *Server) Close() error {
+	if !s.stopListening() {
+		return nil
+	}
+
+	for _, c := range s.instance.Clients() {
+		c.Close()
+	}
+
+	s.clients.Wait()
+	s.instance.Stop()
+	return nil
+}
+
+// Shutdown stops the listeners and waits for the clients to finish their
+// pending requests before closing them.
+// If ctx is done before that, the remaining clients are closed and ctx.Err() is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if !s.stopListening() {
+		return nil
+	}
+
+	defer s.instance.Stop()
+
+	// Clients stop reading new requests but finish the current one
+	for _, c := range s.instance.Clients() {
+		c.Conn().SetReadDeadline(time.Now())
+	}
+
+	drained := make(chan struct{})
+
+	go func() {
+		s.clients.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		for _, c := range s.instance.Clients() {
+			c.Close()
+		}
+
+		<-drained
+		return ctx.Err()
+	}
+}
+
+// Done returns a channel that is closed once the server stops listening.
+func (s *Server) Done() <-chan struct{} {
+	return s.done
+}
+
+// stopListening closes the listeners and waits for the accept loops to exit.
+// It returns false if the server was not running.
+func (s *Server) stopListening() bool {
+	s.mu.Lock()
+
+	if !s.started || s.closed {
+		s.mu.Unlock()
+		return false
+	}
+
+	s.closed = true
+	closeListeners(s.listeners)
+	close(s.done)
+	s.mu.Unlock()
+
+	s.accepts.Wait()
+	return true
+}
+
+func (s *Server) acceptClients(listen net.Listener) {
+	defer s.accepts.Done()
+
+	for {
+		conn, err := listen.Accept()
+
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+
+			s.logger.Println(err)
+
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+
+			return
+		}
+
+		client := s.instance.NewClient(conn)
+		s.clients.Add(1)
+
+		go func() {
+			defer s.clients.Done()
+			s.instance.HandleClient(client)
+		}()
+	}
+}
@@ -0,0 +1,127 @@
+package test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-redis/redis"
+	"github.com/hbina/radish/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringMatch(t *testing.T) {
+	assert.True(t, util.StringMatch("*", "anything", false))
+	assert.True(t, util.StringMatch("cache:*", "cache:foo", false))
+	assert.False(t, util.StringMatch("cache:*", "session:foo", false))
+	assert.True(t, util.StringMatch("h?llo", "hello", false))
+	assert.True(t, util.StringMatch("h[ae]llo", "hallo", false))
+	assert.False(t, util.StringMatch("h[^e]llo", "hello", false))
+	assert.True(t, util.StringMatch("h[a-c]llo", "hbllo", false))
+	assert.True(t, util.StringMatch("h\\*llo", "h*llo", false))
+	assert.True(t, util.StringMatch("HELLO", "hello", true))
+}
+
+func TestAclCommand(t *testing.T) {
+	c := CreateTestClient()
+
+	s, err := c.Do("acl", "setuser", "alice", "on", ">secret", "~cache:*", "%R~shared:*", "+@read", "+set").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", s)
+
+	users, err := c.Do("acl", "users").Result()
+	assert.NoError(t, err)
+	assert.Contains(t, users, "alice")
+
+	_, err = c.Do("acl", "setuser", "alice", "+notacommand").Result()
+	assert.Error(t, err)
+
+	// Use a single connection so that AUTH applies to the following commands
+	alice := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("localhost:%d", port),
+		PoolSize: 1,
+	})
+	defer alice.Close()
+
+	_, err = alice.Do("auth", "alice", "wrong").Result()
+	assert.Error(t, err)
+
+	s, err = alice.Do("auth", "alice", "secret").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", s)
+
+	who, err := alice.Do("acl", "whoami").Result()
+	assert.Error(t, err)
+	assert.Nil(t, who)
+
+	s, err = alice.Set("cache:1", "v", 0).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", s)
+
+	_, err = alice.Set("other", "v", 0).Result()
+	assert.Equal(t, "NOPERM No permissions to access a key", err.Error())
+
+	_, err = alice.Set("shared:1", "v", 0).Result()
+	assert.Equal(t, "NOPERM No permissions to access a key", err.Error())
+
+	_, err = alice.Get("shared:1").Result()
+	assert.Equal(t, redis.Nil, err)
+
+	_, err = alice.Del("cache:1").Result()
+	assert.Equal(t, "NOPERM User alice has no permissions to run the 'del' command", err.Error())
+
+	entries, err := c.Do("acl", "log", "1").Result()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	i, err := c.Do("acl", "deluser", "alice").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), i)
+
+	_, err = c.Do("acl", "deluser", "default").Result()
+	assert.Error(t, err)
+
+	s, err = c.Do("acl", "log", "reset").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", s)
+}
+
+func TestAclSaveLoad(t *testing.T) {
+	c := CreateTestClient()
+
+	_, err := c.Do("acl", "save").Result()
+	assert.Error(t, err)
+
+	s, err := c.ConfigSet("aclfile", filepath.Join(t.TempDir(), "users.acl")).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", s)
+
+	defer c.ConfigSet("aclfile", "")
+
+	r, err := c.Do("acl", "setuser", "bob", "on", "nopass", "~*", "+get").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", r)
+
+	r, err = c.Do("acl", "save").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", r)
+
+	i, err := c.Do("acl", "deluser", "bob").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), i)
+
+	r, err = c.Do("acl", "load").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", r)
+
+	list, err := c.Do("acl", "list").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		"user bob on nopass ~* resetchannels -@all +get",
+		"user default on nopass ~* &* +@all",
+	}, list)
+
+	i, err = c.Do("acl", "deluser", "bob").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), i)
+}
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitcount(t *testing.T) {
+	c := CreateTestClient()
+
+	assert.Equal(t, int64(0), c.Do("bitcount", "missing").Val())
+	assert.NoError(t, c.Set("mykey", "foobar", 0).Err())
+	assert.Equal(t, int64(26), c.Do("bitcount", "mykey").Val())
+	assert.Equal(t, int64(4), c.Do("bitcount", "mykey", 0, 0).Val())
+	assert.Equal(t, int64(6), c.Do("bitcount", "mykey", 1, 1, "byte").Val())
+	assert.Equal(t, int64(17), c.Do("bitcount", "mykey", 5, 30, "bit").Val())
+	assert.Equal(t, int64(26), c.Do("bitcount", "mykey", 0, -1).Val())
+	assert.Equal(t, int64(0), c.Do("bitcount", "mykey", -1, -2).Val())
+	// The ends out of the string do not overflow
+	assert.Equal(t, int64(0), c.Do("bitcount", "mykey", "1152921504606846976", -1).Val())
+	assert.Equal(t, int64(0), c.Do("bitcount", "mykey", "9223372036854775807", "9223372036854775807").Val())
+	assert.Equal(t, int64(26), c.Do("bitcount", "mykey", "-1152921504606846976", "1152921504606846976").Val())
+	assert.Equal(t, int64(26), c.Do("bitcount", "mykey", "-9223372036854775808", "9223372036854775807", "bit").Val())
+	assert.Equal(t, int64(4), c.Do("bitcount", "mykey", "-9223372036854775808", "-9223372036854775808").Val())
+	assert.EqualError(t, c.Do("bitcount", "mykey", 0).Err(), "ERR syntax error")
+	assert.EqualError(t, c.Do("bitcount", "mykey", 0, 1, "word").Err(), "ERR syntax error")
+
+	// The bytes in the middle are counted a word at a time
+	assert.NoError(t, c.Set("ones", strings.Repeat("\xff", 20), 0).Err())
+	assert.Equal(t, int64(160), c.Do("bitcount", "ones").Val())
+	assert.Equal(t, int64(148), c.Do("bitcount", "ones", 3, 150, "bit").Val())
+
+	assert.NoError(t, c.LPush("list", "a").Err())
+	assert.Error(t, c.Do("bitcount", "list").Err())
+}
+
+func TestBitpos(t *testing.T) {
+	c := CreateTestClient()
+
+	assert.Equal(t, int64(-1), c.Do("bitpos", "missing", 1).Val())
+	assert.Equal(t, int64(0), c.Do("bitpos", "missing", 0).Val())
+
+	assert.NoError(t, c.Set("mykey", "\xff\xf0\x00", 0).Err())
+	assert.Equal(t, int64(12), c.Do("bitpos", "mykey", 0).Val())
+
+	assert.NoError(t, c.Set("mykey", "\x00\xff\xf0", 0).Err())
+	assert.Equal(t, int64(8), c.Do("bitpos", "mykey", 1, 0).Val())
+	assert.Equal(t, int64(16), c.Do("bitpos", "mykey", 1, 2).Val())
+	assert.Equal(t, int64(16), c.Do("bitpos", "mykey", 1, 2, -1, "byte").Val())
+	assert.Equal(t, int64(8), c.Do("bitpos", "mykey", 1, 7, 15, "bit").Val())
+	assert.Equal(t, int64(8), c.Do("bitpos", "mykey", 1, 7, -3, "bit").Val())
+	assert.Equal(t, int64(-1), c.Do("bitpos", "mykey", 1, 3).Val())
+	assert.Equal(t, int64(-1), c.Do("bitpos", "mykey", 1, "1152921504606846976").Val())
+	assert.Equal(t, int64(-1), c.Do("bitpos", "mykey", 1, "1152921504606846976", -1).Val())
+	assert.Equal(t, int64(-1), c.Do("bitpos", "mykey", 0, "9223372036854775807", "9223372036854775807").Val())
+	assert.Equal(t, int64(8), c.Do("bitpos", "mykey", 1, "-1152921504606846976", "1152921504606846976").Val())
+	assert.Equal(t, int64(8), c.Do("bitpos", "mykey", 1, "-9223372036854775808", "9223372036854775807", "bit").Val())
+	assert.EqualError(t, c.Do("bitpos", "mykey", 2).Err(), "ERR The bit argument must be 1 or 0.")
+
+	// The string is padded with zeros unless the range has an end
+	assert.NoError(t, c.Set("ones", strings.Repeat("\xff", 20), 0).Err())
+	assert.Equal(t, int64(160), c.Do("bitpos", "ones", 0).Val())
+	assert.Equal(t, int64(-1), c.Do("bitpos", "ones", 0, 0, -1).Val())
+
+	assert.NoError(t, c.SetRange("ones", 17, "\xfe").Err())
+	assert.Equal(t, int64(143), c.Do("bitpos", "ones", 0).Val())
+	assert.Equal(t, int64(143), c.Do("bitpos", "ones", 0, 5, 150, "bit").Val())
+}
+
+func TestBitop(t *testing.T) {
+	c := CreateTestClient()
+
+	assert.NoError(t, c.MSet("key1", "foobar", "key2", "abcdef").Err())
+	assert.Equal(t, int64(6), c.BitOpAnd("dest", "key1", "key2").Val())
+	assert.Equal(t, "`bc`ab", c.Get("dest").Val())
+
+	// The shorter sources are padded with zeros
+	assert.NoError(t, c.MSet("a", "\x0f", "b", "\x3c", "c", "\xf0", "long", strings.Repeat("\xff", 10)).Err())
+	assert.Equal(t, int64(10), c.BitOpAnd("dest", "long", "a").Val())
+	assert.Equal(t, "\x0f"+strings.Repeat("\x00", 9), c.Get("dest").Val())
+	assert.Equal(t, int64(10), c.BitOpOr("dest", "long", "a").Val())
+	assert.Equal(t, strings.Repeat("\xff", 10), c.Get("dest").Val())
+	assert.Equal(t, int64(10), c.BitOpXor("dest", "long", "a").Val())
+	assert.Equal(t, "\xf0"+strings.Repeat("\xff", 9), c.Get("dest").Val())
+
+	for op, expected := range map[string]string{
+		"not":   "\xf0",
+		"diff":  "\x03",
+		"andor": "\x0c",
+		"one":   "\xc3",
+	} {
+		keys := []interface{}{"a", "b", "c"}
+
+		if op == "not" {
+			keys = keys[:1]
+		}
+
+		assert.Equal(t, int64(1), c.Do(append([]interface{}{"bitop", op, "dest"}, keys...)...).Val())
+		assert.Equal(t, expected, c.Get("dest").Val(), op)
+	}
+
+	assert.EqualError(t, c.Do("bitop", "not", "dest", "a", "b").Err(), "ERR BITOP NOT must be called with a single source key.")
+	assert.EqualError(t, c.Do("bitop", "diff", "dest", "a").Err(), "ERR BITOP DIFF must be called with at least two source keys.")
+	assert.EqualError(t, c.Do("bitop", "nand", "dest", "a").Err(), "ERR syntax error")
+
+	// The destination is deleted if the result is empty
+	assert.Equal(t, int64(0), c.BitOpOr("dest", "missing").Val())
+	assert.Equal(t, int64(0), c.Exists("dest").Val())
+}
+
+func TestBitfield(t *testing.T) {
+	c := CreateTestClient()
+
+	assert.Equal(t, []interface{}{int64(1), int64(0)},
+		c.Do("bitfield", "mykey", "incrby", "i5", 100, 1, "get", "u4", 0).Val())
+	assert.Equal(t, []interface{}{int64(0), int64(-1), int64(127)},
+		c.Do("bitfield", "other", "set", "i8", "#1", -1, "get", "i8", 8, "get", "u7", 9).Val())
+	assert.Equal(t, "\x00\xff", c.Get("other").Val())
+
+	for overflow, expected := range map[string][]interface{}{
+		"wrap": {int64(1), int64(2), int64(3), int64(0)},
+		"sat":  {int64(1), int64(2), int64(3), int64(3)},
+		"fail": {int64(1), int64(2), int64(3), nil},
+	} {
+		key := "counter:" + overflow
+
+		for _, value := range expected {
+			assert.Equal(t, []interface{}{value},
+				c.Do("bitfield", key, "overflow", overflow, "incrby", "u2", 102, 1).Val(), overflow)
+		}
+	}
+
+	// The signed fields saturate at both ends
+	assert.Equal(t, []interface{}{int64(-128), int64(127)},
+		c.Do("bitfield", "signed", "overflow", "sat", "incrby", "i8", 0, -200, "incrby", "i8", 0, 1000).Val())
+	assert.Equal(t, []interface{}{int64(0), int64(-9223372036854775808)},
+		c.Do("bitfield", "i64", "set", "i64", 0, "-9223372036854775808", "get", "i64", 0).Val())
+
+	assert.Equal(t, []interface{}{int64(127)}, c.Do("bitfield_ro", "other", "get", "u7", 9).Val())
+	assert.EqualError(t, c.Do("bitfield_ro", "other", "set", "u8", 0, 1).Err(),
+		"ERR BITFIELD_RO only supports the GET subcommand")
+	assert.EqualError(t, c.Do("bitfield", "other", "get", "u64", 0).Err(),
+		"ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+	assert.EqualError(t, c.Do("bitfield", "other", "get", "u8", -1).Err(),
+		"ERR bit offset is not an integer or out of range")
+	assert.EqualError(t, c.Do("bitfield", "other", "overflow", "none").Err(), "ERR Invalid OVERFLOW type specified")
+	assert.EqualError(t, c.Do("bitfield", "other", "set", "u8", 0).Err(), "ERR syntax error")
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	c := redis.NewClient(&redis.Options{Addr: s.Addr().String(), PoolSize: 1})
+	defer c.Close()
+
+	id, err := c.Do("client", "id").Int64()
+	assert.NoError(t, err)
+	assert.Greater(t, id, int64(0))
+
+	assert.NoError(t, c.Do("client", "setname", "foo").Err())
+	assert.Equal(t, "foo", c.Do("client", "getname").Val())
+	assert.Error(t, c.Do("client", "setname", "foo bar").Err())
+
+	info, err := c.Do("client", "info").String()
+	assert.NoError(t, err)
+	assert.Contains(t, info, fmt.Sprintf("id=%d ", id))
+	assert.Contains(t, info, " name=foo ")
+	assert.Contains(t, info, " cmd=client ")
+
+	other := redis.NewClient(&redis.Options{Addr: s.Addr().String(), PoolSize: 1, MaxRetries: 0})
+	defer other.Close()
+
+	assert.NoError(t, other.Ping().Err())
+	otherId, err := other.Do("client", "id").Int64()
+	assert.NoError(t, err)
+
+	list, err := c.Do("client", "list").String()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(list, "\n"))
+
+	list, err = c.Do("client", "list", "id", otherId).String()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(list, fmt.Sprintf("id=%d ", otherId)))
+	assert.Equal(t, 1, strings.Count(list, "\n"))
+
+	// Kill the other client
+	assert.Equal(t, int64(1), c.Do("client", "kill", "id", otherId).Val())
+	assert.Error(t, other.Ping().Err())
+	assert.Equal(t, "ERR No such client", c.Do("client", "kill", "127.0.0.1:1").Err().Error())
+	assert.Equal(t, int64(0), c.Do("client", "kill", "id", id).Val())
+
+	// Only the writes are paused
+	assert.NoError(t, c.Do("client", "pause", "100000", "write").Err())
+
+	done := make(chan error)
+	go func() {
+		w := redis.NewClient(&redis.Options{Addr: s.Addr().String()})
+		defer w.Close()
+		done <- w.Set("key", "value", 0).Err()
+	}()
+
+	assert.Equal(t, redis.Nil, c.Get("key").Err())
+
+	select {
+	case <-done:
+		t.Fatal("the write was not paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.NoError(t, c.Do("client", "unpause").Err())
+	assert.NoError(t, <-done)
+	assert.Equal(t, "value", c.Get("key").Val())
+}
+
+func TestClientReply(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	_, err = conn.Write([]byte("" +
+		"*3\r\n$6\r\nCLIENT\r\n$5\r\nREPLY\r\n$3\r\nOFF\r\n" +
+		"*1\r\n$4\r\nPING\r\n" +
+		"*3\r\n$6\r\nCLIENT\r\n$5\r\nREPLY\r\n$2\r\nON\r\n" +
+		"*3\r\n$6\r\nCLIENT\r\n$5\r\nREPLY\r\n$4\r\nSKIP\r\n" +
+		"*1\r\n$4\r\nPING\r\n" +
+		"*2\r\n$4\r\nINCR\r\n$3\r\nfoo\r\n"))
+	assert.NoError(t, err)
+
+	// Only the reply to CLIENT REPLY ON and the last command are sent
+	line, err := reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "+OK\r\n", line)
+
+	line, err = reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, ":1\r\n", line)
+}
@@ -0,0 +1,71 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandCommand(t *testing.T) {
+	c := CreateTestClient()
+
+	all, err := c.Do("command").Result()
+	assert.NoError(t, err)
+
+	count, err := c.Do("command", "count").Int()
+	assert.NoError(t, err)
+	assert.Equal(t, len(all.([]interface{})), count)
+
+	v, err := c.Do("command", "info", "mset", "mget", "zunionstore").Result()
+	assert.NoError(t, err)
+
+	infos := v.([]interface{})
+	mset := infos[0].([]interface{})
+	assert.Equal(t, []interface{}{"mset", int64(-3), []interface{}{"write", "denyoom"}, int64(1), int64(-1), int64(2)}, mset[:6])
+	assert.Equal(t, []interface{}{"@write", "@string", "@slow"}, mset[6])
+
+	mget := infos[1].([]interface{})
+	assert.Equal(t, []interface{}{"readonly", "fast"}, mget[2])
+	assert.Equal(t, []interface{}{"@read", "@string", "@fast"}, mget[6])
+
+	zunionstore := infos[2].([]interface{})
+	assert.Equal(t, []interface{}{"write", "denyoom", "movablekeys"}, zunionstore[2])
+	assert.Equal(t, []interface{}{int64(1), int64(1), int64(1)}, zunionstore[3:6])
+	assert.Len(t, zunionstore[8], 2)
+
+	// Unknown commands are nil
+	v, err = c.Do("command", "info", "get", "notacommand").Result()
+	assert.NoError(t, err)
+	assert.Len(t, v, 2)
+	assert.Nil(t, v.([]interface{})[1])
+
+	keys, err := c.Do("command", "getkeys", "mset", "a", "1", "b", "2").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, keys)
+
+	keys, err = c.Do("command", "getkeys", "zunionstore", "dst", "2", "x", "y").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"dst", "x", "y"}, keys)
+
+	keys, err = c.Do("command", "getkeysandflags", "getdel", "a").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{"a", []interface{}{"RW", "access", "update"}}}, keys)
+
+	_, err = c.Do("command", "getkeys", "ping").Result()
+	assert.EqualError(t, err, "ERR The command has no key arguments")
+
+	_, err = c.Do("command", "getkeys", "get").Result()
+	assert.EqualError(t, err, "ERR Invalid number of arguments specified for command")
+
+	names, err := c.Do("command", "list", "filterby", "pattern", "zrange*").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"zrange", "zrangebylex", "zrangebyscore"}, names)
+
+	docs, err := c.Do("command", "docs", "get").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"get", []interface{}{"group", "string"}}, docs)
+
+	// The arity is checked before calling the command
+	_, err = c.Do("get", "a", "b").Result()
+	assert.EqualError(t, err, "ERR wrong number of arguments for 'get' command")
+}
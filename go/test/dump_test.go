@@ -0,0 +1,103 @@
+package test
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	radish "github.com/hbina/radish"
+	"github.com/hbina/radish/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// dumpPayload appends the RDB version and the CRC64 to a serialized object.
+func dumpPayload(object string, version uint16) string {
+	payload := binary.LittleEndian.AppendUint16([]byte(object), version)
+	return string(binary.LittleEndian.AppendUint64(payload, util.Crc64(0, payload)))
+}
+
+func TestCrc64(t *testing.T) {
+	assert.Equal(t, uint64(0xe9c6d914c4b8d9ca), util.Crc64(0, []byte("123456789")))
+}
+
+func TestDumpRestore(t *testing.T) {
+	_, c := newTestServer(t)
+
+	// The payload of DUMP in the documentation of Redis
+	assert.NoError(t, c.Restore("doc", 0, "\x00\xc0\n\t\x00\xbem\x06\x89Z(\x00\n").Err())
+	assert.Equal(t, "10", c.Get("doc").Val())
+	assert.Equal(t, dumpPayload("\x00\xc0\n", 10), c.Dump("doc").Val())
+
+	assert.NoError(t, c.Set("string", "bar", 0).Err())
+	assert.Equal(t, dumpPayload("\x00\x03bar", 10), c.Dump("string").Val())
+
+	assert.NoError(t, c.RPush("list", "a", "1", strings.Repeat("x", 100)).Err())
+	assert.NoError(t, c.SAdd("set", "a", "b", "-500").Err())
+	assert.NoError(t, c.ZAdd("zset", redis.Z{Score: 1.5, Member: "a"}, redis.Z{Score: -2, Member: "b"}).Err())
+
+	for _, key := range []string{"string", "list", "set", "zset"} {
+		dump := c.Dump(key).Val()
+		assert.NoError(t, c.Restore(key+"2", time.Minute, dump).Err())
+		assert.Equal(t, dump, c.Dump(key+"2").Val())
+	}
+
+	assert.Equal(t, []string{"a", "1", strings.Repeat("x", 100)}, c.LRange("list2", 0, -1).Val())
+	assert.ElementsMatch(t, []string{"a", "b", "-500"}, c.SMembers("set2").Val())
+	assert.Equal(t, []redis.Z{{Score: -2, Member: "b"}, {Score: 1.5, Member: "a"}},
+		c.ZRangeWithScores("zset2", 0, -1).Val())
+
+	// The payloads are rejected if their checksum or version is wrong
+	dump := []byte(c.Dump("string").Val())
+	dump[1] = 'x'
+	assert.EqualError(t, c.Restore("corrupted", 0, string(dump)).Err(),
+		"ERR DUMP payload version or checksum are wrong")
+	assert.EqualError(t, c.Restore("corrupted", 0, dumpPayload("\x00\x03bar", 12)).Err(),
+		"ERR DUMP payload version or checksum are wrong")
+	assert.EqualError(t, c.Restore("corrupted", 0, dumpPayload("\x00\x05bar", 10)).Err(),
+		"ERR Bad data format")
+	assert.EqualError(t, c.Restore("corrupted", 0, dumpPayload("\x04\x00", 10)).Err(),
+		"ERR Bad data format")
+}
+
+func TestRestoreCompactEncodings(t *testing.T) {
+	_, c := newTestServer(t)
+
+	// A quicklist of a listpack holding "a", 7 and -3
+	listpack := "\x0f\x00\x00\x00\x03\x00" + "\x81a\x02" + "\x07\x01" + "\xdf\xfd\x02" + "\xff"
+	assert.NoError(t, c.Restore("list", 0, dumpPayload("\x12\x01\x02\x0f"+listpack, 11)).Err())
+	assert.Equal(t, []string{"a", "7", "-3"}, c.LRange("list", 0, -1).Val())
+
+	// An intset of 16 bit integers
+	intset := "\x02\x00\x00\x00\x02\x00\x00\x00" + "\x01\x00" + "\xe8\x03"
+	assert.NoError(t, c.Restore("intset", 0, dumpPayload("\x0b\x0c"+intset, 10)).Err())
+	assert.ElementsMatch(t, []string{"1", "1000"}, c.SMembers("intset").Val())
+
+	// A sorted set in a listpack, with the scores after the members
+	zset := "\x11\x00\x00\x00\x04\x00" + "\x81a\x02" + "\x01\x01" + "\x81b\x02" + "\x02\x01" + "\xff"
+	assert.NoError(t, c.Restore("zset", 0, dumpPayload("\x11\x11"+zset, 10)).Err())
+	assert.Equal(t, []redis.Z{{Score: 1, Member: "a"}, {Score: 2, Member: "b"}},
+		c.ZRangeWithScores("zset", 0, -1).Val())
+
+	// A string compressed with LZF
+	assert.NoError(t, c.Restore("lzf", 0, dumpPayload("\x00\xc3\x05\x06\x01ab\x40\x01", 10)).Err())
+	assert.Equal(t, "ababab", c.Get("lzf").Val())
+}
+
+func TestDumpJson(t *testing.T) {
+	_, c := newTestServer(t, radish.WithConfigs(map[string]string{
+		"dump-payload-format": "json",
+	}))
+
+	assert.NoError(t, c.Set("foo", "bar", 0).Err())
+	dump := c.Dump("foo").Val()
+	assert.Equal(t, `{"key":"foo","type":"string","value":"ImJhciI="}`, dump)
+	assert.NoError(t, c.Restore("foo2", 0, dump).Err())
+	assert.Equal(t, "bar", c.Get("foo2").Val())
+
+	// Both formats can be restored regardless of the configuration
+	assert.NoError(t, c.ConfigSet("dump-payload-format", "rdb").Err())
+	assert.NoError(t, c.Restore("foo3", 0, dump).Err())
+	assert.Equal(t, "bar", c.Get("foo3").Val())
+}
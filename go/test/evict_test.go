@@ -0,0 +1,105 @@
+package test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	radish "github.com/hbina/radish"
+	"github.com/stretchr/testify/assert"
+)
+
+func usedMemoryDataset(t *testing.T, c *redis.Client) int64 {
+	info := parseInfo(c.Do("info", "memory").Val().(string))
+	n, err := strconv.ParseInt(info["used_memory_dataset"], 10, 64)
+	assert.NoError(t, err)
+
+	return n
+}
+
+func TestMaxmemoryNoEviction(t *testing.T) {
+	_, c := newTestServer(t, radish.WithConfigs(map[string]string{
+		"maxmemory": "4kb",
+	}))
+
+	value := strings.Repeat("x", 100)
+	var err error
+
+	for i := 0; i < 100 && err == nil; i++ {
+		err = c.Set(fmt.Sprintf("key:%d", i), value, 0).Err()
+	}
+
+	// The commands that do not grow the memory are still allowed
+	assert.EqualError(t, err, "OOM command not allowed when used memory > 'maxmemory'.")
+	assert.Equal(t, value, c.Get("key:0").Val())
+	assert.Equal(t, int64(1), c.Del("key:0").Val())
+	assert.NoError(t, c.ConfigSet("maxmemory", "0").Err())
+	assert.NoError(t, c.Set("key:0", value, 0).Err())
+}
+
+func TestMaxmemoryEviction(t *testing.T) {
+	for _, policy := range []string{"allkeys-lru", "allkeys-lfu", "allkeys-random"} {
+		t.Run(policy, func(t *testing.T) {
+			_, c := newTestServer(t, radish.WithConfigs(map[string]string{
+				"maxmemory":        "8kb",
+				"maxmemory-policy": policy,
+			}))
+
+			value := strings.Repeat("x", 100)
+
+			for i := 0; i < 200; i++ {
+				assert.NoError(t, c.Set(fmt.Sprintf("key:%d", i), value, 0).Err())
+			}
+
+			size := c.DBSize().Val()
+			assert.Greater(t, size, int64(0))
+			assert.Less(t, size, int64(200))
+
+			// The memory is back under the limit before the next command
+			assert.NoError(t, c.Ping().Err())
+			assert.LessOrEqual(t, usedMemoryDataset(t, c), int64(8*1024))
+
+			info := parseInfo(c.Do("info", "stats").Val().(string))
+			assert.Equal(t, strconv.FormatInt(200-size, 10), info["evicted_keys"])
+		})
+	}
+}
+
+func TestMaxmemoryVolatileTtl(t *testing.T) {
+	_, c := newTestServer(t, radish.WithConfigs(map[string]string{
+		"maxmemory":         "8kb",
+		"maxmemory-policy":  "volatile-ttl",
+		"maxmemory-samples": "64",
+	}))
+
+	value := strings.Repeat("x", 100)
+
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, c.Set(fmt.Sprintf("persistent:%d", i), value, 0).Err())
+	}
+
+	// The keys that expire the soonest are evicted first
+	var err error
+
+	for i := 0; i < 100 && err == nil; i++ {
+		err = c.Set(fmt.Sprintf("volatile:%d", i), value, time.Duration(1000-i)*time.Second).Err()
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), c.Exists("volatile:0").Val())
+	assert.Equal(t, int64(0), c.Exists("volatile:98").Val())
+
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, int64(1), c.Exists(fmt.Sprintf("persistent:%d", i)).Val())
+	}
+
+	// Only the keys with an expiry can be evicted
+	for i := 20; i < 200 && err == nil; i++ {
+		err = c.Set(fmt.Sprintf("persistent:%d", i), value, 0).Err()
+	}
+
+	assert.EqualError(t, err, "OOM command not allowed when used memory > 'maxmemory'.")
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"testing"
+
+	radish "github.com/hbina/radish"
+	"github.com/stretchr/testify/assert"
+)
+
+const testLibrary = `#!lua name=mylib
+local function incr(keys, args)
+  return redis.call('incrby', keys[1], args[1])
+end
+
+redis.register_function('myincr', incr)
+redis.register_function{
+  function_name = 'myget',
+  callback = function(keys) return redis.call('get', keys[1]) end,
+  flags = {'no-writes'},
+  description = 'Gets the key',
+}`
+
+func TestFunction(t *testing.T) {
+	_, c := newTestServer(t)
+
+	assert.Equal(t, "mylib", c.Do("function", "load", testLibrary).Val())
+	assert.EqualError(t, c.Do("function", "load", testLibrary).Err(), "ERR Library 'mylib' already exists")
+	assert.Equal(t, "mylib", c.Do("function", "load", "replace", testLibrary).Val())
+
+	assert.Equal(t, int64(5), c.Do("fcall", "myincr", 1, "foo", 5).Val())
+	assert.Equal(t, "5", c.Do("fcall_ro", "myget", 1, "foo").Val())
+	assert.EqualError(t, c.Do("fcall_ro", "myincr", 1, "foo", 5).Err(),
+		"ERR Can not execute a script with write flag using *_ro command.")
+	assert.EqualError(t, c.Do("fcall", "missing", 0).Err(), "ERR Function not found")
+
+	// The errors are reported with the name of the function
+	assert.NoError(t, c.Set("bar", "baz", 0).Err())
+	assert.EqualError(t, c.Do("fcall", "myincr", 1, "bar", 5).Err(),
+		"ERR value is not an integer or out of range script: myincr, on @user_function:3.")
+
+	// The functions of the other libraries can not be replaced
+	assert.EqualError(t, c.Do("function", "load", "#!lua name=other\nredis.register_function('myget', function() end)").Err(),
+		"ERR Function myget already exists")
+	assert.EqualError(t, c.Do("function", "load", "return 1").Err(), "ERR Missing library metadata")
+	assert.EqualError(t, c.Do("function", "load", "#!lua name=empty\nlocal a = 1").Err(), "ERR No functions registered")
+	assert.EqualError(t, c.Do("function", "load", "#!lua name=calls\nredis.call('ping')").Err(),
+		"ERR Error registering functions: user_function:2: "+
+			"redis.call and redis.pcall can only be called inside a script invocation")
+
+	assert.Equal(t, []interface{}{
+		[]interface{}{
+			"library_name", "mylib",
+			"engine", "LUA",
+			"functions", []interface{}{
+				[]interface{}{"name", "myget", "description", "Gets the key", "flags", []interface{}{"no-writes"}},
+				[]interface{}{"name", "myincr", "description", nil, "flags", []interface{}{}},
+			},
+			"library_code", testLibrary,
+		},
+	}, c.Do("function", "list", "libraryname", "my*", "withcode").Val())
+	assert.Equal(t, []interface{}{}, c.Do("function", "list", "libraryname", "other*").Val())
+
+	stats := c.Do("function", "stats").Val().([]interface{})
+	assert.Equal(t, []interface{}{"LUA", []interface{}{"libraries_count", int64(1), "functions_count", int64(2)}}, stats[3])
+
+	// The libraries are moved with DUMP and RESTORE
+	payload := c.Do("function", "dump").Val()
+	assert.Equal(t, "OK", c.Do("function", "delete", "mylib").Val())
+	assert.EqualError(t, c.Do("function", "delete", "mylib").Err(), "ERR Library not found")
+	assert.EqualError(t, c.Do("fcall", "myincr", 1, "foo", 5).Err(), "ERR Function not found")
+
+	assert.Equal(t, "OK", c.Do("function", "restore", payload).Val())
+	assert.EqualError(t, c.Do("function", "restore", payload).Err(), "ERR Library 'mylib' already exists")
+	assert.Equal(t, "OK", c.Do("function", "restore", payload, "replace").Val())
+	assert.EqualError(t, c.Do("function", "restore", "bad payload").Err(), "ERR payload version or checksum are wrong")
+	assert.Equal(t, int64(10), c.Do("fcall", "myincr", 1, "foo", 5).Val())
+
+	assert.Equal(t, "OK", c.Do("function", "flush").Val())
+	assert.Equal(t, []interface{}{}, c.Do("function", "list").Val())
+}
+
+func TestFunctionPersistence(t *testing.T) {
+	configs := radish.WithConfigs(map[string]string{
+		"dir": t.TempDir(),
+	})
+
+	s, c := newTestServer(t, configs)
+	assert.Equal(t, "mylib", c.Do("function", "load", testLibrary).Val())
+	s.Close()
+
+	// The libraries are loaded again after a restart
+	_, c = newTestServer(t, configs)
+
+	assert.Equal(t, int64(3), c.Do("fcall", "myincr", 1, "foo", 3).Val())
+}
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	radish "github.com/hbina/radish"
+	"github.com/stretchr/testify/assert"
+)
+
+// parseInfo returns the fields of an INFO reply by their name.
+func parseInfo(info string) map[string]string {
+	res := make(map[string]string, 0)
+
+	for _, line := range strings.Split(info, "\r\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			res[line[:i]] = line[i+1:]
+		}
+	}
+
+	return res
+}
+
+func TestInfo(t *testing.T) {
+	s, c := newTestServer(t)
+
+	assert.NoError(t, c.Set("foo", "bar", 0).Err())
+	assert.NoError(t, c.Set("baz", "qux", time.Hour).Err())
+	assert.NoError(t, c.Get("foo").Err())
+	assert.Equal(t, redis.Nil, c.Get("missing").Err())
+	assert.Error(t, c.Do("incr", "foo").Err())
+	assert.Error(t, c.Do("lpush", "foo", "bar").Err())
+	assert.Error(t, c.Do("get").Err())
+
+	info, err := c.Info().Result()
+	assert.NoError(t, err)
+
+	for _, section := range []string{"# Server", "# Clients", "# Memory", "# Persistence",
+		"# Stats", "# Replication", "# CPU", "# Errorstats", "# Keyspace"} {
+		assert.Contains(t, info, section)
+	}
+
+	assert.NotContains(t, info, "# Commandstats")
+
+	fields := parseInfo(info)
+	assert.Equal(t, s.Addr().String()[len("127.0.0.1:"):], fields["tcp_port"])
+	assert.Equal(t, "1", fields["connected_clients"])
+	assert.Equal(t, "1", fields["keyspace_hits"])
+	assert.Equal(t, "1", fields["keyspace_misses"])
+	assert.Equal(t, "6", fields["total_commands_processed"])
+	assert.Equal(t, "count=1", fields["errorstat_WRONGTYPE"])
+	assert.Equal(t, "count=2", fields["errorstat_ERR"])
+	assert.True(t, strings.HasPrefix(fields["db0"], "keys=2,expires=1,avg_ttl="))
+
+	info, err = c.Do("info", "commandstats", "LATENCYSTATS").String()
+	assert.NoError(t, err)
+	assert.NotContains(t, info, "# Server")
+
+	fields = parseInfo(info)
+	assert.True(t, strings.HasPrefix(fields["cmdstat_set"], "calls=2,usec="))
+	assert.True(t, strings.HasSuffix(fields["cmdstat_incr"], "rejected_calls=0,failed_calls=1"))
+	assert.True(t, strings.HasSuffix(fields["cmdstat_get"], "rejected_calls=1,failed_calls=0"))
+	assert.Contains(t, fields["latency_percentiles_usec_set"], "p50=")
+	assert.Contains(t, fields["latency_percentiles_usec_set"], "p99.9=")
+
+	assert.NoError(t, c.Do("config", "resetstat").Err())
+
+	info, err = c.Info("stats").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "0", parseInfo(info)["keyspace_hits"])
+}
+
+func TestInfoKeyspace(t *testing.T) {
+	s, c := newTestServer(t, radish.WithClock(radish.NewManualClock(time.Unix(1700000000, 0))))
+
+	keyspace := func() map[string]string {
+		return parseInfo(c.Info("keyspace").Val())
+	}
+
+	assert.NoError(t, c.Set("a", "1", 10*time.Second).Err())
+	assert.NoError(t, c.Set("b", "1", 20*time.Second).Err())
+	assert.NoError(t, c.Set("c", "1", 0).Err())
+	assert.Equal(t, "keys=3,expires=2,avg_ttl=15000", keyspace()["db0"])
+
+	assert.NoError(t, s.FastForward(5*time.Second))
+	assert.NoError(t, c.Do("getex", "b", "persist").Err())
+	assert.NoError(t, c.Expire("c", 3*time.Second).Err())
+	assert.Equal(t, "keys=3,expires=2,avg_ttl=4000", keyspace()["db0"])
+
+	assert.NoError(t, c.Del("a").Err())
+	assert.NoError(t, c.Set("c", "2", 0).Err())
+	assert.Equal(t, "keys=2,expires=0,avg_ttl=0", keyspace()["db0"])
+
+	assert.NoError(t, c.FlushDB().Err())
+	assert.NotContains(t, keyspace(), "db0")
+
+	// The clients of different databases do not wait for each other,
+	// the expiring keys make INFO slow without the counters
+	done := make(chan error)
+
+	for db := 0; db < 2; db++ {
+		go func(db int) {
+			other := redis.NewClient(&redis.Options{Addr: s.Addr().String(), DB: db})
+			defer other.Close()
+
+			other.Eval("for i = 1, 10000 do redis.call('set', i, i, 'ex', 100) end", nil)
+			pipe := other.Pipeline()
+
+			for i := 0; i < 200; i++ {
+				pipe.Info("keyspace")
+				pipe.Do("memory", "stats")
+			}
+
+			_, err := pipe.Exec()
+			done <- err
+		}(db)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("the clients are deadlocked")
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package test
+
+import (
+	"testing"
+
+	radish "github.com/hbina/radish"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatency(t *testing.T) {
+	_, c := newTestServer(t, radish.WithConfigs(map[string]string{"latency-monitor-threshold": "10"}))
+
+	assert.Contains(t, c.Do("latency", "doctor").Val(), "no latency spike was observed")
+
+	assert.NoError(t, c.Do("debug", "sleep", "0.02").Err())
+	assert.NoError(t, c.Ping().Err())
+
+	latest, err := c.Do("latency", "latest").Result()
+	assert.NoError(t, err)
+	assert.Len(t, latest, 1)
+
+	entry := latest.([]interface{})[0].([]interface{})
+	assert.Equal(t, "command", entry[0])
+	assert.GreaterOrEqual(t, entry[2].(int64), int64(20))
+	assert.Equal(t, entry[2], entry[3])
+
+	history, err := c.Do("latency", "history", "command").Result()
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	graph, err := c.Do("latency", "graph", "command").String()
+	assert.NoError(t, err)
+	assert.Contains(t, graph, "command - high")
+	assert.Error(t, c.Do("latency", "graph", "expire-cycle").Err())
+
+	assert.Contains(t, c.Do("latency", "doctor").Val(), "1. command: 1 latency spikes")
+
+	histogram, err := c.Do("latency", "histogram", "ping", "unknown").Result()
+	assert.NoError(t, err)
+	assert.Len(t, histogram, 2)
+	assert.Equal(t, "ping", histogram.([]interface{})[0])
+
+	assert.Equal(t, int64(0), c.Do("latency", "reset", "expire-cycle").Val())
+	assert.Equal(t, int64(1), c.Do("latency", "reset").Val())
+	assert.Len(t, c.Do("latency", "latest").Val(), 0)
+}
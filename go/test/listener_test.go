@@ -0,0 +1,50 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-redis/redis"
+	radish "github.com/hbina/radish"
+	"github.com/hbina/radish/internal/commands"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnixSocketListener(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "radish.sock")
+
+	configs := commands.GenerateConfigs()
+	configs["port"] = "6391"
+	configs["bind"] = "127.0.0.1 -10.255.255.1"
+	configs["unixsocket"] = socket
+	configs["unixsocketperm"] = "700"
+
+	server := radish.NewServer(radish.WithConfigs(configs))
+	assert.NoError(t, server.Start())
+	defer server.Close()
+
+	info, err := os.Stat(socket)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	c := redis.NewClient(&redis.Options{
+		Network: "unix",
+		Addr:    socket,
+	})
+	defer c.Close()
+
+	s, err := c.Set("foo", "bar", 0).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", s)
+
+	// Both listeners are feeding the same instance
+	c2 := redis.NewClient(&redis.Options{
+		Addr: "127.0.0.1:6391",
+	})
+	defer c2.Close()
+
+	s, err = c2.Get("foo").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", s)
+}
@@ -0,0 +1,71 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryUsage(t *testing.T) {
+	_, c := newTestServer(t)
+
+	usage := func(args ...interface{}) int64 {
+		return c.Do(append([]interface{}{"memory", "usage"}, args...)...).Val().(int64)
+	}
+
+	assert.NoError(t, c.Set("small", "x", 0).Err())
+	assert.NoError(t, c.Set("big", strings.Repeat("x", 10000), 0).Err())
+	assert.Greater(t, usage("small"), int64(0))
+	assert.Greater(t, usage("big"), usage("small")+9000)
+
+	// The size of the members is estimated from the samples
+	assert.NoError(t, c.RPush("list", "a", "b", "c", "d", "e", "f", strings.Repeat("x", 1000)).Err())
+	assert.Less(t, usage("list", "samples", 1), usage("list", "samples", 0))
+
+	assert.NoError(t, c.SAdd("set", "a", "b", "c").Err())
+	assert.NoError(t, c.ZAdd("zset", redis.Z{Score: 1, Member: "a"}).Err())
+	assert.Greater(t, usage("set"), int64(0))
+	assert.Greater(t, usage("zset"), int64(0))
+
+	assert.Equal(t, nil, c.Do("memory", "usage", "missing").Val())
+	assert.EqualError(t, c.Do("memory", "usage", "small", "samples").Err(), "ERR syntax error")
+	assert.EqualError(t, c.Do("memory", "usage", "small", "samples", -1).Err(),
+		"ERR value is not an integer or out of range")
+}
+
+func TestMemoryStats(t *testing.T) {
+	s, c := newTestServer(t)
+
+	other := redis.NewClient(&redis.Options{Addr: s.Addr().String(), DB: 2})
+	defer other.Close()
+
+	assert.NoError(t, c.Set("foo", "bar", 0).Err())
+	assert.NoError(t, other.Set("foo", "bar", 0).Err())
+
+	res, err := c.Do("memory", "stats").Result()
+	assert.NoError(t, err)
+
+	stats := make(map[string]interface{}, 0)
+	reply := res.([]interface{})
+
+	for i := 0; i+1 < len(reply); i += 2 {
+		stats[reply[i].(string)] = reply[i+1]
+	}
+
+	assert.Equal(t, int64(2), stats["keys.count"])
+	assert.Contains(t, stats, "db.0")
+	assert.Contains(t, stats, "db.2")
+	assert.NotContains(t, stats, "db.1")
+	assert.Greater(t, stats["dataset.bytes"], int64(0))
+	assert.GreaterOrEqual(t, stats["peak.allocated"], stats["total.allocated"])
+	assert.Equal(t, []interface{}{
+		"overhead.hashtable.main", int64(32),
+		"overhead.hashtable.expires", int64(40),
+	}, stats["db.0"])
+
+	doctor, err := c.Do("memory", "doctor").Result()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doctor.(string), "Hi Sam") || strings.HasPrefix(doctor.(string), "Sam"))
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	radish "github.com/hbina/radish"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	src, c := newTestServer(t, radish.WithClock(radish.NewManualClock(time.Unix(1700000000, 0))))
+	dst, _ := newTestServer(t)
+
+	d := redis.NewClient(&redis.Options{Addr: dst.Addr().String(), DB: 3})
+	defer d.Close()
+
+	host, port, _ := net.SplitHostPort(dst.Addr().String())
+
+	assert.NoError(t, c.Set("foo", "bar", time.Minute).Err())
+	assert.Equal(t, "OK", c.Migrate(host, port, "foo", 3, time.Second).Val())
+	assert.Equal(t, int64(0), c.Exists("foo").Val())
+	assert.Equal(t, "bar", d.Get("foo").Val())
+	assert.InDelta(t, time.Minute, d.PTTL("foo").Val(), float64(time.Second))
+
+	assert.Equal(t, "NOKEY", c.Migrate(host, port, "foo", 3, time.Second).Val())
+
+	// The existing keys are only replaced with REPLACE
+	assert.NoError(t, c.MSet("a", "1", "b", "2", "foo", "baz").Err())
+	assert.EqualError(t, c.Do("migrate", host, port, "", 3, 1000, "copy", "keys", "a", "foo").Err(),
+		"ERR Target instance replied with error: BUSYKEY Target key name already exists.")
+	assert.Equal(t, "1", d.Get("a").Val())
+	assert.Equal(t, "bar", d.Get("foo").Val())
+
+	assert.Equal(t, "OK", c.Do("migrate", host, port, "", 3, 1000, "replace", "keys", "a", "b", "foo", "missing").Val())
+	assert.Equal(t, int64(0), c.Exists("a", "b", "foo").Val())
+	assert.Equal(t, []interface{}{"1", "2", "baz"}, d.MGet("a", "b", "foo").Val())
+
+	assert.EqualError(t, c.Do("migrate", host, port, "a", 3, 1000, "keys", "b").Err(),
+		"ERR When using MIGRATE KEYS option, the key argument must be set to the empty string")
+
+	// The connection is cached until it is idle for 10 seconds
+	assert.Equal(t, "1", parseInfo(c.Info("stats").Val())["migrate_cached_sockets"])
+	assert.NoError(t, src.FastForward(11*time.Second))
+	assert.Equal(t, "0", parseInfo(c.Info("stats").Val())["migrate_cached_sockets"])
+}
+
+func TestMigrateAuth(t *testing.T) {
+	_, c := newTestServer(t)
+	dst, _ := newTestServer(t, radish.WithConfigs(map[string]string{
+		"requirepass": "secret",
+	}))
+
+	host, port, _ := net.SplitHostPort(dst.Addr().String())
+
+	assert.NoError(t, c.Set("foo", "bar", 0).Err())
+	assert.Error(t, c.Migrate(host, port, "foo", 0, time.Second).Err())
+	assert.Equal(t, int64(1), c.Exists("foo").Val())
+
+	assert.Equal(t, "OK", c.Do("migrate", host, port, "foo", 0, 1000, "auth2", "default", "secret").Val())
+	assert.Equal(t, int64(0), c.Exists("foo").Val())
+
+	// The passwords are not logged
+	assert.NoError(t, c.ConfigSet("slowlog-log-slower-than", "0").Err())
+	c.Do("migrate", host, port, "foo", 0, 1000, "auth", "secret")
+	entries, err := c.Do("slowlog", "get", 1).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"migrate", host, port, "foo", "0", "1000", "auth", "(redacted)"},
+		entries.([]interface{})[0].([]interface{})[3])
+
+	// The instance is not reachable once it is closed
+	dst.Close()
+	assert.Equal(t, "NOKEY", c.Do("migrate", host, port, "", 0, 100, "keys", "foo", "bar").Val())
+	assert.NoError(t, c.Set("foo", "bar", 0).Err())
+	assert.EqualError(t, c.Migrate(host, port, "foo", 0, 100*time.Millisecond).Err(),
+		"IOERR error or timeout connecting to the client")
+}
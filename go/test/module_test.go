@@ -0,0 +1,98 @@
+package test
+
+import (
+	"strconv"
+	"testing"
+
+	radish "github.com/hbina/radish"
+	"github.com/stretchr/testify/assert"
+)
+
+type counter struct {
+	n int64
+}
+
+func (c *counter) Value() interface{} { return c.n }
+func (c *counter) Type() uint64       { return radish.TypeModule }
+func (c *counter) TypeFancy() string  { return "counter" }
+
+func TestModule(t *testing.T) {
+	m := &radish.Module{
+		Name:    "counters",
+		Version: 3,
+		Commands: []*radish.Command{{
+			Name:     "counter.incr",
+			Arity:    2,
+			Flags:    []string{"write", "fast"},
+			FirstKey: 1,
+			LastKey:  1,
+			Handler: func(ctx *radish.Context, args [][]byte) {
+				key := string(args[1])
+				c, ok := ctx.Get(key).(*counter)
+
+				if !ok {
+					if ctx.Get(key) != nil {
+						ctx.ReplyError("WRONGTYPE Operation against a key holding the wrong kind of value")
+						return
+					}
+
+					c = &counter{}
+				}
+
+				c.n++
+				ctx.Set(key, c)
+				ctx.ReplyInt(c.n)
+			},
+		}},
+		Types: []*radish.Type{{
+			Name: "counter",
+			Marshal: func(item radish.Item) ([]byte, error) {
+				return []byte(strconv.FormatInt(item.(*counter).n, 10)), nil
+			},
+			Unmarshal: func(data []byte) (radish.Item, error) {
+				n, err := strconv.ParseInt(string(data), 10, 64)
+				return &counter{n: n}, err
+			},
+		}},
+	}
+
+	_, c := newTestServer(t, radish.WithModules(m))
+
+	for i := int64(1); i <= 3; i++ {
+		n, err := c.Do("counter.incr", "hits").Int64()
+		assert.NoError(t, err)
+		assert.Equal(t, i, n)
+	}
+
+	_, err := c.Do("counter.incr").Result()
+	assert.EqualError(t, err, "ERR wrong number of arguments for 'counter.incr' command")
+
+	typ, err := c.Type("hits").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "counter", typ)
+
+	// Custom types can be dumped and restored
+	dump, err := c.Dump("hits").Result()
+	assert.NoError(t, err)
+	assert.NoError(t, c.Restore("hits2", 0, dump).Err())
+
+	n, err := c.Do("counter.incr", "hits2").Int64()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), n)
+
+	modules, err := c.Do("module", "list").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{"name", "counters", "ver", int64(3), "path", "", "args", []interface{}{}},
+	}, modules)
+
+	// Modules cannot override the existing commands
+	s2 := radish.NewServer(radish.WithAddress("127.0.0.1:0"), radish.WithModules(&radish.Module{
+		Name: "bad",
+		Commands: []*radish.Command{{
+			Name:    "get",
+			Handler: func(ctx *radish.Context, args [][]byte) {},
+		}},
+	}))
+	assert.Error(t, s2.Start())
+}
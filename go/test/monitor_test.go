@@ -0,0 +1,61 @@
+package test
+
+import (
+	"bufio"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitor(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	_, err = conn.Write([]byte("*1\r\n$7\r\nMONITOR\r\n"))
+	assert.NoError(t, err)
+
+	line, err := reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "+OK\r\n", line)
+
+	c := redis.NewClient(&redis.Options{Addr: s.Addr().String()})
+	defer c.Close()
+
+	assert.NoError(t, c.ConfigSet("slowlog-max-len", "10").Err())
+	assert.NoError(t, c.Set("foo", "a \"b\"\n", 0).Err())
+	assert.Error(t, c.Do("auth", "secret").Err())
+	assert.NoError(t, c.Do("select", "2").Err())
+	assert.Equal(t, redis.Nil, c.Do("get", "foo").Err())
+
+	// The admin commands are not sent and the passwords are hidden
+	expected := []string{
+		`^\+\d+\.\d{6} \[0 127\.0\.0\.1:\d+\] "set" "foo" "a \\"b\\"\\n"\r\n$`,
+		`^\+\d+\.\d{6} \[0 127\.0\.0\.1:\d+\] "auth" "\(redacted\)"\r\n$`,
+		`^\+\d+\.\d{6} \[2 127\.0\.0\.1:\d+\] "select" "2"\r\n$`,
+		`^\+\d+\.\d{6} \[2 127\.0\.0\.1:\d+\] "get" "foo"\r\n$`,
+	}
+
+	for _, pattern := range expected {
+		line, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+		assert.Regexp(t, regexp.MustCompile(pattern), line)
+	}
+
+	// The monitor cannot access the keyspace
+	_, err = conn.Write([]byte("*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n"))
+	assert.NoError(t, err)
+
+	line, err = reader.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "-ERR Replica can't interact with the keyspace\r\n", line)
+}
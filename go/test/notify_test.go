@@ -0,0 +1,161 @@
+package test
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	radish "github.com/hbina/radish"
+	"github.com/stretchr/testify/assert"
+)
+
+// receiveMessages receives the given number of pubsub messages as channel and payload pairs.
+func receiveMessages(t *testing.T, ps *redis.PubSub, n int) [][2]string {
+	res := make([][2]string, 0, n)
+
+	for len(res) < n {
+		msg, err := ps.ReceiveTimeout(5 * time.Second)
+
+		if !assert.NoError(t, err) {
+			break
+		}
+
+		if m, ok := msg.(*redis.Message); ok {
+			res = append(res, [2]string{m.Channel, m.Payload})
+		}
+	}
+
+	return res
+}
+
+func TestKeyspaceNotifications(t *testing.T) {
+	s, c := newTestServer(t,
+		radish.WithClock(radish.NewManualClock(time.Unix(1700000000, 0))),
+		radish.WithConfigs(map[string]string{"notify-keyspace-events": "KEA"}))
+
+	ps := c.PSubscribe("__key*@0__:*")
+	defer ps.Close()
+
+	_, err := ps.Receive()
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Set("foo", "bar", 0).Err())
+	assert.NoError(t, c.LPush("list", "a").Err())
+	assert.NoError(t, c.Del("foo").Err())
+	assert.Equal(t, [][2]string{
+		{"__keyspace@0__:foo", "set"},
+		{"__keyevent@0__:set", "foo"},
+		{"__keyspace@0__:list", "lpush"},
+		{"__keyevent@0__:lpush", "list"},
+		{"__keyspace@0__:foo", "del"},
+		{"__keyevent@0__:del", "foo"},
+	}, receiveMessages(t, ps, 6))
+
+	// A key emptied by a command is deleted after the command is notified
+	assert.Equal(t, "a", c.LPop("list").Val())
+	assert.Equal(t, [][2]string{
+		{"__keyspace@0__:list", "lpop"},
+		{"__keyevent@0__:lpop", "list"},
+		{"__keyspace@0__:list", "del"},
+		{"__keyevent@0__:del", "list"},
+	}, receiveMessages(t, ps, 4))
+	assert.Equal(t, int64(0), c.Exists("list").Val())
+
+	assert.NoError(t, c.SAdd("set", "a").Err())
+	assert.NoError(t, c.ZAdd("zset", redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"}).Err())
+	receiveMessages(t, ps, 4)
+
+	assert.Equal(t, "a", c.SPop("set").Val())
+	assert.Equal(t, int64(1), c.ZRemRangeByScore("zset", "1", "1").Val())
+	assert.Equal(t, int64(1), c.ZRemRangeByRank("zset", 0, -1).Val())
+	assert.Equal(t, [][2]string{
+		{"__keyspace@0__:set", "spop"},
+		{"__keyevent@0__:spop", "set"},
+		{"__keyspace@0__:set", "del"},
+		{"__keyevent@0__:del", "set"},
+		{"__keyspace@0__:zset", "zremrangebyscore"},
+		{"__keyevent@0__:zremrangebyscore", "zset"},
+		{"__keyspace@0__:zset", "zremrangebyrank"},
+		{"__keyevent@0__:zremrangebyrank", "zset"},
+		{"__keyspace@0__:zset", "del"},
+		{"__keyevent@0__:del", "zset"},
+	}, receiveMessages(t, ps, 10))
+	assert.Equal(t, int64(0), c.Exists("set", "zset").Val())
+
+	// The keys deleted by any command publish a del event
+	assert.NoError(t, c.Set("dst", "a", 0).Err())
+	assert.NoError(t, c.Set("old", "a", 0).Err())
+	receiveMessages(t, ps, 4)
+
+	assert.Equal(t, int64(0), c.BitOpAnd("dst", "missing1", "missing2").Val())
+	assert.True(t, c.Expire("old", -time.Second).Val())
+	assert.Equal(t, [][2]string{
+		{"__keyspace@0__:dst", "del"},
+		{"__keyevent@0__:del", "dst"},
+		{"__keyspace@0__:old", "del"},
+		{"__keyevent@0__:del", "old"},
+	}, receiveMessages(t, ps, 4))
+	assert.Equal(t, int64(0), c.Exists("dst", "old").Val())
+
+	// Expired keys are notified by the expiry job
+	assert.NoError(t, c.Set("session", "1", 10*time.Second).Err())
+	assert.Equal(t, [][2]string{
+		{"__keyspace@0__:session", "set"},
+		{"__keyevent@0__:set", "session"},
+		{"__keyspace@0__:session", "expire"},
+		{"__keyevent@0__:expire", "session"},
+	}, receiveMessages(t, ps, 4))
+
+	assert.NoError(t, s.FastForward(11*time.Second))
+	assert.Equal(t, [][2]string{
+		{"__keyspace@0__:session", "expired"},
+		{"__keyevent@0__:expired", "session"},
+	}, receiveMessages(t, ps, 2))
+
+	// Only the classes that are enabled are notified
+	assert.NoError(t, c.ConfigSet("notify-keyspace-events", "Exn").Err())
+	assert.Error(t, c.ConfigSet("notify-keyspace-events", "Q").Err())
+	assert.NoError(t, c.Set("foo", "bar", 0).Err())
+	assert.NoError(t, c.Set("foo", "baz", 1*time.Second).Err())
+	assert.NoError(t, s.FastForward(2*time.Second))
+	assert.Equal(t, [][2]string{
+		{"__keyevent@0__:new", "foo"},
+		{"__keyevent@0__:expired", "foo"},
+	}, receiveMessages(t, ps, 2))
+
+	assert.Equal(t, int64(1), c.Publish("__keyevent@0__:new", "manual").Val())
+	assert.Equal(t, [][2]string{{"__keyevent@0__:new", "manual"}}, receiveMessages(t, ps, 1))
+}
+
+func TestSubscribeContext(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	_, err = conn.Write([]byte("*2\r\n$9\r\nSUBSCRIBE\r\n$2\r\nch\r\n" +
+		"*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n" +
+		"*1\r\n$4\r\nPING\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "*3\r\n$9\r\nsubscribe\r\n$2\r\nch\r\n:1\r\n", readLines(t, reader, 6))
+	assert.Equal(t, "-ERR Can't execute 'get': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / "+
+		"PING / QUIT / RESET are allowed in this context\r\n", readLines(t, reader, 1))
+	assert.Equal(t, "*2\r\n$4\r\npong\r\n$0\r\n\r\n", readLines(t, reader, 5))
+
+	c := redis.NewClient(&redis.Options{Addr: s.Addr().String()})
+	defer c.Close()
+
+	assert.Equal(t, int64(1), c.Publish("ch", "hello").Val())
+	assert.Equal(t, int64(0), c.Publish("other", "hello").Val())
+	assert.Equal(t, "*3\r\n$7\r\nmessage\r\n$2\r\nch\r\n$5\r\nhello\r\n", readLines(t, reader, 7))
+
+	_, err = conn.Write([]byte("*1\r\n$11\r\nUNSUBSCRIBE\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "*3\r\n$11\r\nunsubscribe\r\n$2\r\nch\r\n:0\r\n", readLines(t, reader, 6))
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	radish "github.com/hbina/radish"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectEncoding(t *testing.T) {
+	c := CreateTestClient()
+
+	encoding := func(key string) string {
+		return c.Do("object", "encoding", key).Val().(string)
+	}
+
+	assert.NoError(t, c.Set("int", "123", 0).Err())
+	assert.NoError(t, c.Set("embstr", "hello", 0).Err())
+	assert.NoError(t, c.Set("raw", strings.Repeat("x", 45), 0).Err())
+	assert.NoError(t, c.SetRange("setrange", 0, "a").Err())
+	assert.Equal(t, "int", encoding("int"))
+	assert.Equal(t, "embstr", encoding("embstr"))
+	assert.Equal(t, "raw", encoding("raw"))
+	assert.Equal(t, "raw", encoding("setrange"))
+
+	// Shared integers have the maximum reference count
+	assert.Equal(t, int64(2147483647), c.Do("object", "refcount", "int").Val())
+	assert.Equal(t, int64(1), c.Do("object", "refcount", "embstr").Val())
+
+	assert.NoError(t, c.RPush("list", "a", "b").Err())
+	assert.Equal(t, "listpack", encoding("list"))
+	assert.NoError(t, c.RPush("list", strings.Repeat("x", 10000)).Err())
+	assert.Equal(t, "quicklist", encoding("list"))
+
+	assert.NoError(t, c.SAdd("set", 1, 2, 3).Err())
+	assert.Equal(t, "intset", encoding("set"))
+	assert.NoError(t, c.SAdd("set", "a").Err())
+	assert.Equal(t, "listpack", encoding("set"))
+	assert.NoError(t, c.SAdd("set", strings.Repeat("x", 65)).Err())
+	assert.Equal(t, "hashtable", encoding("set"))
+
+	// Sets are not converted back once they have outgrown the limits
+	assert.NoError(t, c.SRem("set", strings.Repeat("x", 65)).Err())
+	assert.Equal(t, "hashtable", encoding("set"))
+
+	assert.NoError(t, c.ZAdd("zset", redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"}).Err())
+	assert.Equal(t, "listpack", encoding("zset"))
+	assert.NoError(t, c.ZAdd("zset", redis.Z{Score: 3, Member: strings.Repeat("x", 65)}).Err())
+	assert.Equal(t, "skiplist", encoding("zset"))
+
+	assert.Equal(t, nil, c.Do("object", "encoding", "missing").Val())
+	assert.EqualError(t, c.Do("object", "foo", "int").Err(),
+		"ERR unknown subcommand or wrong number of arguments for 'foo'. Try OBJECT HELP.")
+}
+
+func TestObjectAccess(t *testing.T) {
+	s, c := newTestServer(t, radish.WithClock(radish.NewManualClock(time.Unix(1700000000, 0))))
+
+	assert.NoError(t, c.Set("foo", "bar", 0).Err())
+	assert.NoError(t, s.FastForward(10*time.Second))
+
+	// OBJECT does not touch the key
+	assert.Equal(t, int64(10), c.Do("object", "idletime", "foo").Val())
+	assert.Equal(t, int64(10), c.Do("object", "idletime", "foo").Val())
+	assert.NoError(t, c.Get("foo").Err())
+	assert.Equal(t, int64(0), c.Do("object", "idletime", "foo").Val())
+	assert.Error(t, c.Do("object", "freq", "foo").Err())
+
+	dump := c.Dump("foo").Val()
+	assert.NoError(t, c.Do("restore", "idle", 0, dump, "idletime", 1000).Err())
+	assert.Equal(t, int64(1000), c.Do("object", "idletime", "idle").Val())
+	assert.EqualError(t, c.Do("restore", "other", 0, dump, "idletime", -1).Err(),
+		"ERR Invalid IDLETIME value, must be >= 0")
+	assert.EqualError(t, c.Do("restore", "other", 0, dump, "freq", 256).Err(),
+		"ERR Invalid FREQ value, must be >= 0 and <= 255")
+
+	assert.NoError(t, c.ConfigSet("maxmemory-policy", "allkeys-lfu").Err())
+	assert.NoError(t, c.Do("restore", "freq", 0, dump, "freq", 100).Err())
+	assert.Equal(t, int64(100), c.Do("object", "freq", "freq").Val())
+	assert.Error(t, c.Do("object", "idletime", "freq").Err())
+
+	// The counter of a new key starts at LFU_INIT_VAL and decays every minute
+	assert.NoError(t, c.Set("new", "1", 0).Err())
+	assert.Equal(t, int64(5), c.Do("object", "freq", "new").Val())
+	assert.NoError(t, s.FastForward(3*time.Minute))
+	assert.Equal(t, int64(2), c.Do("object", "freq", "new").Val())
+}
@@ -13,7 +13,7 @@ import (
 )
 
 var dbId int64 = 0
-var port int = 6381
+var port int
 
 func CreateTestClient() *redis.Client {
 	c := redis.NewClient(&redis.Options{
@@ -23,9 +23,34 @@ func CreateTestClient() *redis.Client {
 	return c
 }
 
+// newTestServer starts a server of its own, for the tests that need options
+// or look at the state of the whole server, and returns it with a client.
+// Both are closed at the end of the test.
+func newTestServer(t *testing.T, opts ...radish.Option) (*radish.Server, *redis.Client) {
+	t.Helper()
+
+	s := radish.NewServer(append([]radish.Option{radish.WithAddress("127.0.0.1:0")}, opts...)...)
+
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { s.Close() })
+
+	c := redis.NewClient(&redis.Options{Addr: s.Addr().String()})
+	t.Cleanup(func() { c.Close() })
+
+	return s, c
+}
+
 func init() {
-	go radish.Run(port, false)
-	time.Sleep(1 * time.Second)
+	server := radish.NewServer(radish.WithAddress("localhost:0"))
+
+	if err := server.Start(); err != nil {
+		panic(err)
+	}
+
+	port = server.Addr().(*net.TCPAddr).Port
 }
 
 func TestPingCommand(t *testing.T) {
@@ -115,14 +140,28 @@ func TestTtlCommand(t *testing.T) {
 	assert.Equal(t, time.Duration(-1000000000), ttl)
 }
 
+func TestConfigSetRollback(t *testing.T) {
+	_, c := newTestServer(t)
+
+	// The hooks that have succeeded are called again with the old values
+	assert.Error(t, c.Do("config", "set", "slowlog-log-slower-than", "0", "slowlog-max-len", "-1").Err())
+	assert.Equal(t, []interface{}{"slowlog-log-slower-than", "10000"}, c.ConfigGet("slowlog-log-slower-than").Val())
+
+	assert.NoError(t, c.Do("slowlog", "reset").Err())
+	assert.NoError(t, c.Ping().Err())
+	assert.Equal(t, int64(0), c.Do("slowlog", "len").Val())
+}
+
 func TestExpiry(t *testing.T) {
-	c := CreateTestClient()
+	server, c := newTestServer(t, radish.WithClock(radish.NewManualClock(time.Unix(1700000000, 0))))
 
 	s, err := c.Set("x", "val", 10*time.Millisecond).Result()
 	assert.NoError(t, err)
 	assert.Equal(t, "OK", s)
 
-	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, server.FastForward(9*time.Millisecond))
+	assert.NoError(t, c.Get("x").Err())
+	assert.NoError(t, server.FastForward(2*time.Millisecond))
 
 	_, err = c.Get("x").Result()
 	assert.Error(t, err)
@@ -265,7 +304,7 @@ func TestRestoreCommand(t *testing.T) {
 }
 
 func TestBadRespCommand(t *testing.T) {
-	tcpAddr, err := net.ResolveTCPAddr("tcp", "localhost:6381")
+	tcpAddr, err := net.ResolveTCPAddr("tcp", fmt.Sprintf("localhost:%d", port))
 	assert.NoError(t, err)
 
 	tcpConn, err := net.DialTCP("tcp", nil, tcpAddr)
@@ -466,3 +505,49 @@ func TestZrankCommand(t *testing.T) {
 		assert.Equal(t, int64(0), r2)
 	}
 }
+
+func TestAuthCommand(t *testing.T) {
+	c := CreateTestClient()
+
+	_, err := c.Do("auth", "foo").Result()
+	assert.Error(t, err)
+
+	s, err := c.ConfigSet("requirepass", "foobar").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", s)
+
+	// Existing connections remain authenticated
+	_, err = c.Ping().Result()
+	assert.NoError(t, err)
+
+	{
+		c2 := redis.NewClient(&redis.Options{
+			Addr: fmt.Sprintf("localhost:%d", port),
+		})
+
+		_, err = c2.Get("foo").Result()
+		assert.Equal(t, "NOAUTH Authentication required.", err.Error())
+
+		_, err = c2.Do("auth", "wrong").Result()
+		assert.Equal(t, "WRONGPASS invalid username-password pair or user is disabled.", err.Error())
+
+		c2.Close()
+	}
+
+	{
+		c3 := redis.NewClient(&redis.Options{
+			Addr:     fmt.Sprintf("localhost:%d", port),
+			Password: "foobar",
+		})
+
+		s, err = c3.Ping().Result()
+		assert.NoError(t, err)
+		assert.Equal(t, "PONG", s)
+
+		s, err = c3.ConfigSet("requirepass", "").Result()
+		assert.NoError(t, err)
+		assert.Equal(t, "OK", s)
+
+		c3.Close()
+	}
+}
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	radish "github.com/hbina/radish"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEval(t *testing.T) {
+	_, c := newTestServer(t)
+
+	// The conversions of the Lua values to the replies
+	assert.Equal(t, int64(3), c.Eval("return 3.99", nil).Val())
+	assert.Equal(t, "foo", c.Eval("return 'foo'", nil).Val())
+	assert.Equal(t, []interface{}{int64(1), "a", []interface{}{int64(2)}},
+		c.Eval("return {1, 'a', {2}, nil, 3}", nil).Val())
+	assert.Equal(t, int64(1), c.Eval("return true", nil).Val())
+	assert.Equal(t, redis.Nil, c.Eval("return false", nil).Err())
+	assert.Equal(t, "PONG", c.Eval("return redis.status_reply('PONG')", nil).Val())
+	assert.EqualError(t, c.Eval("return redis.error_reply('MY error')", nil).Err(), "MY error")
+
+	// The conversions of the replies to the Lua values
+	assert.Equal(t, "OK", c.Eval("return redis.call('set', KEYS[1], ARGV[1])", []string{"foo"}, "bar").Val())
+	assert.Equal(t, []interface{}{"bar", int64(2), int64(1)},
+		c.Eval("return {redis.call('get', KEYS[1]), redis.call('rpush', KEYS[2], 'a', 'b'), "+
+			"redis.call('get', 'missing') == false and 1 or 0}", []string{"foo", "list"}).Val())
+	assert.Equal(t, "string", c.Eval("return redis.call('type', 'foo').ok", nil).Val())
+
+	// redis.call raises the errors while redis.pcall returns them
+	sha := c.ScriptLoad("return redis.call('incr', KEYS[1])").Val()
+	assert.EqualError(t, c.EvalSha(sha, []string{"foo"}).Err(),
+		"ERR value is not an integer or out of range script: "+sha+", on @user_script:1.")
+	assert.EqualError(t, c.Eval("return redis.pcall('incr', KEYS[1])", []string{"foo"}).Err(),
+		"ERR value is not an integer or out of range")
+	assert.Equal(t, "caught", c.Eval("local ok = pcall(redis.call, 'incr', KEYS[1]) "+
+		"if not ok then return 'caught' end", []string{"foo"}).Val())
+	sha = c.ScriptLoad("return redis.call('nosuchcommand')").Val()
+	assert.EqualError(t, c.EvalSha(sha, nil).Err(),
+		"ERR Unknown Redis command called from script script: "+sha+", on @user_script:1.")
+
+	// The scripts can not use global variables
+	sha = c.ScriptLoad("x = 1").Val()
+	assert.EqualError(t, c.EvalSha(sha, nil).Err(),
+		"ERR user_script:1: Script attempted to create global variable 'x' script: "+sha+", on @user_script:1.")
+	assert.EqualError(t, c.Eval("return (", nil).Err(),
+		"ERR Error compiling script (new function): user_script at EOF: syntax error")
+
+	// EVALSHA only runs the cached scripts
+	sha = c.ScriptLoad("return ARGV[1]").Val()
+	assert.Equal(t, []bool{true, false}, c.ScriptExists(sha, "ffffffffffffffffffffffffffffffffffffffff").Val())
+	assert.Equal(t, "a", c.EvalSha(sha, nil, "a").Val())
+	assert.NoError(t, c.ScriptFlush().Err())
+	assert.EqualError(t, c.EvalSha(sha, nil, "a").Err(), "NOSCRIPT No matching script. Please use EVAL.")
+
+	// The read-only scripts can not write
+	assert.Equal(t, "bar", c.Do("eval_ro", "return redis.call('get', KEYS[1])", 1, "foo").Val())
+	assert.Error(t, c.Do("eval_ro", "return redis.call('del', KEYS[1])", 1, "foo").Err())
+	assert.Equal(t, int64(1), c.Exists("foo").Val())
+
+	assert.EqualError(t, c.Do("eval", "return 1", 2, "a").Err(), "ERR Number of keys can't be greater than number of args")
+}
+
+func TestScriptReadonly(t *testing.T) {
+	_, c := newTestServer(t)
+
+	assert.NoError(t, c.Set("x", "value", 0).Err())
+
+	// The interpreters are shared so the libraries and the globals can not be changed
+	for _, script := range []string{
+		"redis.call = function() return 'hijacked' end",
+		"string.rep = nil",
+		"math.random = function() return 4 end",
+		"table.insert = nil",
+		"redis = {}",
+		"tostring = nil",
+		"rawset(_G, 'redis', {})",
+		"rawset(redis, 'call', nil)",
+		"setfenv(0, {})",
+	} {
+		err := c.Eval(script, nil).Err()
+		if assert.Error(t, err, script) {
+			assert.Contains(t, err.Error(), "Attempt to modify a readonly table", script)
+		}
+	}
+
+	assert.Contains(t, c.Eval("setmetatable(_G, nil)", nil).Err().Error(), "cannot change a protected metatable")
+	assert.Contains(t, c.Eval("getmetatable('').__index.rep = nil", nil).Err().Error(), "attempt to index")
+	assert.Equal(t, "value", c.Eval("return redis.call('get', 'x')", nil).Val())
+	assert.Equal(t, "aa", c.Eval("return string.rep('a', 2)", nil).Val())
+
+	// The tables of the scripts can still be modified
+	assert.Equal(t, int64(3), c.Eval("local t = {} t.a = 1 table.insert(t, 2) rawset(t, 'b', 0) "+
+		"return t.a + t[1] + t.b", nil).Val())
+}
+
+func TestScriptKill(t *testing.T) {
+	s, c2 := newTestServer(t, radish.WithConfigs(map[string]string{
+		"lua-time-limit": "10",
+	}))
+
+	c1 := redis.NewClient(&redis.Options{Addr: s.Addr().String(), ReadTimeout: time.Minute})
+	defer c1.Close()
+
+	assert.EqualError(t, c2.ScriptKill().Err(), "NOTBUSY No scripts in execution right now.")
+
+	done := make(chan error)
+
+	go func() {
+		done <- c1.Eval("while true do end", nil).Err()
+	}()
+
+	// The other clients are busy until the script is killed
+	assert.Eventually(t, func() bool {
+		err := c2.Ping().Err()
+		return err != nil && err.Error() == "BUSY Redis is busy running a script. You can only call SCRIPT KILL or SHUTDOWN NOSAVE."
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, c2.ScriptKill().Err())
+	assert.EqualError(t, <-done, "ERR Script killed by user with SCRIPT KILL...")
+	assert.Equal(t, "PONG", c2.Ping().Val())
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	radish "github.com/hbina/radish"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerInstances(t *testing.T) {
+	s1 := radish.NewServer(radish.WithAddress("127.0.0.1:0"))
+	s2 := radish.NewServer(radish.WithAddress("127.0.0.1:0"))
+
+	assert.NoError(t, s1.Start())
+	assert.NoError(t, s2.Start())
+	assert.Error(t, s1.Start())
+	assert.NotEqual(t, s1.Addr().String(), s2.Addr().String())
+
+	c1 := redis.NewClient(&redis.Options{Addr: s1.Addr().String()})
+	defer c1.Close()
+	c2 := redis.NewClient(&redis.Options{Addr: s2.Addr().String()})
+	defer c2.Close()
+
+	// The instances do not share their databases
+	assert.NoError(t, c1.Set("foo", "bar", 0).Err())
+	assert.Equal(t, redis.Nil, c2.Get("foo").Err())
+
+	// The bound port is reported in the configurations
+	v, err := c1.ConfigGet("port").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"port", s1.Addr().String()[len("127.0.0.1:"):]}, v)
+
+	assert.NoError(t, s1.Close())
+	assert.Error(t, c1.Ping().Err())
+	assert.NoError(t, c2.Ping().Err())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, s2.Shutdown(ctx))
+	assert.Error(t, c2.Ping().Err())
+
+	select {
+	case <-s2.Done():
+	default:
+		t.Error("Done is not closed after Shutdown")
+	}
+}
+
+func TestServerFastForward(t *testing.T) {
+	s, c := newTestServer(t, radish.WithClock(radish.NewManualClock(time.Unix(1700000000, 0))))
+
+	assert.NoError(t, c.Set("foo", "bar", 10*time.Second).Err())
+
+	ttl, err := c.TTL("foo").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, ttl)
+
+	assert.NoError(t, s.FastForward(9*time.Second))
+	assert.NoError(t, c.Get("foo").Err())
+
+	assert.NoError(t, s.FastForward(2*time.Second))
+	assert.Equal(t, redis.Nil, c.Get("foo").Err())
+
+	// Blocked commands time out when the clock reaches their timeout
+	res := make(chan error)
+
+	go func() {
+		res <- c.BZPopMin(30*time.Second, "myzset").Err()
+	}()
+
+	assert.Eventually(t, func() bool {
+		assert.NoError(t, s.FastForward(10*time.Second))
+
+		select {
+		case err := <-res:
+			assert.Equal(t, redis.Nil, err)
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Only manual clocks can be fast forwarded
+	s2, _ := newTestServer(t)
+	assert.Error(t, s2.FastForward(time.Second))
+}
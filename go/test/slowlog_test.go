@@ -0,0 +1,82 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redis"
+	radish "github.com/hbina/radish"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlowlog(t *testing.T) {
+	_, c := newTestServer(t, radish.WithConfigs(map[string]string{
+		"slowlog-log-slower-than": "0",
+		"slowlog-max-len":         "3",
+	}))
+
+	assert.NoError(t, c.Do("slowlog", "reset").Err())
+	assert.NoError(t, c.Set("foo", strings.Repeat("x", 200), 0).Err())
+	assert.Error(t, c.Do("auth", "secret").Err())
+	assert.NoError(t, c.Ping().Err())
+
+	entries, err := c.Do("slowlog", "get").Result()
+	assert.NoError(t, err)
+
+	// The newest entry is first and the oldest one has been dropped
+	assert.Len(t, entries, 3)
+	assert.Equal(t, []interface{}{"ping"}, entries.([]interface{})[0].([]interface{})[3])
+	assert.Equal(t, []interface{}{"auth", "(redacted)"}, entries.([]interface{})[1].([]interface{})[3])
+
+	set := entries.([]interface{})[2].([]interface{})
+	assert.Equal(t, int64(1), set[0])
+	assert.Equal(t, []interface{}{"set", "foo", strings.Repeat("x", 128) + "... (72 more bytes)"}, set[3])
+	assert.Contains(t, set[4], "127.0.0.1:")
+
+	entries, err = c.Do("slowlog", "get", "1").Result()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.Equal(t, int64(3), c.Do("slowlog", "len").Val())
+	assert.Error(t, c.Do("slowlog", "get", "-2").Err())
+
+	// Disabled with a negative threshold
+	assert.NoError(t, c.ConfigSet("slowlog-log-slower-than", "-1").Err())
+	assert.NoError(t, c.Do("slowlog", "reset").Err())
+	assert.NoError(t, c.Ping().Err())
+	assert.Equal(t, int64(0), c.Do("slowlog", "len").Val())
+
+	assert.Error(t, c.ConfigSet("slowlog-max-len", "-1").Err())
+
+	// The buffer is not allocated up front and shrinking keeps the newest entries
+	assert.NoError(t, c.ConfigSet("slowlog-max-len", "2147483647").Err())
+	assert.NoError(t, c.ConfigSet("slowlog-log-slower-than", "0").Err())
+	assert.Equal(t, redis.Nil, c.Get("a").Err())
+	assert.Equal(t, redis.Nil, c.Get("b").Err())
+	assert.Equal(t, int64(3), c.Do("slowlog", "len").Val())
+
+	assert.NoError(t, c.ConfigSet("slowlog-max-len", "2").Err())
+	entries, err = c.Do("slowlog", "get").Result()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, []interface{}{"config", "set", "slowlog-max-len", "2"}, entries.([]interface{})[0].([]interface{})[3])
+	assert.Equal(t, []interface{}{"slowlog", "len"}, entries.([]interface{})[1].([]interface{})[3])
+
+	// The passwords of the users and the sensitive configurations are not logged
+	assert.NoError(t, c.Do("acl", "setuser", "bob", "on", ">secret", "#"+strings.Repeat("a", 64), "~*").Err())
+	assert.NoError(t, c.ConfigSet("masterauth", "secret").Err())
+	assert.NoError(t, c.Do("config", "set", "slowlog-max-len", "2", "masterauth", "secret").Err())
+	entries, err = c.Do("slowlog", "get").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"config", "set", "slowlog-max-len", "2", "masterauth", "(redacted)"},
+		entries.([]interface{})[0].([]interface{})[3])
+	assert.Equal(t, []interface{}{"config", "set", "masterauth", "(redacted)"},
+		entries.([]interface{})[1].([]interface{})[3])
+
+	assert.NoError(t, c.ConfigSet("slowlog-max-len", "3").Err())
+	assert.NoError(t, c.Do("acl", "setuser", "bob", "<secret", "#"+strings.Repeat("b", 64), "~*").Err())
+	entries, err = c.Do("slowlog", "get", "1").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"acl", "setuser", "bob", "(redacted)", "(redacted)", "~*"},
+		entries.([]interface{})[0].([]interface{})[3])
+}
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppend(t *testing.T) {
+	c := CreateTestClient()
+
+	assert.Equal(t, int64(5), c.Append("log", "Hello").Val())
+	assert.Equal(t, int64(11), c.Append("log", " World").Val())
+	assert.Equal(t, "Hello World", c.Get("log").Val())
+
+	for i := 0; i < 1000; i++ {
+		c.Append("many", "ab")
+	}
+
+	assert.Equal(t, strings.Repeat("ab", 1000), c.Get("many").Val())
+
+	// The counters become strings once they are appended to, with their expiry
+	assert.NoError(t, c.Set("counter", "10", time.Minute).Err())
+	assert.Equal(t, int64(3), c.Append("counter", "0").Val())
+	assert.Equal(t, "raw", c.ObjectEncoding("counter").Val())
+	assert.Equal(t, int64(101), c.Incr("counter").Val())
+	assert.True(t, c.TTL("counter").Val() > 0)
+
+	assert.NoError(t, c.LPush("list", "a").Err())
+	assert.Error(t, c.Append("list", "a").Err())
+}
+
+func TestIncrDecr(t *testing.T) {
+	c := CreateTestClient()
+
+	assert.Equal(t, int64(1), c.Incr("counter").Val())
+	assert.Equal(t, int64(11), c.IncrBy("counter", 10).Val())
+	assert.Equal(t, int64(10), c.Decr("counter").Val())
+	assert.Equal(t, int64(-5), c.DecrBy("missing", 5).Val())
+	assert.Equal(t, "int", c.ObjectEncoding("counter").Val())
+
+	assert.NoError(t, c.Set("max", "9223372036854775807", 0).Err())
+	assert.EqualError(t, c.Incr("max").Err(), "ERR increment or decrement would overflow")
+	assert.NoError(t, c.Set("min", "-9223372036854775808", 0).Err())
+	assert.EqualError(t, c.Decr("min").Err(), "ERR increment or decrement would overflow")
+	assert.EqualError(t, c.IncrBy("min", -1).Err(), "ERR increment or decrement would overflow")
+	assert.Equal(t, int64(-1), c.IncrBy("min", 9223372036854775807).Val())
+	assert.EqualError(t, c.Do("decrby", "min", "-9223372036854775808").Err(), "ERR decrement would overflow")
+
+	// Only the integers in their canonical form are counters
+	assert.NoError(t, c.Set("padded", "007", 0).Err())
+	assert.EqualError(t, c.Incr("padded").Err(), "ERR value is not an integer or out of range")
+	assert.NoError(t, c.LPush("list", "a").Err())
+	assert.Error(t, c.Incr("list").Err())
+}
+
+func TestPsetex(t *testing.T) {
+	c := CreateTestClient()
+
+	assert.Equal(t, "OK", c.Do("psetex", "key", 100000, "value").Val())
+	assert.Equal(t, "value", c.Get("key").Val())
+	assert.True(t, c.PTTL("key").Val() > 99*time.Second)
+	assert.EqualError(t, c.Do("psetex", "key", 0, "value").Err(), "ERR invalid expire time in 'psetex' command")
+	assert.EqualError(t, c.Do("setex", "key", -1, "value").Err(), "ERR invalid expire time in 'setex' command")
+}
+
+func TestGetrangeSetrange(t *testing.T) {
+	c := CreateTestClient()
+
+	assert.NoError(t, c.Set("mykey", "This is a string", 0).Err())
+	assert.Equal(t, "This", c.GetRange("mykey", 0, 3).Val())
+	assert.Equal(t, "ing", c.GetRange("mykey", -3, -1).Val())
+	assert.Equal(t, "This is a string", c.GetRange("mykey", 0, -1).Val())
+	assert.Equal(t, "string", c.GetRange("mykey", 10, 100).Val())
+	assert.Equal(t, "This is a string", c.GetRange("mykey", -100, 9223372036854775807).Val())
+	assert.Equal(t, "", c.GetRange("mykey", -1, -5).Val())
+	// Like in Redis, the indices before the start are clamped to the first byte
+	assert.Equal(t, "T", c.GetRange("mykey", 0, -100).Val())
+	assert.Equal(t, "T", c.GetRange("mykey", -100, -16).Val())
+	assert.Equal(t, "", c.GetRange("mykey", 16, 20).Val())
+	assert.Equal(t, "is", c.Do("substr", "mykey", 5, 6).Val())
+	assert.Equal(t, "", c.GetRange("missing", 0, -1).Val())
+
+	assert.NoError(t, c.Set("int", "12345", 0).Err())
+	assert.Equal(t, "234", c.GetRange("int", 1, 3).Val())
+
+	// The strings are padded with zeros, keeping their expiry
+	assert.NoError(t, c.Set("key1", "Hello World", time.Minute).Err())
+	assert.Equal(t, int64(11), c.SetRange("key1", 6, "Redis").Val())
+	assert.Equal(t, "Hello Redis", c.Get("key1").Val())
+	assert.True(t, c.TTL("key1").Val() > 0)
+	assert.Equal(t, int64(11), c.SetRange("key2", 6, "Redis").Val())
+	assert.Equal(t, "\x00\x00\x00\x00\x00\x00Redis", c.Get("key2").Val())
+
+	// An empty value does not create the key
+	assert.Equal(t, int64(0), c.SetRange("key3", 10, "").Val())
+	assert.Equal(t, int64(0), c.Exists("key3").Val())
+	assert.Equal(t, int64(11), c.SetRange("key1", 100, "").Val())
+
+	assert.EqualError(t, c.SetRange("key1", -1, "a").Err(), "ERR offset is out of range")
+	assert.EqualError(t, c.SetRange("key1", 536870912, "a").Err(),
+		"ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+	assert.EqualError(t, c.SetRange("key1", math.MaxInt64, "a").Err(),
+		"ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+	assert.Equal(t, "Hello Redis", c.Get("key1").Val())
+}
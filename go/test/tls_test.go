@@ -0,0 +1,153 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	radish "github.com/hbina/radish"
+	"github.com/hbina/radish/internal/commands"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// createTestCert creates a certificate signed by parent or a self-signed CA if parent is nil.
+func createTestCert(t *testing.T, dir string, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(1 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+
+	signer := template
+	signerKey := key
+
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer = parent.cert
+		signerKey = parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	assert.NoError(t, err)
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	assert.NoError(t, err)
+
+	return &testCert{cert: cert, key: key, certFile: certFile, keyFile: keyFile}
+}
+
+func TestTlsListener(t *testing.T) {
+	dir := t.TempDir()
+	ca := createTestCert(t, dir, "ca", nil)
+	server := createTestCert(t, dir, "server", ca)
+	client := createTestCert(t, dir, "client", ca)
+
+	configs := commands.GenerateConfigs()
+	configs["port"] = "0"
+	configs["tls-port"] = "6390"
+	configs["tls-cert-file"] = server.certFile
+	configs["tls-key-file"] = server.keyFile
+	configs["tls-ca-cert-file"] = ca.certFile
+
+	srv := radish.NewServer(radish.WithConfigs(configs))
+	assert.NoError(t, srv.Start())
+	defer srv.Close()
+
+	addr := srv.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	clientCert, err := tls.LoadX509KeyPair(client.certFile, client.keyFile)
+	assert.NoError(t, err)
+
+	// Clients with a valid certificate are accepted
+	c := redis.NewClient(&redis.Options{
+		Addr: addr,
+		TLSConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: []tls.Certificate{clientCert},
+		},
+	})
+	defer c.Close()
+
+	s, err := c.Ping().Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "PONG", s)
+
+	// Clients without certificate are rejected
+	{
+		c2 := redis.NewClient(&redis.Options{
+			Addr:       addr,
+			TLSConfig:  &tls.Config{RootCAs: roots},
+			MaxRetries: 0,
+		})
+
+		_, err = c2.Ping().Result()
+		assert.Error(t, err)
+
+		c2.Close()
+	}
+
+	// Reloading a bad certificate keeps the current one
+	_, err = c.ConfigSet("tls-cert-file", filepath.Join(dir, "missing.crt")).Result()
+	assert.Error(t, err)
+
+	v, err := c.ConfigGet("tls-cert-file").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"tls-cert-file", server.certFile}, v)
+
+	// Reloading a new certificate is picked up by new connections
+	server2 := createTestCert(t, dir, "server2", ca)
+
+	r, err := c.Do("config", "set", "tls-cert-file", server2.certFile, "tls-key-file", server2.keyFile).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", r)
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{clientCert},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, server2.cert.SerialNumber, conn.ConnectionState().PeerCertificates[0].SerialNumber)
+	conn.Close()
+}
@@ -0,0 +1,244 @@
+package test
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// readLines reads the given number of lines and joins them.
+func readLines(t *testing.T, reader *bufio.Reader, n int) string {
+	var str strings.Builder
+
+	for i := 0; i < n; i++ {
+		line, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+		str.WriteString(line)
+	}
+
+	return str.String()
+}
+
+func TestTrackingResp3(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	// Skip the reply of HELLO
+	_, err = conn.Write([]byte("*2\r\n$5\r\nHELLO\r\n$1\r\n3\r\n*1\r\n$4\r\nPING\r\n"))
+	assert.NoError(t, err)
+
+	for {
+		line, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+
+		if line == "+PONG\r\n" {
+			break
+		}
+	}
+
+	_, err = conn.Write([]byte("*3\r\n$6\r\nCLIENT\r\n$8\r\nTRACKING\r\n$2\r\nON\r\n" +
+		"*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "+OK\r\n_\r\n", readLines(t, reader, 2))
+
+	c := redis.NewClient(&redis.Options{Addr: s.Addr().String()})
+	defer c.Close()
+
+	// The key is only invalidated once until it is read again
+	assert.NoError(t, c.Set("foo", "bar", 0).Err())
+	assert.NoError(t, c.Set("foo", "baz", 0).Err())
+	assert.Equal(t, ">2\r\n$10\r\ninvalidate\r\n*1\r\n$3\r\nfoo\r\n", readLines(t, reader, 6))
+
+	_, err = conn.Write([]byte("*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "$3\r\nbaz\r\n", readLines(t, reader, 2))
+
+	assert.NoError(t, c.FlushAll().Err())
+	assert.Equal(t, ">2\r\n$10\r\ninvalidate\r\n_\r\n", readLines(t, reader, 4))
+
+	// Only the keys read after CLIENT CACHING YES are tracked in OPTIN mode
+	_, err = conn.Write([]byte("*4\r\n$6\r\nCLIENT\r\n$8\r\nTRACKING\r\n$2\r\nON\r\n$5\r\nOPTIN\r\n" +
+		"*2\r\n$3\r\nGET\r\n$1\r\na\r\n" +
+		"*3\r\n$6\r\nCLIENT\r\n$7\r\nCACHING\r\n$3\r\nYES\r\n" +
+		"*2\r\n$3\r\nGET\r\n$1\r\nb\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "+OK\r\n_\r\n+OK\r\n_\r\n", readLines(t, reader, 4))
+
+	assert.NoError(t, c.Set("a", "1", 0).Err())
+	assert.NoError(t, c.Set("b", "1", 0).Err())
+	assert.Equal(t, ">2\r\n$10\r\ninvalidate\r\n*1\r\n$1\r\nb\r\n", readLines(t, reader, 6))
+}
+
+func TestTrackingRedirect(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	_, err = conn.Write([]byte("*2\r\n$6\r\nCLIENT\r\n$2\r\nID\r\n"))
+	assert.NoError(t, err)
+
+	line, err := reader.ReadString('\n')
+	assert.NoError(t, err)
+	id := strings.TrimSuffix(strings.TrimPrefix(line, ":"), "\r\n")
+
+	// RESP2 clients receive the invalidations on the pubsub channel
+	_, err = conn.Write([]byte("*2\r\n$9\r\nSUBSCRIBE\r\n$20\r\n__redis__:invalidate\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "*3\r\n$9\r\nsubscribe\r\n$20\r\n__redis__:invalidate\r\n:1\r\n", readLines(t, reader, 6))
+
+	c := redis.NewClient(&redis.Options{Addr: s.Addr().String(), PoolSize: 1})
+	defer c.Close()
+
+	assert.Equal(t, "ERR The client ID you want redirect to does not exist",
+		c.Do("client", "tracking", "on", "redirect", "12345").Err().Error())
+	assert.Equal(t, "ERR PREFIX option requires BCAST mode to be enabled",
+		c.Do("client", "tracking", "on", "prefix", "user:").Err().Error())
+
+	assert.NoError(t, c.Do("client", "tracking", "on", "redirect", id, "bcast", "prefix", "user:", "noloop").Err())
+
+	redir, err := c.Do("client", "getredir").Int64()
+	assert.NoError(t, err)
+	assert.Equal(t, id, strconv.FormatInt(redir, 10))
+
+	list, err := c.Do("client", "list").String()
+	assert.NoError(t, err)
+	assert.Contains(t, list, " flags=tB ")
+	assert.Contains(t, list, " redir="+id+" ")
+
+	other := redis.NewClient(&redis.Options{Addr: s.Addr().String()})
+	defer other.Close()
+
+	// Keys are broadcasted by prefix, except those written by the client itself
+	assert.NoError(t, c.Set("user:1", "a", 0).Err())
+	assert.NoError(t, other.Set("session:1", "a", 0).Err())
+	assert.NoError(t, other.Set("user:2", "a", 0).Err())
+
+	assert.Equal(t, "*3\r\n$7\r\nmessage\r\n$20\r\n__redis__:invalidate\r\n*1\r\n$6\r\nuser:2\r\n", readLines(t, reader, 8))
+
+	assert.NoError(t, c.Do("client", "tracking", "off").Err())
+	assert.Equal(t, int64(-1), c.Do("client", "getredir").Val())
+}
+
+func TestTrackingRedirectBroken(t *testing.T) {
+	s, c := newTestServer(t)
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	// RESP2 clients only receive the invalidations on the tracking channel
+	target, err := net.Dial("tcp", s.Addr().String())
+	assert.NoError(t, err)
+	defer target.Close()
+
+	target.SetReadDeadline(time.Now().Add(5 * time.Second))
+	targetReader := bufio.NewReader(target)
+
+	_, err = target.Write([]byte("*2\r\n$6\r\nCLIENT\r\n$2\r\nID\r\n" +
+		"*2\r\n$9\r\nSUBSCRIBE\r\n$5\r\nother\r\n"))
+	assert.NoError(t, err)
+
+	line, err := targetReader.ReadString('\n')
+	assert.NoError(t, err)
+	redirect := strings.TrimSuffix(strings.TrimPrefix(line, ":"), "\r\n")
+	assert.Equal(t, "*3\r\n$9\r\nsubscribe\r\n$5\r\nother\r\n:1\r\n", readLines(t, targetReader, 6))
+
+	_, err = conn.Write([]byte("*2\r\n$5\r\nHELLO\r\n$1\r\n3\r\n*1\r\n$4\r\nPING\r\n"))
+	assert.NoError(t, err)
+
+	for {
+		line, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+
+		if line == "+PONG\r\n" {
+			break
+		}
+	}
+
+	_, err = conn.Write([]byte("*5\r\n$6\r\nCLIENT\r\n$8\r\nTRACKING\r\n$2\r\nON\r\n$8\r\nREDIRECT\r\n" +
+		"$" + strconv.Itoa(len(redirect)) + "\r\n" + redirect + "\r\n" +
+		"*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "+OK\r\n_\r\n", readLines(t, reader, 2))
+
+	assert.NoError(t, c.Set("foo", "bar", 0).Err())
+	assert.NoError(t, c.Publish("other", "hello").Err())
+
+	assert.Equal(t, "*3\r\n$7\r\nmessage\r\n$5\r\nother\r\n$5\r\nhello\r\n", readLines(t, targetReader, 7))
+
+	// The client is told once the client it redirects to is gone
+	_, err = conn.Write([]byte("*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "$3\r\nbar\r\n", readLines(t, reader, 2))
+
+	assert.Equal(t, int64(1), c.Do("client", "kill", "id", redirect).Val())
+	assert.Eventually(t, func() bool {
+		return c.Do("client", "list", "id", redirect).Val() == ""
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, c.Set("foo", "baz", 0).Err())
+	assert.Equal(t, ">2\r\n$21\r\ntracking-redir-broken\r\n:"+redirect+"\r\n", readLines(t, reader, 4))
+}
+
+func TestTrackingModifiedInPlace(t *testing.T) {
+	s, c := newTestServer(t)
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	_, err = conn.Write([]byte("*2\r\n$6\r\nCLIENT\r\n$2\r\nID\r\n" +
+		"*2\r\n$9\r\nSUBSCRIBE\r\n$20\r\n__redis__:invalidate\r\n"))
+	assert.NoError(t, err)
+
+	line, err := reader.ReadString('\n')
+	assert.NoError(t, err)
+	id := strings.TrimSuffix(strings.TrimPrefix(line, ":"), "\r\n")
+	readLines(t, reader, 6)
+
+	tracking := redis.NewClient(&redis.Options{Addr: s.Addr().String(), PoolSize: 1})
+	defer tracking.Close()
+
+	assert.NoError(t, tracking.Do("client", "tracking", "on", "redirect", id).Err())
+
+	// The commands that modify the values in place invalidate the keys too
+	for _, cmd := range [][]interface{}{
+		{"spop", "set"},
+		{"zremrangebyscore", "zset", "1", "1"},
+		{"zremrangebyrank", "zset", 0, 0},
+		{"zremrangebylex", "zset", "-", "+"},
+	} {
+		assert.NoError(t, c.SAdd("set", "a", "b").Err())
+		assert.NoError(t, c.ZAdd("zset", redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"},
+			redis.Z{Score: 3, Member: "c"}).Err())
+
+		key := cmd[1].(string)
+		assert.NoError(t, tracking.Do("type", key).Err())
+		assert.NoError(t, c.Do(cmd...).Err())
+		assert.Equal(t, "*3\r\n$7\r\nmessage\r\n$20\r\n__redis__:invalidate\r\n*1\r\n$"+
+			strconv.Itoa(len(key))+"\r\n"+key+"\r\n", readLines(t, reader, 8), cmd[0])
+	}
+}
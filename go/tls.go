@@ -0,0 +1,284 @@
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/hbina/radish/internal/pkg"
+)
+
+// Configurations that requires the TLS context to be reloaded when changed.
+var tlsConfigs = []string{
+	"tls-cert-file",
+	"tls-key-file",
+	"tls-ca-cert-file",
+	"tls-ca-cert-dir",
+	"tls-auth-clients",
+	"tls-protocols",
+	"tls-ciphers",
+	"tls-ciphersuites",
+	"tls-session-caching",
+}
+
+var tlsProtocols = map[string]uint16{
+	"tlsv1":   tls.VersionTLS10,
+	"tlsv1.1": tls.VersionTLS11,
+	"tlsv1.2": tls.VersionTLS12,
+	"tlsv1.3": tls.VersionTLS13,
+}
+
+// OpenSSL names of the TLSv1.2 ciphers supported by Go.
+// The IANA names are also accepted.
+var tlsOpenSslCiphers = map[string]uint16{
+	"ECDHE-ECDSA-AES128-GCM-SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	"ECDHE-RSA-AES128-GCM-SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"ECDHE-ECDSA-AES256-GCM-SHA384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	"ECDHE-RSA-AES256-GCM-SHA384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	"ECDHE-ECDSA-CHACHA20-POLY1305": tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	"ECDHE-RSA-CHACHA20-POLY1305":   tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+	"ECDHE-ECDSA-AES128-SHA":        tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	"ECDHE-ECDSA-AES256-SHA":        tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	"ECDHE-RSA-AES128-SHA":          tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	"ECDHE-RSA-AES256-SHA":          tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	"AES128-GCM-SHA256":             tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	"AES256-GCM-SHA384":             tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	"AES128-SHA":                    tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	"AES256-SHA":                    tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+}
+
+// tlsContext holds the current TLS configuration so that it
+// can be swapped without restarting the listeners.
+type tlsContext struct {
+	config atomic.Pointer[tls.Config]
+}
+
+// newTlsContext creates the TLS context from the configurations of the instance
+// and registers the hooks that reload it when they are changed.
+func newTlsContext(instance *pkg.Redis) (*tlsContext, error) {
+	ctx := &tlsContext{}
+
+	err := ctx.reload(instance)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range tlsConfigs {
+		instance.RegisterConfigHook(key, func(r *pkg.Redis, value string) error {
+			return ctx.reload(r)
+		})
+	}
+
+	return ctx, nil
+}
+
+// reload loads the certificates again, the current configuration
+// is kept if there is any error.
+func (ctx *tlsContext) reload(instance *pkg.Redis) error {
+	config, err := loadTlsConfig(func(key string) string {
+		v := instance.GetConfigValue(key)
+
+		if v == nil {
+			return ""
+		}
+
+		return *v
+	})
+
+	if err != nil {
+		return err
+	}
+
+	ctx.config.Store(config)
+	return nil
+}
+
+// serverConfig returns the configuration used by the listeners.
+// Every new connection picks up the latest configuration.
+func (ctx *tlsContext) serverConfig() *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return ctx.config.Load(), nil
+		},
+	}
+}
+
+func loadTlsConfig(get func(key string) string) (*tls.Config, error) {
+	certFile := get("tls-cert-file")
+	keyFile := get("tls-key-file")
+
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("tls-cert-file and tls-key-file must be configured")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate '%s' and key '%s': %s", certFile, keyFile, err.Error())
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		MaxVersion:   tls.VersionTLS13,
+	}
+
+	// Setup client certificate authentication
+	caFile := get("tls-ca-cert-file")
+	caDir := get("tls-ca-cert-dir")
+
+	switch strings.ToLower(get("tls-auth-clients")) {
+	case "no":
+		config.ClientAuth = tls.NoClientCert
+	case "optional":
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	if caFile != "" || caDir != "" {
+		pool, err := loadCaCerts(caFile, caDir)
+
+		if err != nil {
+			return nil, err
+		}
+
+		config.ClientCAs = pool
+	} else if config.ClientAuth != tls.NoClientCert {
+		return nil, errors.New("either tls-ca-cert-file or tls-ca-cert-dir must be specified when tls-auth-clients is enabled")
+	}
+
+	if protocols := get("tls-protocols"); protocols != "" {
+		config.MinVersion = 0
+		config.MaxVersion = 0
+
+		for _, p := range strings.Fields(protocols) {
+			version, ok := tlsProtocols[strings.ToLower(p)]
+
+			if !ok {
+				return nil, fmt.Errorf("invalid tls-protocols specified '%s'", p)
+			}
+
+			if config.MinVersion == 0 || version < config.MinVersion {
+				config.MinVersion = version
+			}
+
+			if version > config.MaxVersion {
+				config.MaxVersion = version
+			}
+		}
+	}
+
+	if ciphers := get("tls-ciphers"); ciphers != "" {
+		suites, err := parseTlsCiphers(ciphers)
+
+		if err != nil {
+			return nil, err
+		}
+
+		config.CipherSuites = suites
+	}
+
+	// TLSv1.3 cipher suites are not configurable in Go, we only validate them.
+	if ciphersuites := get("tls-ciphersuites"); ciphersuites != "" {
+		for _, name := range strings.Split(ciphersuites, ":") {
+			if !isTls13CipherSuite(name) {
+				return nil, fmt.Errorf("failed to configure tls-ciphersuites '%s'", name)
+			}
+		}
+	}
+
+	config.SessionTicketsDisabled = strings.ToLower(get("tls-session-caching")) == "no"
+
+	return config, nil
+}
+
+func loadCaCerts(caFile string, caDir string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	files := make([]string, 0)
+
+	if caFile != "" {
+		files = append(files, caFile)
+	}
+
+	if caDir != "" {
+		entries, err := os.ReadDir(caDir)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls-ca-cert-dir '%s': %s", caDir, err.Error())
+		}
+
+		for _, e := range entries {
+			if !e.IsDir() {
+				files = append(files, filepath.Join(caDir, e.Name()))
+			}
+		}
+	}
+
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA certificate '%s': %s", f, err.Error())
+		}
+
+		if !pool.AppendCertsFromPEM(data) && f == caFile {
+			return nil, fmt.Errorf("failed to load CA certificate '%s': no certificates found", f)
+		}
+	}
+
+	return pool, nil
+}
+
+func parseTlsCiphers(ciphers string) ([]uint16, error) {
+	iana := make(map[string]uint16)
+
+	for _, s := range tls.CipherSuites() {
+		iana[s.Name] = s.ID
+	}
+
+	for _, s := range tls.InsecureCipherSuites() {
+		iana[s.Name] = s.ID
+	}
+
+	res := make([]uint16, 0)
+
+	for _, name := range strings.Split(ciphers, ":") {
+		// Exclusions are implicit since we only use the given ciphers
+		if name == "" || name[0] == '!' || name[0] == '-' {
+			continue
+		}
+
+		if id, ok := tlsOpenSslCiphers[name]; ok {
+			res = append(res, id)
+		} else if id, ok := iana[name]; ok {
+			res = append(res, id)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, fmt.Errorf("failed to configure tls-ciphers '%s'", ciphers)
+	}
+
+	return res, nil
+}
+
+func isTls13CipherSuite(name string) bool {
+	for _, s := range tls.CipherSuites() {
+		if s.Name == name {
+			for _, v := range s.SupportedVersions {
+				if v == tls.VersionTLS13 {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}